@@ -1,4 +1,7 @@
 #!/bin/sh
 
 go build -o bin/update github.com/Encinarus/genconplanner/cmd/update && \
+go build -o bin/backfill github.com/Encinarus/genconplanner/cmd/backfill && \
+go build -o bin/export github.com/Encinarus/genconplanner/cmd/export && \
+go build -o bin/fakefeed github.com/Encinarus/genconplanner/cmd/fakefeed && \
 go build -o bin/web github.com/Encinarus/genconplanner/cmd/web
\ No newline at end of file
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Encinarus/genconplanner/internal/background"
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+var dir = flag.String("dir", "", "Directory of archived xlsx, csv and ndjson event files")
+var strict = flag.Bool("strict", false, "Fail on the first bad row instead of skipping it")
+var dryRun = flag.Bool("dry-run", false, "Show what the backfill would change without saving it")
+var verbose = flag.Bool("verbose", false, "Print every event changed, not just the counts")
+var force = flag.Bool("force", false, "Backfill years that are already imported live, or when no import has recorded which year is")
+var maxDeactivated = flag.Float64("max-deactivated-percent", postgres.DefaultImportLimits.MaxDeactivatedPercent, "Fail a year if it would deactivate more than this percent of its active events")
+var minRows = flag.Float64("min-row-percent", postgres.DefaultImportLimits.MinRowPercent, "Fail a year if the file has fewer of its events than this percent of the active ones")
+
+// Loads event listings from past conventions, for years before the site
+// tracked them. Files can have events from several years.
+func main() {
+	flag.Parse()
+	if len(*dir) == 0 {
+		log.Fatalf("You must specify a directory")
+	}
+
+	files, err := background.BackfillFiles(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("No xlsx, csv or ndjson files in %v", *dir)
+	}
+
+	db, err := postgres.OpenDb()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	options := background.BackfillOptions{
+		Mode:   events.Lenient,
+		DryRun: *dryRun,
+		Limits: &postgres.ImportLimits{
+			MaxDeactivatedPercent: *maxDeactivated,
+			MinRowPercent:         *minRows,
+		},
+		Force: *force,
+	}
+	if *strict {
+		options.Mode = events.Strict
+	}
+
+	// Backfilling the year being imported live would fight over its events
+	lock, err := postgres.TryImportLock(context.Background(), db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if lock == nil {
+		log.Fatal(background.ErrImportRunning)
+	}
+	defer lock.Release()
+
+	err = background.Backfill(db, files, options, func(progress background.BackfillProgress) {
+		fmt.Printf("[%d/%d] %v: %v\n", progress.FileNumber, progress.Files, progress.File, progress.Parsed.Summary())
+		for _, report := range progress.Reports {
+			if *verbose {
+				fmt.Print(report)
+				continue
+			}
+			fmt.Printf("  %d: %d new, %d updated, %d unchanged, %d deactivated\n",
+				report.Year, len(report.New), len(report.Updated), report.Unchanged, len(report.Deactivated))
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+var year = flag.Int("year", time.Now().Year(), "Year of events to export")
+var outFile = flag.String("out", "-", "File to write the events to, - for stdout")
+
+// Writes a year's events as ndjson records, which cmd/update and cmd/backfill
+// can load into another database with -eventFile or -dir.
+func main() {
+	flag.Parse()
+
+	db, err := postgres.OpenDb()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	records, err := postgres.LoadEventRecords(db, *year)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(records) == 0 {
+		log.Fatalf("No events in %d", *year)
+	}
+
+	out := os.Stdout
+	if *outFile != "-" {
+		if out, err = os.Create(*outFile); err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+	buffered := bufio.NewWriter(out)
+	writer := events.NewEventWriter(buffered)
+	for _, record := range records {
+		if err = writer.Write(record); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err = buffered.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Exported %d events from %d", len(records), *year)
+}
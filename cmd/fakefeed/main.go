@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/fakefeed"
+)
+
+var port = flag.Int("port", 8090, "port to listen on")
+var year = flag.Int("year", time.Now().Year(), "Year the generated events are in")
+var size = flag.Int("events", 1000, "How many events to start with")
+var seed = flag.Int64("seed", 1, "Seed for the generated events, the same seed gives the same feed")
+var advanceEvery = flag.Int("advance-every", 1, "Change the listing every this many downloads, 0 to only change on POST /advance")
+
+// Serves a made up event listing that changes as it's downloaded, so imports,
+// change tracking and notifications can be tried without gencon.com. Point
+// cmd/update at it with -eventFile.
+func main() {
+	flag.Parse()
+
+	feed := fakefeed.NewFeed(*year, *size, *seed)
+	address := fmt.Sprintf(":%d", *port)
+	log.Printf("Serving %d events at http://localhost%s/downloads/events.xlsx and events.csv", *size, address)
+	log.Fatal(http.ListenAndServe(address, fakefeed.NewServer(feed, *advanceEvery)))
+}
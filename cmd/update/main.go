@@ -2,17 +2,35 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/Encinarus/genconplanner/internal/background"
+	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
 )
 
-var sourceFile = flag.String("eventFile", "http://www.gencon.com/downloads/events.xlsx", "file path or url to load from")
+var sourceFile = flag.String("eventFile", "http://www.gencon.com/downloads/events.xlsx", "url, file or directory to load events from")
+var strict = flag.Bool("strict", false, "Fail on the first bad row instead of skipping it")
+var dryRun = flag.Bool("dry-run", false, "Show what the import would change without saving it")
+var reportFile = flag.String("report", "", "Also write the import report as JSON to this file, - for stdout")
+var maxDeactivated = flag.Float64("max-deactivated-percent", postgres.DefaultImportLimits.MaxDeactivatedPercent, "Hold the import if it would deactivate more than this percent of active events")
+var minRows = flag.Float64("min-row-percent", postgres.DefaultImportLimits.MinRowPercent, "Hold the import if the feed has fewer events than this percent of the active ones")
+var listHeld = flag.Bool("list-held", false, "List imports held for approval")
+var approve = flag.Int64("approve", 0, "Apply the held import with this id")
+var reject = flag.Int64("reject", 0, "Discard the held import with this id")
+var snapshotDir = flag.String("snapshots", "snapshots", "Directory downloaded feeds are archived in, empty to not keep them")
+var snapshotsKept = flag.Int("snapshots-kept", background.DefaultSnapshotsKept, "How many archived feeds to keep, 0 to keep them all")
+var replay = flag.String("replay", "", "Show what importing the archived snapshot with this checksum, or a prefix of it, would change")
+var apply = flag.Bool("apply", false, "Write the -replay snapshot's events, without this it's a dry run")
 var overrideDns = flag.Bool("overrideDNS", false, "Override DNS settings (useful for docker)")
 
 func setGoogleDns() {
@@ -42,6 +60,21 @@ func setGoogleDns() {
 
 }
 
+func printHeld(db *sql.DB) {
+	held, err := postgres.LoadHeldImports(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(held) == 0 {
+		fmt.Println("No imports held")
+		return
+	}
+	for _, h := range held {
+		fmt.Printf("%d: %d events for %d from %v, held %v\n  %v\n",
+			h.Id, h.EventCount, h.Year, h.Source, h.Created.Format("2006-01-02 15:04"), h.Reason)
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -51,13 +84,113 @@ func main() {
 	}
 	defer db.Close()
 
-	if len(*sourceFile) == 0 {
-		log.Fatalf("You must specify a source file")
+	// Keep stdout to just the JSON when that's where it's going
+	out := os.Stdout
+	if *reportFile == "-" {
+		out = os.Stderr
 	}
 
-	if *overrideDns {
-		setGoogleDns()
+	// Scheduled imports in cmd/web take the same lock
+	if *approve > 0 || (!*listHeld && *reject == 0) {
+		lock, err := postgres.TryImportLock(context.Background(), db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lock == nil {
+			log.Fatal(background.ErrImportRunning)
+		}
+		defer lock.Release()
 	}
 
-	background.UpdateEventsFromGencon(db, *sourceFile)
+	var report *postgres.ImportReport
+	switch {
+	case *listHeld:
+		printHeld(db)
+		return
+	case *reject > 0:
+		if err = postgres.RejectHeldImport(db, *reject); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Rejected import %d\n", *reject)
+		return
+	case *approve > 0:
+		if report, err = postgres.ApproveHeldImport(db, *approve); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		if len(*sourceFile) == 0 && len(*replay) == 0 {
+			log.Fatalf("You must specify a source file")
+		}
+
+		if *overrideDns {
+			setGoogleDns()
+		}
+
+		options := background.ImportOptions{
+			Mode: events.Lenient,
+			// Replays write over what's there without history, so they only
+			// do that when asked
+			DryRun: *dryRun || (len(*replay) > 0 && !*apply),
+			Limits: &postgres.ImportLimits{
+				MaxDeactivatedPercent: *maxDeactivated,
+				MinRowPercent:         *minRows,
+			},
+		}
+		if *strict {
+			options.Mode = events.Strict
+		}
+		if len(*snapshotDir) > 0 {
+			options.Snapshots = background.NewSnapshotStore(*snapshotDir)
+			options.Snapshots.Keep = *snapshotsKept
+		}
+
+		var result *events.ParseResult
+		if len(*replay) > 0 {
+			if options.Snapshots == nil {
+				log.Fatalf("Replaying needs a -snapshots directory")
+			}
+			result, report, err = background.ReplaySnapshot(db, options.Snapshots, *replay, options)
+		} else {
+			var source events.Source
+			if source, err = events.OpenSource(*sourceFile); err != nil {
+				log.Fatal(err)
+			}
+			result, report, err = background.UpdateEventsFromGencon(db, source, options)
+		}
+		if errors.Is(err, background.ErrFeedUnchanged) {
+			fmt.Fprintln(out, "Feed unchanged since the last import, nothing to do")
+			return
+		}
+		if result != nil {
+			fmt.Fprintln(out, result.Summary())
+		}
+		if err != nil {
+			if report != nil {
+				fmt.Fprintln(out, report)
+			}
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Fprintln(out, report)
+	if len(*reportFile) > 0 {
+		if err = writeReport(*reportFile, report); err != nil {
+			log.Fatalf("Unable to write report: %v", err)
+		}
+	}
+}
+
+func writeReport(path string, report *postgres.ImportReport) error {
+	out := os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
 }
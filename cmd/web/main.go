@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 
 	firebase "firebase.google.com/go"
 	"github.com/Encinarus/genconplanner/internal/api"
 	"github.com/Encinarus/genconplanner/internal/background"
+	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
 	"github.com/Encinarus/genconplanner/internal/web"
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	_ "github.com/heroku/x/hmetrics/onload"
@@ -25,7 +29,11 @@ import (
 )
 
 var port = flag.Int("port", 8080, "port to listen on")
-var sourceFile = flag.String("eventFile", "https://www.gencon.com/downloads/events.xlsx", "file path or url to load from")
+var sourceFile = flag.String("eventFile", "https://www.gencon.com/downloads/events.xlsx", "url, file or directory to load events from")
+var importSchedule = flag.String("import-schedule", "", "Import events on this cron schedule, like \"5 * * * *\" or \"@every 1h\", empty to leave it to cmd/update")
+var importJitter = flag.Duration("import-jitter", 2*time.Minute, "Most of the random delay added to each scheduled import")
+var snapshotDir = flag.String("snapshots", "snapshots", "Directory downloaded feeds are archived in, empty to not keep them")
+var snapshotsKept = flag.Int("snapshots-kept", background.DefaultSnapshotsKept, "How many archived feeds to keep, 0 to keep them all")
 
 func main() {
 	flag.Parse()
@@ -40,14 +48,24 @@ func main() {
 	}
 	defer db.Close()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cache := background.NewGameCache(db)
 	cache.PeriodicallyUpdate()
-	SetupBackground(db)
+	importerDone := SetupBackground(ctx, db)
+
+	SetupWeb(ctx, db, cache) // Must be last, won't return until server shutdown
 
-	SetupWeb(db, cache) // Must be last, won't return until server shutdown
+	if importerDone != nil {
+		log.Printf("Waiting for the event importer to stop")
+		<-importerDone
+	}
 }
 
-func SetupBackground(db *sql.DB) {
+// Returns a channel closed once the event importer has stopped, nil if it
+// isn't running.
+func SetupBackground(ctx context.Context, db *sql.DB) <-chan struct{} {
 	// We run this in a background thread on web because running as a separate
 	// app would be expensive. Unlike updating from gencon, these take a long time to
 	// process, so the app would be running continually, costing a bit more money than
@@ -65,21 +83,49 @@ func SetupBackground(db *sql.DB) {
 		}
 	}()
 
-	// TODO: decide if this should run here too.
-	if 1 == 2 {
-		genconTicker := time.NewTicker(time.Hour)
-		go func() {
-			for {
-				background.UpdateEventsFromGencon(db, *sourceFile)
-				select {
-				case <-genconTicker.C:
-				}
+	notifiers := background.NotifiersFromEnv()
+	notifyTicker := time.NewTicker(time.Minute * 10)
+	go func() {
+		for {
+			background.DeliverNotifications(db, notifiers)
+			select {
+			case <-notifyTicker.C:
 			}
-		}()
+		}
+	}()
+
+	// Small deployments can import here instead of running cmd/update
+	if len(*importSchedule) == 0 {
+		return nil
+	}
+	schedule, err := background.ParseSchedule(*importSchedule)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := background.ImportOptions{
+		Mode:   events.Lenient,
+		Limits: &postgres.DefaultImportLimits,
 	}
+	if len(*snapshotDir) > 0 {
+		options.Snapshots = background.NewSnapshotStore(*snapshotDir)
+		options.Snapshots.Keep = *snapshotsKept
+	}
+	source, err := events.OpenSource(*sourceFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scheduler := background.NewImportScheduler(db, source, schedule, options)
+	scheduler.Jitter = *importJitter
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(ctx)
+	}()
+	return done
 }
 
-func SetupWeb(db *sql.DB, cache *background.GameCache) {
+func SetupWeb(ctx context.Context, db *sql.DB, cache *background.GameCache) {
 
 	opt := option.WithCredentialsJSON([]byte(os.Getenv("FIREBASE_CONFIG")))
 	app, err := firebase.NewApp(context.Background(), nil, opt)
@@ -108,18 +154,62 @@ func SetupWeb(db *sql.DB, cache *background.GameCache) {
 	r.GET("/index", index)
 	r.GET("/cat/:year", web.CategoryList(db))
 	r.GET("/starred/:year", web.StarredPage(db))
+	r.GET("/starred/:year/calendar.ics", web.StarredCalendar(db))
+	r.GET("/calendar/:token/:year", web.CalendarFeed(db))
+	r.POST("/calendar/reset", web.ResetCalendarFeed(db))
+	r.GET("/itinerary/:year", web.ItineraryPage(db))
+	r.POST("/itinerary/:year/priorities", web.SetItineraryPriorities(db))
+	r.POST("/itinerary/:year/accept", web.AcceptItinerary(db))
 	r.POST("/starEvent/", web.StarEvent(db))
 	r.GET("/starEvent/", web.GetStarredEvents(db))
 	r.GET("/listStarredGroups/:year", web.GetStarredEventGroups(db))
 	r.GET("/about", web.About(db))
 	r.GET("/user", web.User(db))
-	r.GET("/admin/orgs/", web.ViewOrgs(db))
-	r.POST("/admin/orgs/", web.MergeOrgs(db))
+	r.POST("/user/notifications", web.SaveNotificationPreferences(db))
+
+	admin := r.Group("/admin", web.RequireAdmin())
+	admin.GET("/orgs/", web.ViewOrgs(db))
+	admin.POST("/orgs/", web.MergeOrgs(db))
+	admin.GET("/imports/", web.ViewImports(db))
+	admin.GET("/systems/", web.ViewGameSystems(db))
+	admin.POST("/systems/", web.SaveGameSystemAlias(db))
+	admin.POST("/systems/delete", web.DeleteGameSystemAlias(db))
+	admin.GET("/venue/", web.ViewVenue(db))
+	admin.POST("/venue/buildings", web.SaveBuilding(db))
+	admin.POST("/venue/buildings/delete", web.DeleteBuilding(db))
+	admin.POST("/venue/aliases", web.SaveBuildingAlias(db))
+	admin.POST("/venue/aliases/delete", web.DeleteBuildingAlias(db))
+	admin.POST("/venue/rooms", web.SaveRoomAlias(db))
+	admin.POST("/venue/rooms/delete", web.DeleteRoomAlias(db))
+	admin.POST("/venue/walks", web.SaveWalks(db))
 
 	r.POST("/party/new", web.NewParty(db))
 	r.GET("/party/:party_id", web.Party(db))
+	r.POST("/party/:party_id/invite", web.InviteToParty(db))
+	r.POST("/party/:party_id/revoke", web.RevokePartyInvite(db))
+	r.POST("/party/:party_id/remove", web.RemovePartyMember(db))
+	r.POST("/party/:party_id/leave", web.LeaveParty(db))
+	r.POST("/party/:party_id/delete", web.DeleteParty(db))
+	r.GET("/invite/:token", web.ViewPartyInvite(db))
+	r.POST("/invite/:token/accept", web.AcceptPartyInvite(db))
+	r.POST("/invite/:token/decline", web.DeclinePartyInvite(db))
 
 	api.BuildAPIRoutes(r.Group("/api/v1"), db, cache, app)
 
-	r.Run(fmt.Sprintf(":%d", *port))
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", *port),
+		Handler: r,
+	}
+	go func() {
+		<-ctx.Done()
+		// Heroku allows 30 seconds after SIGTERM
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down: %v", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
 }
@@ -12,4 +12,7 @@ func BuildAPIRoutes(api_group *gin.RouterGroup, db *sql.DB, gameCache *backgroun
 	categoryRoutes(api_group, db)
 	eventRoutes(api_group, db, gameCache)
 	userRoutes(api_group, db, app)
+	scheduleRoutes(api_group, db, app)
+	partyRoutes(api_group, db, app)
+	importRoutes(api_group, db)
 }
@@ -22,6 +22,12 @@ type EventsSearch struct {
 	MinFriTickets int    `form:"minFriTickets"`
 	MinSatTickets int    `form:"minSatTickets"`
 	MinSunTickets int    `form:"minSunTickets"`
+	// Someone's age, 0 for any
+	Age int `form:"age"`
+	// The most experience needed, none, some or expert
+	Experience   string `form:"experience"`
+	Registration string `form:"registration"`
+	MaxCostCents *int   `form:"maxCostCents"`
 
 	// Not yet implemented
 	TextQuery string `form:"search"`
@@ -68,7 +74,10 @@ type Event struct {
 	MinPlayers           int        `json:"minPlayers"`
 	MaxPlayers           int        `json:"maxPlayers"`
 	AgeRequired          string     `json:"ageRequired"`
+	MinAge               int        `json:"minAge"`
+	MaxAge               int        `json:"maxAge"`
 	ExperienceRequired   string     `json:"experienceRequired"`
+	ExperienceLevel      string     `json:"experienceLevel"`
 	MaterialsProvided    bool       `json:"materialsProvided"`
 	StartTime            time.Time  `json:"startTime"`
 	Duration             int        `json:"duration"`
@@ -81,9 +90,13 @@ type Event struct {
 	TotalRounds          int        `json:"totalRounds"`
 	MinPlayTime          int        `json:"minPlayTime"`
 	AttendeeRegistration string     `json:"attendeeRegistration"`
+	Registration         string     `json:"registration"`
 	Cost                 int        `json:"cost"`
+	CostCents            int        `json:"costCents"`
 	Location             string     `json:"location"`
 	RoomName             string     `json:"roomName"`
+	Building             string     `json:"building"`
+	Room                 string     `json:"room"`
 	TableNumber          string     `json:"tableNumber"`
 	TicketsAvailable     int        `json:"ticketsAvailable"`
 	LastModified         time.Time  `json:"lastModified"`
@@ -104,7 +117,10 @@ func convertEvent(apiEvent *Event, dbEvent *events.GenconEvent) {
 	apiEvent.MinPlayers = dbEvent.MinPlayers
 	apiEvent.MaxPlayers = dbEvent.MaxPlayers
 	apiEvent.AgeRequired = dbEvent.AgeRequired
+	apiEvent.MinAge = dbEvent.MinAge
+	apiEvent.MaxAge = dbEvent.MaxAge
 	apiEvent.ExperienceRequired = dbEvent.ExperienceRequired
+	apiEvent.ExperienceLevel = dbEvent.ExperienceLevel.String()
 	apiEvent.MaterialsProvided = dbEvent.MaterialsProvided
 	apiEvent.StartTime = dbEvent.StartTime
 	apiEvent.Duration = dbEvent.Duration
@@ -117,9 +133,13 @@ func convertEvent(apiEvent *Event, dbEvent *events.GenconEvent) {
 	apiEvent.TotalRounds = dbEvent.TotalRounds
 	apiEvent.MinPlayTime = dbEvent.MinPlayTime
 	apiEvent.AttendeeRegistration = dbEvent.AttendeeRegistration
+	apiEvent.Registration = string(dbEvent.Registration)
 	apiEvent.Cost = dbEvent.Cost
+	apiEvent.CostCents = dbEvent.CostCents
 	apiEvent.Location = dbEvent.Location
 	apiEvent.RoomName = dbEvent.RoomName
+	apiEvent.Building = dbEvent.Building
+	apiEvent.Room = dbEvent.Room
 	apiEvent.TableNumber = dbEvent.TableNumber
 	apiEvent.TicketsAvailable = dbEvent.TicketsAvailable
 	apiEvent.LastModified = dbEvent.LastModified
@@ -216,6 +236,25 @@ func searchEvents(c *gin.Context, db *sql.DB, gameCache *background.GameCache) {
 	q.MinFriTickets = search.MinFriTickets
 	q.MinSatTickets = search.MinSatTickets
 	q.MinSunTickets = search.MinSunTickets
+	q.Age = search.Age
+	q.MaxCostCents = search.MaxCostCents
+
+	if search.Experience != "" {
+		level, found := events.LookupExperienceLevel(search.Experience)
+		if !found {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		q.MaxExperience = level
+	}
+	if search.Registration != "" {
+		registration, found := events.LookupRegistration(search.Registration)
+		if !found {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		q.Registration = registration
+	}
 
 	matches, err := postgres.SearchEvents(db, q)
 	// postgres.LoadEventGroupsForCategory(db, search.Category, search.Year)
@@ -241,6 +280,13 @@ func eventRoutes(api_group *gin.RouterGroup, db *sql.DB, gameCache *background.G
 		lookupEvent(c, db, gameCache)
 	})
 
+	api_group.GET("/event/:event_id/tickets", func(c *gin.Context) {
+		eventTickets(c, db)
+	})
+	api_group.GET("/event/:event_id/cluster/tickets", func(c *gin.Context) {
+		clusterTickets(c, db)
+	})
+
 	api_group.POST("/events/", func(c *gin.Context) {
 		searchEvents(c, db, gameCache)
 	})
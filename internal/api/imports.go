@@ -0,0 +1,55 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultImportRuns = 50
+const maxImportRuns = 500
+
+type ImportLedger struct {
+	LastRefreshed *time.Time            `json:"lastRefreshed"`
+	Runs          []*postgres.ImportRun `json:"runs"`
+}
+
+func listImports(c *gin.Context, db *sql.DB) {
+	limit := defaultImportRuns
+	if rawLimit, found := c.GetQuery("limit"); found {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		if limit > maxImportRuns {
+			limit = maxImportRuns
+		}
+	}
+
+	runs, err := postgres.LoadImportRuns(db, limit)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	lastRefreshed, err := postgres.LastRefreshed(db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(ImportLedger{LastRefreshed: lastRefreshed, Runs: runs})
+}
+
+func importRoutes(api_group *gin.RouterGroup, db *sql.DB) {
+	api_group.GET("/imports", func(c *gin.Context) {
+		listImports(c, db)
+	})
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	firebase "firebase.google.com/go"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/siteurl"
+	"github.com/gin-gonic/gin"
+)
+
+type Party struct {
+	Id      int64  `json:"id"`
+	Name    string `json:"name"`
+	Year    int64  `json:"year"`
+	Owner   string `json:"owner"`
+	Members []User `json:"members"`
+}
+
+type PartyInvite struct {
+	Token     string    `json:"token"`
+	PartyId   int64     `json:"partyId"`
+	PartyName string    `json:"partyName"`
+	Year      int64     `json:"year"`
+	InvitedBy string    `json:"invitedBy"`
+	Email     string    `json:"email,omitempty"`
+	Created   time.Time `json:"created"`
+	Url       string    `json:"url"`
+}
+
+func convertParty(party *postgres.Party) Party {
+	apiParty := Party{
+		Id:      party.Id,
+		Name:    party.Name,
+		Year:    party.Year,
+		Owner:   party.Owner,
+		Members: make([]User, 0, len(party.Members)),
+	}
+	for _, member := range party.Members {
+		apiParty.Members = append(apiParty.Members, User{
+			Email:       member.Email,
+			DisplayName: member.DisplayName,
+		})
+	}
+	return apiParty
+}
+
+func convertInvite(c *gin.Context, invite *postgres.PartyInvite) PartyInvite {
+	return PartyInvite{
+		Token:     invite.Token,
+		PartyId:   invite.PartyId,
+		PartyName: invite.PartyName,
+		Year:      invite.Year,
+		InvitedBy: invite.InvitedBy,
+		Email:     invite.Email,
+		Created:   invite.Created,
+		Url:       invite.Url(siteurl.Base(c)),
+	}
+}
+
+func writeJSON(c *gin.Context, value interface{}) {
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(value)
+}
+
+// Loads the signed in user and the party from the url, aborting unless
+// they're a member of it.
+func loadMemberParty(c *gin.Context, db *sql.DB, app *firebase.App) (*postgres.User, *postgres.Party) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return nil, nil
+	}
+
+	partyId, err := strconv.ParseInt(c.Param("party_id"), 10, 64)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return nil, nil
+	}
+
+	user, err := postgres.LoadOrCreateUser(db, email)
+	if err != nil {
+		c.AbortWithError(http.StatusServiceUnavailable, err)
+		return nil, nil
+	}
+	party, err := postgres.LoadParty(db, user, partyId)
+	if err != nil {
+		log.Printf("error loading party: %v\n", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return nil, nil
+	}
+	if party == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return nil, nil
+	}
+	return user, party
+}
+
+func listParties(c *gin.Context, db *sql.DB, app *firebase.App) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+	user, err := postgres.LoadOrCreateUser(db, email)
+	if err != nil {
+		c.AbortWithError(http.StatusServiceUnavailable, err)
+		return
+	}
+
+	parties, err := postgres.LoadParties(db, user)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	results := make([]Party, 0, len(parties))
+	for _, party := range parties {
+		results = append(results, convertParty(party))
+	}
+	writeJSON(c, results)
+}
+
+func getParty(c *gin.Context, db *sql.DB, app *firebase.App) {
+	_, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+	writeJSON(c, convertParty(party))
+}
+
+func deleteParty(c *gin.Context, db *sql.DB, app *firebase.App) {
+	user, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+	if !party.IsOwner(user.Email) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	if err := postgres.DeleteParty(db, party.Id); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Owners can remove anyone else. Removing yourself is leaving the party.
+func removeMember(c *gin.Context, db *sql.DB, app *firebase.App) {
+	user, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+
+	email := c.Param("email")
+	var err error
+	if email == user.Email {
+		err = postgres.LeaveParty(db, party, email)
+	} else if !party.IsOwner(user.Email) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	} else if !party.HasMember(email) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	} else {
+		err = postgres.RemovePartyMember(db, party.Id, email)
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func listPartyInvites(c *gin.Context, db *sql.DB, app *firebase.App) {
+	_, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+	invites, err := postgres.LoadPartyInvites(db, party.Id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	results := make([]PartyInvite, 0, len(invites))
+	for _, invite := range invites {
+		results = append(results, convertInvite(c, invite))
+	}
+	writeJSON(c, results)
+}
+
+// Expects an optional {"email": ...} body. Leaving it out makes a join link.
+func createPartyInvite(c *gin.Context, db *sql.DB, app *firebase.App) {
+	user, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&request); err != nil {
+			return
+		}
+	}
+
+	invite, err := postgres.CreatePartyInvite(db, party.Id, user.Email,
+		strings.ToLower(strings.TrimSpace(request.Email)))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusCreated)
+	writeJSON(c, convertInvite(c, invite))
+}
+
+func revokePartyInvite(c *gin.Context, db *sql.DB, app *firebase.App) {
+	_, party := loadMemberParty(c, db, app)
+	if party == nil {
+		return
+	}
+	invite, err := postgres.LoadPartyInvite(db, c.Param("token"))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if invite == nil || invite.PartyId != party.Id {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err = postgres.DeletePartyInvite(db, invite.Token); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func listPendingInvites(c *gin.Context, db *sql.DB, app *firebase.App) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+	invites, err := postgres.LoadPendingInvites(db, email)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	results := make([]PartyInvite, 0, len(invites))
+	for _, invite := range invites {
+		results = append(results, convertInvite(c, invite))
+	}
+	writeJSON(c, results)
+}
+
+func answerInvite(c *gin.Context, db *sql.DB, app *firebase.App, accept bool) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+	if _, err := postgres.LoadOrCreateUser(db, email); err != nil {
+		c.AbortWithError(http.StatusServiceUnavailable, err)
+		return
+	}
+
+	invite, err := postgres.LoadPartyInvite(db, c.Param("token"))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if invite == nil || !invite.CanUse(email) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if accept {
+		err = postgres.AcceptPartyInvite(db, invite, email)
+	} else if !invite.IsLink() {
+		err = postgres.DeletePartyInvite(db, invite.Token)
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func partyRoutes(api_group *gin.RouterGroup, db *sql.DB, app *firebase.App) {
+	api_group.GET("/parties", func(c *gin.Context) {
+		listParties(c, db, app)
+	})
+	api_group.GET("/parties/:party_id", func(c *gin.Context) {
+		getParty(c, db, app)
+	})
+	api_group.DELETE("/parties/:party_id", func(c *gin.Context) {
+		deleteParty(c, db, app)
+	})
+	api_group.DELETE("/parties/:party_id/members/:email", func(c *gin.Context) {
+		removeMember(c, db, app)
+	})
+	api_group.GET("/parties/:party_id/invites", func(c *gin.Context) {
+		listPartyInvites(c, db, app)
+	})
+	api_group.POST("/parties/:party_id/invites", func(c *gin.Context) {
+		createPartyInvite(c, db, app)
+	})
+	api_group.DELETE("/parties/:party_id/invites/:token", func(c *gin.Context) {
+		revokePartyInvite(c, db, app)
+	})
+	api_group.GET("/invites", func(c *gin.Context) {
+		listPendingInvites(c, db, app)
+	})
+	api_group.POST("/invites/:token/accept", func(c *gin.Context) {
+		answerInvite(c, db, app, true)
+	})
+	api_group.POST("/invites/:token/decline", func(c *gin.Context) {
+		answerInvite(c, db, app, false)
+	})
+}
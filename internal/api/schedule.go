@@ -0,0 +1,87 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	firebase "firebase.google.com/go"
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/schedule"
+	"github.com/gin-gonic/gin"
+)
+
+type Conflict struct {
+	Kind          string     `json:"kind"`
+	First         EventRef   `json:"first"`
+	Second        EventRef   `json:"second"`
+	GapMinutes    int        `json:"gapMinutes"`
+	TravelMinutes int        `json:"travelMinutes"`
+	Alternatives  []EventRef `json:"alternatives"`
+}
+
+func toEventRef(event *events.GenconEvent) EventRef {
+	return EventRef{
+		EventId:          event.EventId,
+		TicketsAvailable: event.TicketsAvailable,
+		StartTime:        event.StartTime,
+		EndTime:          event.EndTime,
+	}
+}
+
+func convertConflict(conflict *schedule.Conflict) Conflict {
+	apiConflict := Conflict{
+		Kind:          string(conflict.Kind),
+		First:         toEventRef(conflict.First),
+		Second:        toEventRef(conflict.Second),
+		GapMinutes:    int(conflict.Gap.Minutes()),
+		TravelMinutes: int(conflict.TravelTime.Minutes()),
+		Alternatives:  make([]EventRef, 0, len(conflict.Alternatives)),
+	}
+	for _, alternative := range conflict.Alternatives {
+		apiConflict.Alternatives = append(apiConflict.Alternatives, toEventRef(alternative))
+	}
+	return apiConflict
+}
+
+func listConflicts(c *gin.Context, db *sql.DB, app *firebase.App) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	entries, err := postgres.LoadStarredSchedule(db, email, year)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	venue, err := postgres.LoadVenue(db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	results := make([]Conflict, 0)
+	for _, conflict := range schedule.NewVenueAnalyzer(venue).FindConflicts(entries) {
+		results = append(results, convertConflict(conflict))
+	}
+
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(results)
+}
+
+func scheduleRoutes(api_group *gin.RouterGroup, db *sql.DB, app *firebase.App) {
+	api_group.GET("/starred/:year/conflicts", func(c *gin.Context) {
+		listConflicts(c, db, app)
+	})
+}
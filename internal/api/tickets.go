@@ -0,0 +1,96 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/tickets"
+	"github.com/gin-gonic/gin"
+)
+
+type TicketHistory struct {
+	EventId     string             `json:"eventId,omitempty"`
+	Snapshots   []tickets.Snapshot `json:"snapshots"`
+	RatePerHour float64            `json:"ratePerHour"`
+	SellOutBy   *time.Time         `json:"sellOutBy"`
+}
+
+type ClusterTicketHistory struct {
+	Combined TicketHistory   `json:"combined"`
+	Sessions []TicketHistory `json:"sessions"`
+}
+
+func buildTicketHistory(eventId string, snapshots []tickets.Snapshot, now time.Time) TicketHistory {
+	if snapshots == nil {
+		snapshots = make([]tickets.Snapshot, 0)
+	}
+	forecast := tickets.Estimate(snapshots, now)
+	return TicketHistory{
+		EventId:     eventId,
+		Snapshots:   snapshots,
+		RatePerHour: forecast.RatePerHour,
+		SellOutBy:   forecast.SellOutBy,
+	}
+}
+
+func eventTickets(c *gin.Context, db *sql.DB) {
+	eventId := c.Param("event_id")
+	if len(strings.TrimSpace(eventId)) == 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	history, err := postgres.LoadTicketHistory(db, []string{eventId})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(buildTicketHistory(eventId, history[eventId], time.Now()))
+}
+
+// Every session of the event, and all of them added together.
+func clusterTickets(c *gin.Context, db *sql.DB) {
+	eventId := c.Param("event_id")
+	if len(strings.TrimSpace(eventId)) == 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := postgres.LoadSimilarEvents(db, eventId, "")
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if len(sessions) == 0 {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	sessionIds := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIds = append(sessionIds, session.EventId)
+	}
+	history, err := postgres.LoadTicketHistory(db, sessionIds)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	result := ClusterTicketHistory{Sessions: make([]TicketHistory, 0, len(sessionIds))}
+	allSnapshots := make([][]tickets.Snapshot, 0, len(sessionIds))
+	for _, sessionId := range sessionIds {
+		result.Sessions = append(result.Sessions, buildTicketHistory(sessionId, history[sessionId], now))
+		allSnapshots = append(allSnapshots, history[sessionId])
+	}
+	result.Combined = buildTicketHistory("", tickets.Combine(allSnapshots), now)
+
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(result)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	firebase "firebase.google.com/go"
+	"github.com/Encinarus/genconplanner/internal/notify"
 	"github.com/Encinarus/genconplanner/internal/postgres"
 	"github.com/gin-gonic/gin"
 )
@@ -116,6 +119,48 @@ func loadUserEvents(c *gin.Context, db *sql.DB, app *firebase.App) {
 	json.NewEncoder(c.Writer).Encode(userEvents)
 }
 
+func getNotificationPreferences(c *gin.Context, db *sql.DB, app *firebase.App) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+
+	prefs, err := postgres.LoadNotificationPreferences(db, email)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(prefs)
+}
+
+func putNotificationPreferences(c *gin.Context, db *sql.DB, app *firebase.App) {
+	email := requireLogin(c, app)
+	if email == "" {
+		// requireLogin already aborted the request.
+		return
+	}
+
+	prefs := notify.DefaultPreferences(email)
+	if err := c.BindJSON(prefs); err != nil {
+		return
+	}
+	// Only ever your own
+	prefs.Email = email
+	if prefs.WebhookUrl != "" && !strings.HasPrefix(prefs.WebhookUrl, "https://") {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("webhooks must use https"))
+		return
+	}
+
+	if err := postgres.SaveNotificationPreferences(db, prefs); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Content-Type", "application/json")
+	json.NewEncoder(c.Writer).Encode(prefs)
+}
+
 func userRoutes(api_group *gin.RouterGroup, db *sql.DB, app *firebase.App) {
 	api_group.GET("/user/", func(c *gin.Context) {
 		getUser(c, db, app)
@@ -123,4 +168,10 @@ func userRoutes(api_group *gin.RouterGroup, db *sql.DB, app *firebase.App) {
 	api_group.GET("/user/events/:email/:year", func(c *gin.Context) {
 		loadUserEvents(c, db, app)
 	})
+	api_group.GET("/user/notifications", func(c *gin.Context) {
+		getNotificationPreferences(c, db, app)
+	})
+	api_group.PUT("/user/notifications", func(c *gin.Context) {
+		putNotificationPreferences(c, db, app)
+	})
 }
@@ -0,0 +1,129 @@
+package background
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+type BackfillOptions struct {
+	Mode events.ParseMode
+	// Does all the same work and rolls it back
+	DryRun bool
+	// Nil to skip the checks
+	Limits *postgres.ImportLimits
+	// Backfills years that live imports have already written. Their events
+	// have change history and notifications that a backfill would skip.
+	Force bool
+}
+
+// Nil when the year can be backfilled. Live imports own liveYear and
+// anything after it, an old file would undo what they've written. A liveYear
+// of 0 means it isn't known, so nothing is safe.
+func checkBackfillYear(year, liveYear int, force bool) error {
+	if force || (liveYear > 0 && year < liveYear) {
+		return nil
+	}
+	if liveYear == 0 {
+		return fmt.Errorf("no import has recorded the live year, force it to backfill %d anyway", year)
+	}
+	return fmt.Errorf("%d is imported live, the newest live year is %d, force it to backfill anyway", year, liveYear)
+}
+
+// Where a backfill has got to, after each file
+type BackfillProgress struct {
+	File       string
+	FileNumber int
+	Files      int
+	Parsed     *events.ParseResult
+	// One for each year in the file
+	Reports []*postgres.ImportReport
+}
+
+// The event files under a directory, in name order. Files are applied in that
+// order, so a year's later listings should sort after its earlier ones.
+func BackfillFiles(dir string) ([]events.Source, error) {
+	return (&events.DirSource{Path: dir}).Files()
+}
+
+// Imports archived event files from past conventions. Each file is split up
+// by year, and each year in it is treated as that year's full listing, so
+// what's missing from it is deactivated, within options.Limits. Years live
+// imports have written are refused without options.Force, and so is
+// everything when the import ledger doesn't say which year is live. Stops at the first
+// file that fails, the years already written stay, so it can be run again
+// once that's fixed.
+func Backfill(db *sql.DB, files []events.Source, options BackfillOptions, progress func(BackfillProgress)) error {
+	aliases, err := postgres.LoadSystemAliases(db)
+	if err != nil {
+		return fmt.Errorf("loading game system aliases: %w", err)
+	}
+	venue, err := postgres.LoadVenue(db)
+	if err != nil {
+		return fmt.Errorf("loading the venue: %w", err)
+	}
+	liveYear, err := postgres.NewestLiveYear(db)
+	if err != nil {
+		return fmt.Errorf("finding the newest live year: %w", err)
+	}
+
+	for i, file := range files {
+		result, err := file.Load(options.Mode)
+		if err != nil {
+			return err
+		}
+		aliases.ApplyAll(result.Events)
+		venue.LocateAll(result.Events)
+
+		reports, err := backfillYears(db, result, liveYear, options)
+		if err != nil {
+			return fmt.Errorf("importing %v: %w", file, err)
+		}
+		progress(BackfillProgress{
+			File:       file.String(),
+			FileNumber: i + 1,
+			Files:      len(files),
+			Parsed:     result,
+			Reports:    reports,
+		})
+	}
+	return nil
+}
+
+// A transaction for each year, so a bad year doesn't undo the others
+func backfillYears(db *sql.DB, result *events.ParseResult, liveYear int, options BackfillOptions) ([]*postgres.ImportReport, error) {
+	years, byYear := events.PartitionByYear(result.Events)
+	reports := make([]*postgres.ImportReport, 0, len(years))
+	for _, year := range years {
+		if year == 0 {
+			log.Printf("Skipping %d events without a year", len(byYear[year]))
+			continue
+		}
+		if err := checkBackfillYear(year, liveYear, options.Force); err != nil {
+			return reports, err
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return reports, err
+		}
+		report, err := postgres.BackfillEvents(tx, byYear[year], result.Rejected, options.Limits)
+		if err != nil {
+			tx.Rollback()
+			return reports, fmt.Errorf("%d: %w", year, err)
+		}
+		if options.DryRun {
+			report.DryRun = true
+			err = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
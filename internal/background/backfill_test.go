@@ -0,0 +1,60 @@
+package background
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestBackfillFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"2016/events-2016-08.xlsx", "2016/events-2016-05.CSV", "2015.xlsx", "2017.ndjson", "notes.txt", "2014/readme",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := BackfillFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []events.Source{
+		&events.XlsxFile{Path: filepath.Join(dir, "2015.xlsx")},
+		&events.CsvFile{Path: filepath.Join(dir, "2016/events-2016-05.CSV")},
+		&events.XlsxFile{Path: filepath.Join(dir, "2016/events-2016-08.xlsx")},
+		&events.NdjsonFile{Path: filepath.Join(dir, "2017.ndjson")},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestCheckBackfillYear(t *testing.T) {
+	for _, test := range []struct {
+		year, liveYear int
+		force          bool
+		ok             bool
+	}{
+		{2015, 0, false, false},
+		{2015, 0, true, true},
+		{2015, 2024, false, true},
+		{2024, 2024, false, false},
+		{2025, 2024, false, false},
+		{2024, 2024, true, true},
+	} {
+		name := fmt.Sprintf("%d with %d live, force %v", test.year, test.liveYear, test.force)
+		if err := checkBackfillYear(test.year, test.liveYear, test.force); (err == nil) != test.ok {
+			t.Errorf("%s: expected ok %v, got %v", name, test.ok, err)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 type GameCache struct {
 	// Name -> games
 	games map[string][]*postgres.Game // guarded by mu
+	index *gameIndex                  // guarded by mu
 
 	db *sql.DB // threadsafe, not guarded by mutex
 
@@ -51,15 +52,21 @@ func (gc *GameCache) UpdateCache() error {
 	}
 
 	newGames := make(map[string][]*postgres.Game)
+	names := make([]string, 0, len(dbGames))
 
 	for _, g := range dbGames {
 		normalizedName := strings.TrimSpace(strings.ToLower(g.Name))
+		if len(newGames[normalizedName]) == 0 {
+			names = append(names, strings.TrimSpace(g.Name))
+		}
 		newGames[normalizedName] = append(newGames[normalizedName], g)
 	}
+	index := newGameIndex(names)
 
 	gc.mu.Lock()
 	defer gc.mu.Unlock()
 	gc.games = newGames
+	gc.index = index
 
 	return nil
 }
@@ -100,3 +107,16 @@ func (gc *GameCache) FindGame(name string) *postgres.Game {
 
 	return matches[0]
 }
+
+// BGG names that look like the game system, for fixing ones that don't match
+// anything
+func (gc *GameCache) SuggestGames(name string, limit int) []string {
+	gc.mu.Lock()
+	index := gc.index
+	gc.mu.Unlock()
+
+	if index == nil {
+		return nil
+	}
+	return index.suggest(name, limit)
+}
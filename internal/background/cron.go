@@ -0,0 +1,189 @@
+package background
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When something periodic should next run
+type Schedule interface {
+	// The first time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// Runs at a fixed interval, measured from the last run
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// Five cron fields, each a set of allowed values
+type cronSchedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek map[int]bool
+	// Cron matches either day field when both are restricted
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+}
+
+// Parses a cron expression like "5 * * * *", one of @hourly, @daily or
+// @weekly, or "@every 30m" for a fixed interval.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("schedule %q: run at most once a minute", spec)
+		}
+		return everySchedule{interval}, nil
+	}
+	if expanded, found := cronDescriptors[spec]; found {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields, minute hour day month weekday", spec)
+	}
+	limits := []struct {
+		name     string
+		min, max int
+	}{
+		{"minute", 0, 59}, {"hour", 0, 23}, {"day", 1, 31}, {"month", 1, 12}, {"weekday", 0, 7},
+	}
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, limits[i].min, limits[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %v %w", spec, limits[i].name, err)
+		}
+		sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if sets[4][7] {
+		sets[4][0] = true
+		delete(sets[4], 7)
+	}
+	schedule := &cronSchedule{
+		minutes:       sets[0],
+		hours:         sets[1],
+		daysOfMonth:   sets[2],
+		months:        sets[3],
+		daysOfWeek:    sets[4],
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", spec)
+	}
+	return schedule, nil
+}
+
+// One field, a comma separated list of *, values and ranges, each optionally
+// stepped with /n
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("bad step in %q", part)
+			}
+			part = part[:slash]
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("bad value %q", part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("bad range %q", part)
+				}
+			} else if step > 1 {
+				// 5/15 means from 5 on
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// Midnight can be skipped by daylight saving too, in which case time.Date
+// gives a time before the one we started from
+func later(from, to time.Time) time.Time {
+	if to.After(from) {
+		return to
+	}
+	return from.Add(time.Hour)
+}
+
+// Walks forward a month, day, hour or minute at a time, skipping whatever
+// can't match. Gives up after a few years, which only a date like Feb 30
+// reaches, and returns the zero time.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if !s.months[int(next.Month())] {
+			next = later(next, time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location()))
+			continue
+		}
+		if !s.matchesDay(next) {
+			next = later(next, time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location()))
+			continue
+		}
+		if !s.hours[next.Hour()] {
+			// Not time.Date, which puts hours skipped by daylight saving
+			// back an hour
+			next = next.Add(time.Hour - time.Duration(next.Minute())*time.Minute)
+			continue
+		}
+		if !s.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
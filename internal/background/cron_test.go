@@ -0,0 +1,59 @@
+package background
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// A Thursday
+	start := time.Date(2024, 8, 1, 10, 7, 30, 0, events.Indianapolis)
+	for _, test := range []struct {
+		spec string
+		want time.Time
+	}{
+		{"5 * * * *", time.Date(2024, 8, 1, 11, 5, 0, 0, events.Indianapolis)},
+		{"*/15 * * * *", time.Date(2024, 8, 1, 10, 15, 0, 0, events.Indianapolis)},
+		{"@hourly", time.Date(2024, 8, 1, 11, 0, 0, 0, events.Indianapolis)},
+		{"@daily", time.Date(2024, 8, 2, 0, 0, 0, 0, events.Indianapolis)},
+		{"30 6-8,20 * * *", time.Date(2024, 8, 1, 20, 30, 0, 0, events.Indianapolis)},
+		{"0 9 * * 0", time.Date(2024, 8, 4, 9, 0, 0, 0, events.Indianapolis)},
+		{"0 9 * * 7", time.Date(2024, 8, 4, 9, 0, 0, 0, events.Indianapolis)},
+		// Either day field matches when both are given
+		{"0 9 15 * 6", time.Date(2024, 8, 3, 9, 0, 0, 0, events.Indianapolis)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, events.Indianapolis)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, events.Indianapolis)},
+		{"@every 45m", start.Add(45 * time.Minute)},
+	} {
+		schedule, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.spec, err)
+			continue
+		}
+		if got := schedule.Next(start); !got.Equal(test.want) {
+			t.Errorf("%q: expected %v, got %v", test.spec, test.want, got)
+		}
+	}
+}
+
+func TestScheduleAcrossDaylightSaving(t *testing.T) {
+	schedule, _ := ParseSchedule("30 2 * * *")
+	// 2:30 doesn't exist on March 10 2024, so it's the next day
+	got := schedule.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, events.Indianapolis))
+	if want := time.Date(2024, 3, 11, 2, 30, 0, 0, events.Indianapolis); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *",
+		"a * * * *", "0 0 30 2 *", "@every soon", "@every 10s", "@yearly",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
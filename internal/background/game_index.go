@@ -0,0 +1,107 @@
+package background
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Finds BGG names that look like a misspelled or shortened game system, by
+// how many three letter runs they share.
+type gameIndex struct {
+	names []string
+	// How many distinct trigrams each name has
+	sizes    []int
+	trigrams map[string][]int
+}
+
+// Lowercase letters and digits, with punctuation and spacing collapsed so
+// "Ascension; Tactics" and "Ascension Tactics" look the same
+func fuzzyKey(name string) string {
+	var key strings.Builder
+	space := true
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key.WriteRune(r)
+			space = false
+		} else if !space {
+			key.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(key.String())
+}
+
+func trigrams(name string) map[string]bool {
+	runes := []rune(" " + fuzzyKey(name) + " ")
+	grams := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+func newGameIndex(names []string) *gameIndex {
+	index := &gameIndex{
+		names:    make([]string, 0, len(names)),
+		sizes:    make([]int, 0, len(names)),
+		trigrams: make(map[string][]int),
+	}
+	for _, name := range names {
+		grams := trigrams(name)
+		if len(grams) == 0 {
+			continue
+		}
+		id := len(index.names)
+		index.names = append(index.names, name)
+		index.sizes = append(index.sizes, len(grams))
+		for gram := range grams {
+			index.trigrams[gram] = append(index.trigrams[gram], id)
+		}
+	}
+	return index
+}
+
+type suggestion struct {
+	name  string
+	score float64
+}
+
+// The closest names, best first, leaving out anything too different to be
+// worth a look
+func (g *gameIndex) suggest(name string, limit int) []string {
+	grams := trigrams(name)
+	if len(grams) == 0 {
+		return nil
+	}
+	shared := make(map[int]int)
+	for gram := range grams {
+		for _, id := range g.trigrams[gram] {
+			shared[id]++
+		}
+	}
+
+	suggestions := make([]suggestion, 0)
+	for id, count := range shared {
+		// Dice coefficient over the trigram sets
+		score := 2 * float64(count) / float64(len(grams)+g.sizes[id])
+		if score >= 0.4 {
+			suggestions = append(suggestions, suggestion{g.names[id], score})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].score != suggestions[j].score {
+			return suggestions[i].score > suggestions[j].score
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+
+	names := make([]string, 0, limit)
+	for _, s := range suggestions {
+		if len(names) == limit {
+			break
+		}
+		names = append(names, s.name)
+	}
+	return names
+}
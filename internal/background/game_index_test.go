@@ -0,0 +1,51 @@
+package background
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGameIndexSuggest(t *testing.T) {
+	index := newGameIndex([]string{
+		"Carcassonne",
+		"Carcassonne: Hunters and Gatherers",
+		"Ascension Tactics: Miniatures Deckbuilding Game",
+		"Ascension: Deckbuilding Game",
+		"Boss Monster: The Dungeon Building Card Game",
+		"Cartagena",
+		"Twilight Imperium: Fourth Edition",
+		"!!!",
+	})
+
+	for _, test := range []struct {
+		name string
+		want []string
+	}{
+		{"Carcassone", []string{"Carcassonne"}},
+		{"Ascension; Tactics", []string{"Ascension Tactics: Miniatures Deckbuilding Game"}},
+		{"cartagena", []string{"Cartagena"}},
+		{"Gloomhaven", []string{}},
+		{"", nil},
+	} {
+		if got := index.suggest(test.name, 1); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("suggest(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	want := []string{"Carcassonne: Hunters and Gatherers", "Carcassonne"}
+	if got := index.suggest("Carcassonne Hunters", 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the closest first, got %q", got)
+	}
+}
+
+func TestFuzzyKey(t *testing.T) {
+	for name, want := range map[string]string{
+		"Ascension; Tactics":      "ascension tactics",
+		"  B-17: Queen  ":         "b 17 queen",
+		"Ahau: Rulers of Yucatán": "ahau rulers of yucatán",
+	} {
+		if got := fuzzyKey(name); got != want {
+			t.Errorf("fuzzyKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package background
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+// Enough rejected rows to see what's wrong without filling the ledger
+const maxRunWarnings = 20
+
+// The ledger shouldn't stop an import, so failures to write it are only logged
+func startRun(db *sql.DB, source string) *postgres.ImportRun {
+	run, err := postgres.StartImportRun(db, source)
+	if err != nil {
+		log.Printf("Unable to record import run: %v", err)
+		return &postgres.ImportRun{Source: source, Status: postgres.RunningStatus}
+	}
+	return run
+}
+
+func finishRun(db *sql.DB, run *postgres.ImportRun, options ImportOptions, result *events.ParseResult, report *postgres.ImportReport, err error) {
+	summarizeRun(run, options, result, report, err)
+	if run.Id == 0 {
+		return
+	}
+	if err = postgres.FinishImportRun(db, run); err != nil {
+		log.Printf("Unable to record the end of import run %d: %v", run.Id, err)
+	}
+}
+
+func summarizeRun(run *postgres.ImportRun, options ImportOptions, result *events.ParseResult, report *postgres.ImportReport, err error) {
+	run.Warnings = make([]string, 0)
+	if result != nil {
+		run.ParsedRows = len(result.Events)
+		run.RejectedRows = result.RejectedRows
+		if result.Headers != nil && result.Headers.HasProblems() {
+			run.Warnings = append(run.Warnings, result.Headers.String())
+		}
+		for i, problem := range result.Rejected {
+			if len(run.Warnings) == maxRunWarnings {
+				run.Warnings = append(run.Warnings, fmt.Sprintf("and %d more problems", len(result.Rejected)-i))
+				break
+			}
+			run.Warnings = append(run.Warnings, problem.Error())
+		}
+	}
+	if report != nil {
+		run.Year = report.Year
+		run.Inserted = len(report.New)
+		run.Updated = len(report.Updated)
+		run.Deactivated = len(report.Deactivated)
+	}
+
+	if err != nil {
+		run.Error = err.Error()
+	}
+	overLimits := errors.As(err, new(*postgres.LimitError))
+	switch {
+	case errors.Is(err, ErrFeedUnchanged):
+		run.Status = postgres.UnchangedStatus
+		run.Error = ""
+	case options.DryRun && (err == nil || overLimits):
+		// The error says it would have been held
+		run.Status = postgres.DryRunStatus
+	case overLimits:
+		run.Status = postgres.HeldStatus
+	case err != nil:
+		run.Status = postgres.FailedStatus
+	default:
+		run.Status = postgres.SucceededStatus
+	}
+}
@@ -0,0 +1,74 @@
+package background
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+func TestSummarizeRunStatus(t *testing.T) {
+	held := fmt.Errorf("%w, held as import 3 for approval", &postgres.LimitError{Reason: "too many"})
+	for _, test := range []struct {
+		name   string
+		dryRun bool
+		err    error
+		status string
+	}{
+		{"imported", false, nil, postgres.SucceededStatus},
+		{"unchanged", false, ErrFeedUnchanged, postgres.UnchangedStatus},
+		{"held", false, held, postgres.HeldStatus},
+		{"failed", false, errors.New("connection refused"), postgres.FailedStatus},
+		{"dry run", true, nil, postgres.DryRunStatus},
+		{"dry run past the limits", true, &postgres.LimitError{Reason: "too many"}, postgres.DryRunStatus},
+		{"dry run that failed", true, errors.New("bad columns"), postgres.FailedStatus},
+	} {
+		run := &postgres.ImportRun{}
+		summarizeRun(run, ImportOptions{DryRun: test.dryRun}, nil, nil, test.err)
+		if run.Status != test.status {
+			t.Errorf("%s: expected %v, got %v", test.name, test.status, run.Status)
+		}
+		if test.status == postgres.SucceededStatus || test.status == postgres.UnchangedStatus {
+			if run.Error != "" {
+				t.Errorf("%s: unexpected error %q", test.name, run.Error)
+			}
+		} else if test.err != nil && run.Error != test.err.Error() {
+			t.Errorf("%s: expected error %q, got %q", test.name, test.err, run.Error)
+		}
+	}
+}
+
+func TestSummarizeRunCounts(t *testing.T) {
+	result := &events.ParseResult{
+		Events:       make([]*events.GenconEvent, 3),
+		Headers:      &events.HeaderReport{Unrecognized: []string{"Mystery"}},
+		RejectedRows: 30,
+	}
+	for i := 0; i < 30; i++ {
+		result.Rejected = append(result.Rejected, &events.RowProblem{Row: i + 2, Reason: "bad"})
+	}
+	report := &postgres.ImportReport{
+		Year:        2024,
+		New:         make([]*postgres.ReportedEvent, 2),
+		Updated:     make([]*postgres.ReportedEvent, 1),
+		Deactivated: make([]*postgres.ReportedEvent, 4),
+	}
+
+	run := &postgres.ImportRun{}
+	summarizeRun(run, ImportOptions{}, result, report, nil)
+	if run.ParsedRows != 3 || run.RejectedRows != 30 {
+		t.Errorf("wrong row counts: %+v", run)
+	}
+	if run.Year != 2024 || run.Inserted != 2 || run.Updated != 1 || run.Deactivated != 4 {
+		t.Errorf("wrong import counts: %+v", run)
+	}
+	// The header problem, 19 rows and a count of the rest
+	if len(run.Warnings) != maxRunWarnings+1 {
+		t.Fatalf("expected %d warnings, got %d", maxRunWarnings+1, len(run.Warnings))
+	}
+	if last := run.Warnings[maxRunWarnings]; last != "and 11 more problems" {
+		t.Errorf("expected the rest counted, got %q", last)
+	}
+}
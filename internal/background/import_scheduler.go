@@ -0,0 +1,138 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+// Another process, like a second web dyno or cmd/update, is importing
+var ErrImportRunning = errors.New("another import is running")
+
+// Imports events from gencon on a schedule inside a long running process.
+// Every replica can run one, the import lock picks which of them imports.
+type ImportScheduler struct {
+	DB       *sql.DB
+	Source   events.Source
+	Schedule Schedule
+	Options  ImportOptions
+	// Most of the random delay added to each run, so replicas that start
+	// together don't all try at once
+	Jitter time.Duration
+	// Failed runs are retried after MinBackoff, doubling up to MaxBackoff,
+	// unless the schedule comes around sooner
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func NewImportScheduler(db *sql.DB, source events.Source, schedule Schedule, options ImportOptions) *ImportScheduler {
+	return &ImportScheduler{
+		DB:         db,
+		Source:     source,
+		Schedule:   schedule,
+		Options:    options,
+		Jitter:     2 * time.Minute,
+		MinBackoff: time.Minute,
+		MaxBackoff: 30 * time.Minute,
+	}
+}
+
+// How long to wait before retrying after this many failures in a row
+func backoff(failures int, min, max time.Duration) time.Duration {
+	delay := min
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// Unchanged feeds, held imports and another process importing are all
+// expected, retrying sooner wouldn't change them.
+func isImportFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrFeedUnchanged) &&
+		!errors.Is(err, ErrImportRunning) &&
+		!errors.As(err, new(*postgres.LimitError))
+}
+
+func (s *ImportScheduler) nextWait(now time.Time, failures int) time.Duration {
+	wait := s.Schedule.Next(now).Sub(now)
+	if failures > 0 {
+		if retry := backoff(failures, s.MinBackoff, s.MaxBackoff); retry < wait {
+			wait = retry
+		}
+	}
+	return wait + jitter(s.Jitter)
+}
+
+// Imports on the schedule until the context is cancelled. An import that's
+// going when it's cancelled is allowed to finish rather than left holding its
+// transaction open.
+func (s *ImportScheduler) Run(ctx context.Context) {
+	failures := 0
+	for {
+		now := time.Now().In(postgres.INDIANAPOLIS)
+		wait := s.nextWait(now, failures)
+		log.Printf("Next event import at %v", now.Add(wait).Format("Jan 2 3:04:05 PM MST"))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("Stopping event imports")
+			return
+		case <-timer.C:
+		}
+
+		err := s.runOnce(ctx)
+		if isImportFailure(err) {
+			failures++
+			log.Printf("Error updating events, %d failures in a row: %v", failures, err)
+		} else {
+			failures = 0
+			if err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+func (s *ImportScheduler) runOnce(ctx context.Context) error {
+	lock, err := postgres.TryImportLock(ctx, s.DB)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return ErrImportRunning
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("Error releasing the import lock: %v", err)
+		}
+	}()
+
+	result, report, err := UpdateEventsFromGencon(s.DB, s.Source, s.Options)
+	if result != nil {
+		log.Print(result.Summary())
+	}
+	if report != nil {
+		log.Print(report)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package background
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+func TestBackoff(t *testing.T) {
+	for failures, want := range map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		5:  16 * time.Minute,
+		6:  30 * time.Minute,
+		40: 30 * time.Minute,
+	} {
+		if got := backoff(failures, time.Minute, 30*time.Minute); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestNextWait(t *testing.T) {
+	schedule, _ := ParseSchedule("0 * * * *")
+	scheduler := &ImportScheduler{Schedule: schedule, MinBackoff: time.Minute, MaxBackoff: 30 * time.Minute}
+	now := time.Date(2024, 8, 1, 10, 20, 0, 0, time.UTC)
+
+	if wait := scheduler.nextWait(now, 0); wait != 40*time.Minute {
+		t.Errorf("expected to wait for the schedule, got %v", wait)
+	}
+	if wait := scheduler.nextWait(now, 2); wait != 2*time.Minute {
+		t.Errorf("expected to retry sooner after failing, got %v", wait)
+	}
+	// The schedule comes around before the backoff would
+	if wait := scheduler.nextWait(now.Add(35*time.Minute), 10); wait != 5*time.Minute {
+		t.Errorf("expected the scheduled run, got %v", wait)
+	}
+
+	scheduler.Jitter = time.Minute
+	for i := 0; i < 20; i++ {
+		if wait := scheduler.nextWait(now, 0); wait < 40*time.Minute || wait >= 41*time.Minute {
+			t.Fatalf("jitter out of range: %v", wait)
+		}
+	}
+}
+
+func TestIsImportFailure(t *testing.T) {
+	held := fmt.Errorf("%w, held as import 3 for approval", &postgres.LimitError{Reason: "too many"})
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrFeedUnchanged, false},
+		{ErrImportRunning, false},
+		{held, false},
+		{errors.New("fetching: 503 Service Unavailable"), true},
+	} {
+		if got := isImportFailure(test.err); got != test.want {
+			t.Errorf("isImportFailure(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/notify"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+)
+
+// Email only goes out when SMTP is configured, webhooks always can.
+func NotifiersFromEnv() []notify.Notifier {
+	notifiers := []notify.Notifier{notify.NewWebhookNotifier()}
+	if email := notify.EmailNotifierFromEnv(); email != nil {
+		notifiers = append(notifiers, email)
+	} else {
+		log.Printf("No SMTP_HOST set, not sending notification emails")
+	}
+	return notifiers
+}
+
+// What one run managed for one user. Notifications are done once every
+// channel has sent them or given up, or the user didn't want them at all.
+type deliveryResult struct {
+	delivered map[string][]int64
+	failed    map[string][]int64
+	done      []int64
+	attempted bool
+}
+
+// Sends each channel whatever it hasn't sent yet and is due to retry, so a
+// failing webhook doesn't send the same email again every run.
+func deliverTo(prefs *notify.Preferences, notifications []*notify.Notification,
+	notifiers []notify.Notifier, now time.Time) *deliveryResult {
+	result := &deliveryResult{
+		delivered: make(map[string][]int64),
+		failed:    make(map[string][]int64),
+	}
+
+	for _, notifier := range notifiers {
+		channel := notifier.Channel()
+		batch := make([]*notify.Notification, 0, len(notifications))
+		ids := make([]int64, 0, len(notifications))
+		for _, notification := range notifications {
+			if prefs.Wants(notification) && notification.Delivery(channel).DueAt(now) {
+				batch = append(batch, notification)
+				ids = append(ids, notification.Id)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		result.attempted = true
+		err := notifier.Send(prefs, batch)
+		for _, notification := range batch {
+			delivery := notification.Delivery(channel)
+			delivery.LastAttempt = now
+			if err == nil {
+				delivery.Sent = true
+			} else {
+				delivery.Attempts++
+			}
+		}
+		if err != nil {
+			log.Printf("Error notifying %v by %v: %v", prefs.Email, channel, err)
+			result.failed[channel] = ids
+		} else {
+			result.delivered[channel] = ids
+		}
+	}
+
+	for _, notification := range notifications {
+		done := true
+		if prefs.Wants(notification) {
+			for _, notifier := range notifiers {
+				done = done && notification.Delivery(notifier.Channel()).Done()
+			}
+		}
+		if done {
+			result.done = append(result.done, notification.Id)
+		}
+	}
+	return result
+}
+
+// Sends everything queued to each user who's due, respecting their
+// preferences. Failures are retried with backoff on later runs, up to
+// notify.MaxAttempts times per channel.
+func DeliverNotifications(db *sql.DB, notifiers []notify.Notifier) {
+	// Another process delivering the same queue would send everything twice
+	lock, err := postgres.TryNotifyLock(context.Background(), db)
+	if err != nil {
+		log.Printf("Error taking the notification lock: %v", err)
+		return
+	}
+	if lock == nil {
+		log.Printf("Another process is delivering notifications, skipping")
+		return
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("Error releasing the notification lock: %v", err)
+		}
+	}()
+
+	pending, err := postgres.LoadPendingNotifications(db)
+	if err != nil {
+		log.Printf("Error loading notifications: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for email, notifications := range pending {
+		prefs, err := postgres.LoadNotificationPreferences(db, email)
+		if err != nil {
+			log.Printf("Error loading notification preferences for %v: %v", email, err)
+			continue
+		}
+		if !prefs.DueAt(now) {
+			continue
+		}
+
+		result := deliverTo(prefs, notifications, notifiers, now)
+		for channel, ids := range result.delivered {
+			if err = postgres.MarkDelivered(db, channel, ids); err != nil {
+				log.Printf("Error recording %v notifications for %v: %v", channel, email, err)
+			}
+		}
+		for channel, ids := range result.failed {
+			if err = postgres.MarkDeliveryFailed(db, channel, ids); err != nil {
+				log.Printf("Error recording failed %v notifications for %v: %v", channel, email, err)
+			}
+		}
+		if len(result.done) == 0 && !result.attempted {
+			continue
+		}
+		if err = postgres.MarkNotificationsSent(db, prefs, result.done); err != nil {
+			log.Printf("Error marking notifications sent for %v: %v", email, err)
+		}
+	}
+}
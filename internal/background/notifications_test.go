@@ -0,0 +1,101 @@
+package background
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/notify"
+)
+
+type fakeNotifier struct {
+	channel string
+	err     error
+	sent    []int64
+}
+
+func (n *fakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *fakeNotifier) Send(recipient *notify.Preferences, notifications []*notify.Notification) error {
+	for _, notification := range notifications {
+		n.sent = append(n.sent, notification.Id)
+	}
+	return n.err
+}
+
+func TestDeliverToRetriesOnlyTheFailedChannel(t *testing.T) {
+	prefs := notify.DefaultPreferences("a@example.com")
+	email := &fakeNotifier{channel: "email"}
+	webhook := &fakeNotifier{channel: "webhook", err: errors.New("connection refused")}
+	notifiers := []notify.Notifier{email, webhook}
+	notifications := []*notify.Notification{{Id: 1, Reason: notify.Cancelled}}
+
+	now := time.Now()
+	result := deliverTo(prefs, notifications, notifiers, now)
+	if !slices.Equal(result.delivered["email"], []int64{1}) || !slices.Equal(result.failed["webhook"], []int64{1}) {
+		t.Errorf("Expected email delivered and webhook failed, got %v and %v", result.delivered, result.failed)
+	}
+	if len(result.done) != 0 {
+		t.Errorf("Nothing is done while the webhook can retry, got %v", result.done)
+	}
+
+	// Too soon to retry the webhook, and the email already went out
+	result = deliverTo(prefs, notifications, notifiers, now.Add(time.Minute))
+	if result.attempted {
+		t.Errorf("Expected to wait before retrying")
+	}
+
+	webhook.err = nil
+	result = deliverTo(prefs, notifications, notifiers, now.Add(notify.RetryDelay))
+	if len(email.sent) != 1 {
+		t.Errorf("Email should only be sent once, sent %v", email.sent)
+	}
+	if len(webhook.sent) != 2 {
+		t.Errorf("Webhook should be retried once, sent %v", webhook.sent)
+	}
+	if !slices.Equal(result.done, []int64{1}) {
+		t.Errorf("Expected the notification done, got %v", result.done)
+	}
+}
+
+func TestDeliverToGivesUp(t *testing.T) {
+	prefs := notify.DefaultPreferences("a@example.com")
+	webhook := &fakeNotifier{channel: "webhook", err: errors.New("connection refused")}
+	notifications := []*notify.Notification{{Id: 1, Reason: notify.Cancelled}}
+
+	now := time.Now()
+	var result *deliveryResult
+	for attempt := 0; attempt < notify.MaxAttempts; attempt++ {
+		result = deliverTo(prefs, notifications, []notify.Notifier{webhook}, now)
+		now = now.Add(notify.RetryDelay << attempt)
+	}
+	if len(webhook.sent) != notify.MaxAttempts {
+		t.Errorf("Expected %v attempts, got %v", notify.MaxAttempts, len(webhook.sent))
+	}
+	if !slices.Equal(result.done, []int64{1}) {
+		t.Errorf("Expected to give up after the last attempt, got %v", result.done)
+	}
+
+	result = deliverTo(prefs, notifications, []notify.Notifier{webhook}, now.Add(24*time.Hour))
+	if result.attempted {
+		t.Errorf("Shouldn't try again after giving up")
+	}
+}
+
+func TestDeliverToSkipsUnwanted(t *testing.T) {
+	prefs := notify.DefaultPreferences("a@example.com")
+	prefs.Tickets = false
+	email := &fakeNotifier{channel: "email"}
+	notifications := []*notify.Notification{{Id: 1, Reason: notify.SoldOut}}
+
+	result := deliverTo(prefs, notifications, []notify.Notifier{email}, time.Now())
+	if result.attempted || len(email.sent) != 0 {
+		t.Errorf("Unwanted notifications shouldn't be sent, sent %v", email.sent)
+	}
+	if !slices.Equal(result.done, []int64{1}) {
+		t.Errorf("Unwanted notifications are done, got %v", result.done)
+	}
+}
@@ -0,0 +1,282 @@
+package background
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// A copy of the event feed as it was downloaded, kept so bad imports can be
+// replayed later.
+type Snapshot struct {
+	// sha256 of the content, which is also its name in the store
+	Checksum     string    `json:"checksum"`
+	Source       string    `json:"source"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	Size         int       `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+}
+
+// How many snapshots a store keeps by default. The feed is a few megabytes
+// and changes a few times a day around the convention, so this is weeks.
+const DefaultSnapshotsKept = 100
+
+// Snapshots kept in a directory. Content goes in <checksum>.data next to
+// <checksum>.json with how it was fetched, and latest.json has the last one
+// imported so the next fetch can be conditional.
+type SnapshotStore struct {
+	Dir    string
+	Client *http.Client
+	// The newest this many are kept, older ones are deleted as new ones are
+	// fetched. Zero keeps everything.
+	Keep int
+}
+
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{Dir: dir, Client: &http.Client{Timeout: events.FetchTimeout}, Keep: DefaultSnapshotsKept}
+}
+
+func (s *SnapshotStore) contentPath(checksum string) string {
+	return filepath.Join(s.Dir, checksum+".data")
+}
+
+func (s *SnapshotStore) metadataPath(checksum string) string {
+	return filepath.Join(s.Dir, checksum+".json")
+}
+
+func (s *SnapshotStore) latestPath() string {
+	return filepath.Join(s.Dir, "latest.json")
+}
+
+func readSnapshot(path string) (*Snapshot, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err = json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("reading %v: %w", path, err)
+	}
+	return &snapshot, nil
+}
+
+func writeSnapshot(path string, snapshot *Snapshot) error {
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content)
+}
+
+// Writes to a temp file and renames it, so a crash never leaves half a file
+// that looks like a good snapshot.
+func writeFileAtomic(path string, content []byte) error {
+	temp, err := ioutil.TempFile(filepath.Dir(path), ".snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err = temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// The last snapshot imported, nil if there hasn't been one
+func (s *SnapshotStore) Latest() (*Snapshot, error) {
+	snapshot, err := readSnapshot(s.latestPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return snapshot, err
+}
+
+// Downloads the url unless it hasn't changed since the last import. Returns
+// the snapshot for what's current and whether it's different from then.
+func (s *SnapshotStore) Fetch(url string) (*Snapshot, bool, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, false, err
+	}
+	latest, err := s.Latest()
+	if err != nil {
+		return nil, false, err
+	}
+	// Validators from a different url don't mean anything here
+	if latest != nil && latest.Source != url {
+		latest = nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest != nil {
+		if latest.ETag != "" {
+			request.Header.Set("If-None-Match", latest.ETag)
+		}
+		if latest.LastModified != "" {
+			request.Header.Set("If-Modified-Since", latest.LastModified)
+		}
+	}
+
+	resp, err := s.Client.Do(request)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && latest != nil {
+		return latest, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("fetching %v: %v", url, resp.Status)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sum := sha256.Sum256(content)
+	snapshot := &Snapshot{
+		Checksum:     hex.EncodeToString(sum[:]),
+		Source:       url,
+		FetchedAt:    time.Now().UTC(),
+		Size:         len(content),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  resp.Header.Get("Content-Type"),
+	}
+	// Servers that ignore the validators still send the same bytes
+	changed := latest == nil || latest.Checksum != snapshot.Checksum
+
+	if _, err = os.Stat(s.contentPath(snapshot.Checksum)); os.IsNotExist(err) {
+		if err = writeFileAtomic(s.contentPath(snapshot.Checksum), content); err != nil {
+			return nil, false, err
+		}
+		if err = writeSnapshot(s.metadataPath(snapshot.Checksum), snapshot); err != nil {
+			return nil, false, err
+		}
+		// The new snapshot is safe either way, so this is no reason to fail
+		if err = s.Prune(); err != nil {
+			log.Printf("Unable to prune snapshots in %v: %v", s.Dir, err)
+		}
+	} else if err != nil {
+		return nil, false, err
+	}
+	return snapshot, changed, nil
+}
+
+// Marks the snapshot as imported. Until then fetches keep downloading it, so
+// an import that failed is retried.
+func (s *SnapshotStore) SetLatest(snapshot *Snapshot) error {
+	return writeSnapshot(s.latestPath(), snapshot)
+}
+
+// Every stored snapshot, oldest first
+func (s *SnapshotStore) List() ([]*Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*Snapshot, 0, len(paths))
+	for _, path := range paths {
+		if path == s.latestPath() {
+			continue
+		}
+		snapshot, err := readSnapshot(path)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].FetchedAt.Before(snapshots[j].FetchedAt)
+	})
+	return snapshots, nil
+}
+
+// Deletes the oldest snapshots past Keep. The latest one imported is never
+// deleted, fetches compare against it.
+func (s *SnapshotStore) Prune() error {
+	if s.Keep <= 0 {
+		return nil
+	}
+	snapshots, err := s.List()
+	if err != nil || len(snapshots) <= s.Keep {
+		return err
+	}
+	latest, err := s.Latest()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots[:len(snapshots)-s.Keep] {
+		if latest != nil && snapshot.Checksum == latest.Checksum {
+			continue
+		}
+		// Metadata first, so a failure part way leaves nothing listed
+		// without its content
+		if err = os.Remove(s.metadataPath(snapshot.Checksum)); err != nil {
+			return err
+		}
+		if err = os.Remove(s.contentPath(snapshot.Checksum)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finds a snapshot by its checksum or a unique prefix of it, like git does
+// for commits.
+func (s *SnapshotStore) Find(id string) (*Snapshot, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if len(id) < 6 {
+		return nil, fmt.Errorf("snapshot id %q is too short, use at least 6 characters", id)
+	}
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Snapshot
+	for _, snapshot := range snapshots {
+		if !strings.HasPrefix(snapshot.Checksum, id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("snapshot id %q is ambiguous", id)
+		}
+		found = snapshot
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no snapshot %q in %v", id, s.Dir)
+	}
+	return found, nil
+}
+
+// Reads a snapshot's content, checking it's what was stored
+func (s *SnapshotStore) Load(snapshot *Snapshot) ([]byte, error) {
+	content, err := ioutil.ReadFile(s.contentPath(snapshot.Checksum))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != snapshot.Checksum {
+		return nil, fmt.Errorf("snapshot %v is corrupt, its checksum doesn't match", snapshot.Checksum)
+	}
+	return content, nil
+}
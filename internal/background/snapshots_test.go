@@ -0,0 +1,149 @@
+package background
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Stands in for gencon.com, serving content with an ETag the way it does
+type feedServer struct {
+	content     string
+	etag        string
+	downloads   int
+	conditional int
+}
+
+func (f *feedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.etag != "" {
+		if r.Header.Get("If-None-Match") == f.etag {
+			f.conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", f.etag)
+	}
+	f.downloads++
+	w.Header().Set("Content-Type", "text/csv")
+	w.Write([]byte(f.content))
+}
+
+func TestSnapshotFetchIsConditional(t *testing.T) {
+	feed := &feedServer{content: "Game ID,Title\nRPG24ND1,Dragons\n", etag: `"v1"`}
+	server := httptest.NewServer(feed)
+	defer server.Close()
+	store := NewSnapshotStore(t.TempDir())
+
+	first, changed, err := store.Fetch(server.URL)
+	if err != nil || !changed {
+		t.Fatalf("expected a new snapshot, got %v %v", changed, err)
+	}
+	if first.ETag != `"v1"` || first.ContentType != "text/csv" || first.Size != len(feed.content) {
+		t.Errorf("HTTP metadata wasn't kept: %+v", first)
+	}
+
+	// Not imported yet, so it's downloaded again
+	if _, changed, _ = store.Fetch(server.URL); !changed || feed.downloads != 2 {
+		t.Errorf("expected a second download before the import, changed %v downloads %d", changed, feed.downloads)
+	}
+
+	if err = store.SetLatest(first); err != nil {
+		t.Fatal(err)
+	}
+	again, changed, err := store.Fetch(server.URL)
+	if err != nil || changed || feed.conditional != 1 || again.Checksum != first.Checksum {
+		t.Errorf("expected a not modified response, got changed %v conditional %d %v", changed, feed.conditional, err)
+	}
+
+	feed.content = "Game ID,Title\nRPG24ND1,Dragons Return\n"
+	feed.etag = `"v2"`
+	second, changed, err := store.Fetch(server.URL)
+	if err != nil || !changed || second.Checksum == first.Checksum {
+		t.Fatalf("expected the new content, got changed %v %v", changed, err)
+	}
+
+	snapshots, err := store.List()
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("expected both snapshots kept, got %v %v", snapshots, err)
+	}
+	old, err := store.Find(first.Checksum[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := store.Load(old)
+	if err != nil || !strings.Contains(string(content), "Dragons\n") {
+		t.Errorf("expected the original content back, got %q %v", content, err)
+	}
+}
+
+func TestSnapshotFetchWithoutValidators(t *testing.T) {
+	feed := &feedServer{content: "Game ID,Title\n"}
+	server := httptest.NewServer(feed)
+	defer server.Close()
+	store := NewSnapshotStore(t.TempDir())
+
+	snapshot, _, err := store.Fetch(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetLatest(snapshot)
+	if _, changed, err := store.Fetch(server.URL); err != nil || changed {
+		t.Errorf("expected the same bytes to count as unchanged, got %v %v", changed, err)
+	}
+}
+
+func TestSnapshotFetchErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	store := NewSnapshotStore(t.TempDir())
+
+	if _, _, err := store.Fetch(server.URL); err == nil {
+		t.Error("expected an error for a missing feed")
+	}
+	if snapshots, _ := store.List(); len(snapshots) != 0 {
+		t.Errorf("expected nothing stored, got %v", snapshots)
+	}
+	if _, err := store.Find("abc"); err == nil {
+		t.Error("expected short ids to be refused")
+	}
+}
+
+func TestSnapshotPrune(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir())
+	store.Keep = 2
+	fetched := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		snapshot := &Snapshot{Checksum: strings.Repeat(fmt.Sprint(i), 64), FetchedAt: fetched.Add(time.Duration(i) * time.Hour)}
+		if err := writeFileAtomic(store.contentPath(snapshot.Checksum), []byte("content")); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeSnapshot(store.metadataPath(snapshot.Checksum), snapshot); err != nil {
+			t.Fatal(err)
+		}
+		// The oldest is the last one imported, so it has to stay
+		if i == 0 {
+			store.SetLatest(snapshot)
+		}
+	}
+
+	if err := store.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		kept = append(kept, snapshot.Checksum[:1])
+	}
+	if strings.Join(kept, ",") != "0,2,3" {
+		t.Errorf("expected the latest and the newest 2 kept, got %v", kept)
+	}
+	if _, err = store.Find(strings.Repeat("1", 64)); err == nil {
+		t.Error("expected the pruned snapshot to be gone")
+	}
+}
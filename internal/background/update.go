@@ -2,76 +2,174 @@ package background
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"os"
-	"strings"
 )
 
-func parseHttp(sourceFile string) []*events.GenconEvent {
-	resp, err := http.Get(sourceFile)
-	
+// Returned when the feed is the same as the last import, nothing is done
+var ErrFeedUnchanged = errors.New("feed unchanged since the last import")
+
+type ImportOptions struct {
+	Mode events.ParseMode
+	// Does all the same work and rolls it back
+	DryRun bool
+	// Nil to skip the checks
+	Limits *postgres.ImportLimits
+	// Where downloads are archived, nil to fetch without keeping them
+	Snapshots *SnapshotStore
+	// Set by ReplaySnapshot, replays write events without their history
+	replay bool
+}
+
+func writeEvents(db *sql.DB, result *events.ParseResult, options ImportOptions) (*postgres.ImportReport, error) {
+	genconEvents := result.Events
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// Dry runs check the limits afterwards, so the report shows what a held
+	// import would have done
+	limits := options.Limits
+	if options.DryRun {
+		limits = nil
+	}
+	write := postgres.BulkUpdateEvents
+	if options.replay {
+		write = postgres.ReplayEvents
+	}
+	report, err := write(tx, genconEvents, result.Rejected, limits)
 	if err != nil {
-		panic(err)
+		tx.Rollback()
+		return nil, err
+	}
+	if options.DryRun {
+		report.DryRun = true
+		if err = tx.Rollback(); err != nil {
+			return nil, err
+		}
+		if options.Limits != nil {
+			return report, options.Limits.Check(report.Active, len(genconEvents), len(report.Deactivated))
+		}
+		return report, nil
 	}
-	defer resp.Body.Close()
-	spreadsheetBytes, err := ioutil.ReadAll(resp.Body)
-	return events.ParseGenconSheet(spreadsheetBytes)
+	return report, tx.Commit()
 }
 
-func parseSheet(sourceFile string) []*events.GenconEvent {
-	fileReader, err := os.Open(sourceFile)
+// Loads events from a source and writes them to the db. Nothing is written
+// if the source can't be parsed, in lenient mode that's only when the columns
+// are wrong, bad rows are just left out and reported in the result. Imports
+// past the limits are held for approval rather than written. With a snapshot
+// store, HTTP sources are archived and ErrFeedUnchanged is returned when
+// there's nothing new. Every run is recorded in the import ledger.
+func UpdateEventsFromGencon(db *sql.DB, source events.Source, options ImportOptions) (*events.ParseResult, *postgres.ImportReport, error) {
+	run := startRun(db, source.String())
+	result, report, err := updateEventsFromGencon(db, source, options, run)
+	finishRun(db, run, options, result, report, err)
+	return result, report, err
+}
+
+func updateEventsFromGencon(db *sql.DB, source events.Source, options ImportOptions, run *postgres.ImportRun) (*events.ParseResult, *postgres.ImportReport, error) {
+	log.Printf("Loading events from %v", source)
 
+	httpSource, isHttp := source.(*events.HttpSource)
+	if isHttp && options.Snapshots != nil {
+		return importFetched(db, httpSource.Url, options, run)
+	}
+	result, err := source.Load(options.Mode)
 	if err != nil {
-		panic(err)
+		// Better to keep the last good import than load garbage
+		return nil, nil, err
 	}
-	defer fileReader.Close()
-	fileBytes, err := ioutil.ReadAll(fileReader)
+	run.BytesFetched = result.Bytes
 
-	return events.ParseGenconSheet(fileBytes)
+	report, err := importEvents(db, source.String(), result, options)
+	return result, report, err
 }
 
-func parseCsv(sourceFile string) []*events.GenconEvent {
-	fileReader, err := os.Open(sourceFile)
+// Fetches the url into the snapshot store and imports it if it's changed
+func importFetched(db *sql.DB, url string, options ImportOptions, run *postgres.ImportRun) (*events.ParseResult, *postgres.ImportReport, error) {
+	snapshot, changed, err := options.Snapshots.Fetch(url)
+	if err != nil {
+		return nil, nil, &events.SourceError{Source: url, Err: err}
+	}
+	// Dry runs always go through, they're asking what an import would do
+	if !changed && !options.DryRun {
+		return nil, nil, ErrFeedUnchanged
+	}
+	log.Printf("Fetched snapshot %v", snapshot.Checksum)
+	run.BytesFetched = int64(snapshot.Size)
 
+	result, err := parseSnapshot(options.Snapshots, snapshot, options.Mode)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-	defer fileReader.Close()
-	fileBytes, err := ioutil.ReadAll(fileReader)
 
-	return events.ParseGenconCsv(fileBytes)
+	report, err := importEvents(db, url, result, options)
+	if !options.DryRun && (err == nil || errors.As(err, new(*postgres.LimitError))) {
+		// Held imports count too, fetching them again would just hold another
+		if latestErr := options.Snapshots.SetLatest(snapshot); latestErr != nil {
+			log.Printf("Unable to record snapshot %v as imported: %v", snapshot.Checksum, latestErr)
+		}
+	}
+	return result, report, err
 }
 
-func writeEvents(db *sql.DB, genconEvents []*events.GenconEvent) {
-	tx, err := db.Begin()
+func parseSnapshot(store *SnapshotStore, snapshot *Snapshot, mode events.ParseMode) (*events.ParseResult, error) {
+	content, err := store.Load(snapshot)
 	if err != nil {
-		log.Fatal(err)
+		return nil, &events.SourceError{Source: "snapshot " + snapshot.Checksum, Err: err}
 	}
-	err = postgres.BulkUpdateEvents(tx, genconEvents)
+	return events.ParseContent(snapshot.Source, content, snapshot.ContentType, mode)
+}
+
+// Imports a stored snapshot again, to reproduce what an old feed did. Only
+// the events are written, there's no change history, ticket snapshots or
+// notifications, so use a dry run to see what it would change.
+func ReplaySnapshot(db *sql.DB, store *SnapshotStore, id string, options ImportOptions) (*events.ParseResult, *postgres.ImportReport, error) {
+	options.replay = true
+	snapshot, err := store.Find(id)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
-	err = tx.Commit()
+	log.Printf("Replaying snapshot %v fetched from %v at %v", snapshot.Checksum, snapshot.Source, snapshot.FetchedAt)
+	source := "snapshot " + snapshot.Checksum
+	run := startRun(db, source)
+	run.BytesFetched = int64(snapshot.Size)
+	result, err := parseSnapshot(store, snapshot, options.Mode)
 	if err != nil {
-		log.Fatal(err)
+		finishRun(db, run, options, nil, nil, err)
+		return nil, nil, err
 	}
+	report, err := importEvents(db, source, result, options)
+	finishRun(db, run, options, result, report, err)
+	return result, report, err
 }
 
-func UpdateEventsFromGencon(db *sql.DB, sourceFile string) {
-	var events []*events.GenconEvent
-	log.Printf("Loading events from %v", sourceFile)
-
-	if strings.HasPrefix(sourceFile, "http") {
-		events = parseHttp(sourceFile)
-	} else if strings.HasSuffix(sourceFile, "xlsx") {
-		events = parseSheet(sourceFile)
-	} else {
-		events = parseCsv(sourceFile)
+func importEvents(db *sql.DB, source string, result *events.ParseResult, options ImportOptions) (*postgres.ImportReport, error) {
+	aliases, err := postgres.LoadSystemAliases(db)
+	if err != nil {
+		return nil, fmt.Errorf("loading game system aliases: %w", err)
 	}
+	aliases.ApplyAll(result.Events)
+	venue, err := postgres.LoadVenue(db)
+	if err != nil {
+		return nil, fmt.Errorf("loading the venue: %w", err)
+	}
+	venue.LocateAll(result.Events)
 
-	writeEvents(db, events)
+	report, err := writeEvents(db, result, options)
+	var limitErr *postgres.LimitError
+	// Approving a held import writes it as a live one, so replays past the
+	// limits aren't held
+	if errors.As(err, &limitErr) && !options.DryRun && !options.replay {
+		id, holdErr := postgres.HoldImport(db, result.Events[0].Year, source, limitErr.Reason, result.Events, result.Rejected)
+		if holdErr != nil {
+			return nil, fmt.Errorf("%v, and holding it failed: %w", err, holdErr)
+		}
+		return nil, fmt.Errorf("%w, held as import %d for approval", err, id)
+	}
+	return report, err
 }
@@ -0,0 +1,28 @@
+package events
+
+// Canonical game system names keyed by the misspellings and short names
+// that show up in the feed, so events can be matched up with BGG.
+type SystemAliases map[string]string
+
+// Sets the event's game system from the feed's name, renamed if that's an
+// alias, then reapplies NormalizeEvent since some of its fixes look for the
+// canonical names. Returns whether the game system changed.
+func (a SystemAliases) Apply(event *GenconEvent) bool {
+	if event.FeedGameSystem == "" {
+		// Stored before the feed's name was kept, the best we have
+		event.FeedGameSystem = event.GameSystem
+	}
+	previous := event.GameSystem
+	event.GameSystem = event.FeedGameSystem
+	if canonical, found := a[event.FeedGameSystem]; found {
+		event.GameSystem = canonical
+	}
+	NormalizeEvent(event)
+	return event.GameSystem != previous
+}
+
+func (a SystemAliases) ApplyAll(events []*GenconEvent) {
+	for _, event := range events {
+		a.Apply(event)
+	}
+}
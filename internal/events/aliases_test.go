@@ -0,0 +1,37 @@
+package events
+
+import "testing"
+
+func TestSystemAliasesApply(t *testing.T) {
+	aliases := SystemAliases{
+		"Carcassone":    "Carcassonne",
+		"Sword Sorcery": "Sword & Sorcery",
+	}
+
+	event := &GenconEvent{GameSystem: "Carcassone", FeedGameSystem: "Carcassone"}
+	if !aliases.Apply(event) || event.GameSystem != "Carcassonne" || event.FeedGameSystem != "Carcassone" {
+		t.Errorf("expected the alias applied and the feed's name kept, got %+v", event)
+	}
+	if aliases.Apply(event) {
+		t.Error("applying twice shouldn't change anything")
+	}
+
+	// NormalizeEvent's fixes apply to the canonical name
+	event = &GenconEvent{GameSystem: "Sword Sorcery", FeedGameSystem: "Sword Sorcery", RulesEdition: "Ancient Chronicles"}
+	aliases.Apply(event)
+	if event.GameSystem != "Sword & Sorcery: Ancient Chronicles" {
+		t.Errorf("expected the edition fix after the alias, got %q", event.GameSystem)
+	}
+
+	// A removed alias goes back to the feed's name
+	event = &GenconEvent{GameSystem: "Carcassonne", FeedGameSystem: "Carcassone"}
+	if !(SystemAliases{}).Apply(event) || event.GameSystem != "Carcassone" {
+		t.Errorf("expected the feed's name back, got %q", event.GameSystem)
+	}
+
+	// Stored before the feed's name was kept
+	event = &GenconEvent{GameSystem: "Carcassone"}
+	if !aliases.Apply(event) || event.GameSystem != "Carcassonne" || event.FeedGameSystem != "Carcassone" {
+		t.Errorf("expected the game system treated as the feed's, got %+v", event)
+	}
+}
@@ -0,0 +1,173 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// One field of an event changing between imports. Field is the column name
+// in the events table, values are stored as text so every field fits.
+type EventChange struct {
+	EventId  string
+	Changed  time.Time
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+const changeTimeFormat = time.RFC3339
+
+// The fields attendees care about when they move. Descriptions and the like
+// get edited for typos all the time and aren't worth tracking.
+var trackedFields = []struct {
+	field string
+	value func(event *GenconEvent) string
+}{
+	{"active", func(e *GenconEvent) string { return strconv.FormatBool(e.Active) }},
+	{"title", func(e *GenconEvent) string { return e.Title }},
+	{"start_time", func(e *GenconEvent) string { return e.StartTime.Format(changeTimeFormat) }},
+	{"end_time", func(e *GenconEvent) string { return e.EndTime.Format(changeTimeFormat) }},
+	{"location", func(e *GenconEvent) string { return e.Location }},
+	{"room_name", func(e *GenconEvent) string { return e.RoomName }},
+	{"table_number", func(e *GenconEvent) string { return e.TableNumber }},
+	{"tickets_available", func(e *GenconEvent) string { return strconv.Itoa(e.TicketsAvailable) }},
+	{"cost", func(e *GenconEvent) string { return FormatCents(e.CostCents) }},
+	{"gm_names", func(e *GenconEvent) string { return e.GMNames }},
+}
+
+// Dropping to this many tickets or fewer counts as running low
+const FewTickets = 3
+
+// Sold out, running low or plenty. Ticket counts move every import during
+// registration and ticket_snapshots has them all, so the history only gets
+// a change when the count moves between these.
+func ticketLevel(tickets int) int {
+	switch {
+	case tickets <= 0:
+		return 0
+	case tickets <= FewTickets:
+		return 1
+	}
+	return 2
+}
+
+// DiffEvents lists the tracked fields which differ between two versions of
+// the same event.
+func DiffEvents(previous, current *GenconEvent, changed time.Time) []*EventChange {
+	changes := make([]*EventChange, 0)
+	for _, tracked := range trackedFields {
+		if tracked.field == "tickets_available" &&
+			ticketLevel(previous.TicketsAvailable) == ticketLevel(current.TicketsAvailable) {
+			continue
+		}
+		oldValue := tracked.value(previous)
+		newValue := tracked.value(current)
+		if tracked.field == "start_time" || tracked.field == "end_time" {
+			// Same instant formatted in different zones isn't a change
+			oldTime, _ := time.Parse(changeTimeFormat, oldValue)
+			newTime, _ := time.Parse(changeTimeFormat, newValue)
+			if oldTime.Equal(newTime) {
+				continue
+			}
+		}
+		if oldValue != newValue {
+			changes = append(changes, &EventChange{
+				EventId:  current.EventId,
+				Changed:  changed,
+				Field:    tracked.field,
+				OldValue: oldValue,
+				NewValue: newValue,
+			})
+		}
+	}
+	return changes
+}
+
+// Cancelled events aren't in the import anymore, so there's nothing to diff
+// against, just the one change.
+func CancelledChange(eventId string, changed time.Time) *EventChange {
+	return &EventChange{
+		EventId:  eventId,
+		Changed:  changed,
+		Field:    "active",
+		OldValue: "true",
+		NewValue: "false",
+	}
+}
+
+// CollapseChanges merges repeated changes to the same field of an event into
+// one, from the earliest old value to the latest new value. Fields which
+// ended up back where they started are dropped. Results are newest first.
+func CollapseChanges(changes []*EventChange) []*EventChange {
+	sorted := make([]*EventChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Changed.Before(sorted[j].Changed)
+	})
+
+	type key struct{ eventId, field string }
+	merged := make(map[key]*EventChange)
+	order := make([]key, 0)
+	for _, change := range sorted {
+		k := key{change.EventId, change.Field}
+		if existing, found := merged[k]; found {
+			existing.NewValue = change.NewValue
+			existing.Changed = change.Changed
+			continue
+		}
+		copied := *change
+		merged[k] = &copied
+		order = append(order, k)
+	}
+
+	collapsed := make([]*EventChange, 0, len(order))
+	for _, k := range order {
+		if change := merged[k]; change.OldValue != change.NewValue {
+			collapsed = append(collapsed, change)
+		}
+	}
+	sort.SliceStable(collapsed, func(i, j int) bool {
+		return collapsed[i].Changed.After(collapsed[j].Changed)
+	})
+	return collapsed
+}
+
+func formatChangeTime(value string) string {
+	parsed, err := time.Parse(changeTimeFormat, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Format("Mon Jan 2 3:04 PM")
+}
+
+// A short human readable sentence for the change
+func (c *EventChange) Describe() string {
+	switch c.Field {
+	case "active":
+		if c.NewValue == "false" {
+			return "Cancelled"
+		}
+		return "Back on the schedule"
+	case "start_time":
+		return fmt.Sprintf("Moved from %s to %s", formatChangeTime(c.OldValue), formatChangeTime(c.NewValue))
+	case "end_time":
+		return fmt.Sprintf("Now ends %s instead of %s", formatChangeTime(c.NewValue), formatChangeTime(c.OldValue))
+	case "location":
+		return fmt.Sprintf("Moved from %s to %s", c.OldValue, c.NewValue)
+	case "room_name":
+		return fmt.Sprintf("Room changed from %s to %s", c.OldValue, c.NewValue)
+	case "table_number":
+		return fmt.Sprintf("Table changed from %s to %s", c.OldValue, c.NewValue)
+	case "tickets_available":
+		return fmt.Sprintf("Tickets went from %s to %s", c.OldValue, c.NewValue)
+	case "cost":
+		return fmt.Sprintf("Cost went from $%s to $%s", c.OldValue, c.NewValue)
+	case "gm_names":
+		return fmt.Sprintf("GMs changed from %s to %s", c.OldValue, c.NewValue)
+	case "title":
+		return fmt.Sprintf("Renamed from %s", c.OldValue)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", c.Field, c.OldValue, c.NewValue)
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDiffEvents(t *testing.T) {
+	start := time.Date(2025, time.July, 31, 10, 0, 0, 0, Indianapolis)
+	previous := &GenconEvent{
+		EventId:          "RPG25ND1",
+		Active:           true,
+		StartTime:        start,
+		EndTime:          start.Add(2 * time.Hour),
+		Location:         "ICC",
+		RoomName:         "Room 101",
+		TicketsAvailable: 6,
+	}
+	current := *previous
+	// Same time, different zone, shouldn't count
+	current.StartTime = start.UTC()
+	current.RoomName = "Room 105"
+	// Still plenty left, ticket_snapshots has the exact count
+	current.TicketsAvailable = 4
+
+	changes := DiffEvents(previous, &current, start)
+	if len(changes) != 1 {
+		t.Fatalf("Expected only the room change, got %+v", changes)
+	}
+	if changes[0].Field != "room_name" || changes[0].OldValue != "Room 101" || changes[0].NewValue != "Room 105" {
+		t.Errorf("Unexpected room change %+v", changes[0])
+	}
+
+	for _, tickets := range []int{FewTickets, 0} {
+		current.TicketsAvailable = tickets
+		changes = DiffEvents(previous, &current, start)
+		if len(changes) != 2 || changes[1].Field != "tickets_available" || changes[1].NewValue != strconv.Itoa(tickets) {
+			t.Errorf("Expected dropping to %d tickets recorded, got %+v", tickets, changes)
+		}
+	}
+}
+
+func TestCollapseChanges(t *testing.T) {
+	base := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	changes := []*EventChange{
+		{EventId: "A", Changed: base.Add(2 * time.Hour), Field: "tickets_available", OldValue: "4", NewValue: "2"},
+		{EventId: "A", Changed: base, Field: "tickets_available", OldValue: "6", NewValue: "4"},
+		{EventId: "A", Changed: base, Field: "room_name", OldValue: "101", NewValue: "105"},
+		{EventId: "A", Changed: base.Add(time.Hour), Field: "room_name", OldValue: "105", NewValue: "101"},
+		{EventId: "B", Changed: base.Add(time.Hour), Field: "active", OldValue: "true", NewValue: "false"},
+	}
+
+	collapsed := CollapseChanges(changes)
+	if len(collapsed) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", collapsed)
+	}
+	if collapsed[0].EventId != "A" || collapsed[0].OldValue != "6" || collapsed[0].NewValue != "2" {
+		t.Errorf("Expected tickets to collapse to 6 -> 2, got %+v", collapsed[0])
+	}
+	if collapsed[1].Describe() != "Cancelled" {
+		t.Errorf("Expected B to be cancelled, got %v", collapsed[1].Describe())
+	}
+	if changes[1].NewValue != "4" {
+		t.Errorf("Collapsing shouldn't modify the input")
+	}
+}
@@ -3,102 +3,51 @@ package events
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"strconv"
-	"time"
 )
 
-func intField(fieldValue string, defaultValue int, fieldName string) int {
-	if len(fieldValue) == 0 {
-		return defaultValue
-	}
-	value, err := strconv.Atoi(fieldValue)
-	if err != nil {
-		log.Fatalf("Unable to parse field '%s' with value '%s', err %v",
-			fieldName, fieldValue, err)
-	}
-	return value
-}
-
-func floatField(fieldValue string, defaultValue float64, fieldName string) float64 {
-	if len(fieldValue) == 0 {
-		return defaultValue
-	}
-	value, err := strconv.ParseFloat(fieldValue, 64)
+func ParseGenconCsv(rawBytes []byte, mode ParseMode) (*ParseResult, error) {
+	csvReader := csv.NewReader(bytes.NewBuffer(rawBytes))
+	// Let rows be ragged, missing trailing cells just import as empty
+	csvReader.FieldsPerRecord = -1
+	headers, err := csvReader.Read()
 	if err != nil {
-		log.Fatalf("Unable to parse field '%s' with value '%s', err %v",
-			fieldName, fieldValue, err)
+		return nil, fmt.Errorf("unable to read csv header: %w", err)
 	}
-	return value
-}
-
-func linetoEvent(row []string) *GenconEvent {
-	startTime := parseTime(row[13])
-	duration := (int)(60 * floatField(row[14], 0, "Duration"))
-	endTime := startTime.Add((time.Duration)(1e9 * 60 * duration))
-
-	eventId := row[0]
-	shortCategory, year, _, _ := splitId(eventId)
-
-	indy, _ := time.LoadLocation("America/Indianapolis")
-	lastModified, _ := time.ParseInLocation("01-02-06", row[30], indy)
-
-	return &GenconEvent{
-		EventId:              eventId,
-		Year:                 year,
-		Active:               true,
-		Group:                row[1],
-		Title:                row[2],
-		ShortDescription:     row[3],
-		LongDescription:      row[4],
-		EventType:            row[5],
-		GameSystem:           row[6],
-		RulesEdition:         row[7],
-		MinPlayers:           intField(row[8], 0, "MinPlayers"),
-		MaxPlayers:           intField(row[9], 0, "MaxPlayers"),
-		AgeRequired:          row[10],
-		ExperienceRequired:   row[11],
-		MaterialsProvided:    row[12] == "Yes",
-		StartTime:            startTime,
-		Duration:             duration,
-		EndTime:              endTime,
-		GMNames:              row[16],
-		Website:              row[17],
-		Email:                row[18],
-		Tournament:           row[19] == "Yes",
-		RoundNumber:          intField(row[20], 0, "RoundNumber"),
-		TotalRounds:          intField(row[21], 0, "TotalRounds"),
-		MinPlayTime:          (int)(60 * floatField(row[22], 0, "MinPlayTime")),
-		AttendeeRegistration: row[23],
-		Cost:                 (int)(floatField(row[24], 0, "Cost")),
-		Location:             row[25],
-		RoomName:             row[26],
-		TableNumber:          row[27],
-		SpecialCategory:      row[28],
-		TicketsAvailable:     intField(row[29], 0, "TicketsAvailable"),
-		LastModified:         lastModified,
-		ShortCategory:        shortCategory,
+	columns, report := MapColumns(EventSchema, headers)
+	if err = report.Err(); err != nil {
+		return nil, err
 	}
-}
 
-func ParseGenconCsv(rawBytes []byte) []*GenconEvent {
-	csvReader := csv.NewReader(bytes.NewBuffer(rawBytes))
-	line, err := csvReader.Read()
-	if err != nil {
-		fmt.Println(line)
-	}
-	var events = make([]*GenconEvent, 0)
+	result := &ParseResult{Events: make([]*GenconEvent, 0), Headers: report}
 	for {
-		line, err = csvReader.Read()
+		line, err := csvReader.Read()
 		if err == io.EOF {
-			return events
+			return result, nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// The reader picks up again on the next line
+			err = result.addRow(mode, parseErr.StartLine, nil, []*RowProblem{{Reason: parseErr.Err.Error()}})
+			if err != nil {
+				return nil, err
+			}
+			continue
 		} else if err != nil {
-			log.Fatal("Errored during parsing", err)
+			return nil, fmt.Errorf("errored during parsing: %w", err)
 		}
 
-		events = append(events, linetoEvent(line))
+		row, _ := csvReader.FieldPos(0)
+		cells := make([]rawCell, len(line))
+		for i, value := range line {
+			cells[i] = rawCell{Text: value}
+		}
+		event, problems := columns.toEvent(cells)
+		if err = result.addRow(mode, row, event, problems); err != nil {
+			return nil, err
+		}
 	}
-	return events
 }
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// How much experience an event expects, ordered so lower levels are easier.
+// Gen Con's labels start with the level, like "Some (You've played it a bit
+// and understand the basics)".
+type ExperienceLevel int
+
+const (
+	UnknownExperience ExperienceLevel = iota
+	NoExperience
+	SomeExperience
+	ExpertExperience
+)
+
+var experienceLevels = map[string]ExperienceLevel{
+	"none":   NoExperience,
+	"some":   SomeExperience,
+	"expert": ExpertExperience,
+}
+
+func (l ExperienceLevel) String() string {
+	switch l {
+	case NoExperience:
+		return "none"
+	case SomeExperience:
+		return "some"
+	case ExpertExperience:
+		return "expert"
+	}
+	return ""
+}
+
+// The level named by a search or an api call, like "some"
+func LookupExperienceLevel(name string) (ExperienceLevel, bool) {
+	level, found := experienceLevels[strings.ToLower(strings.TrimSpace(name))]
+	return level, found
+}
+
+func ParseExperienceLevel(label string) ExperienceLevel {
+	word := strings.ToLower(strings.TrimSpace(label))
+	if i := strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }); i >= 0 {
+		word = word[:i]
+	}
+	return experienceLevels[word]
+}
+
+// How attendees get into an event
+type Registration string
+
+const (
+	UnknownRegistration Registration = ""
+	// Buy a ticket, or use generic tickets
+	TicketRegistration Registration = "ticket"
+	// Just show up
+	FreeRegistration Registration = "free"
+	// Attendees can't sign up themselves, like later rounds of tournaments
+	ClosedRegistration Registration = "closed"
+)
+
+var registrations = map[string]Registration{
+	"ticket": TicketRegistration,
+	"free":   FreeRegistration,
+	"closed": ClosedRegistration,
+}
+
+func LookupRegistration(name string) (Registration, bool) {
+	registration, found := registrations[strings.ToLower(strings.TrimSpace(name))]
+	return registration, found
+}
+
+// Reads the feed's "Attendee Registration?" answer, like "Yes, they can
+// register for this round without having played in any other events" or
+// "No, this event does not require tickets!"
+func ParseRegistration(label string) Registration {
+	lower := strings.ToLower(strings.TrimSpace(label))
+	switch {
+	case lower == "":
+		return UnknownRegistration
+	case strings.Contains(lower, "not require ticket"), strings.Contains(lower, "free"):
+		return FreeRegistration
+	case strings.HasPrefix(lower, "yes"):
+		return TicketRegistration
+	case strings.HasPrefix(lower, "no"):
+		return ClosedRegistration
+	}
+	return UnknownRegistration
+}
+
+// The ages an event is open to. Min is -1 when the label doesn't say, Max
+// is 0 when there's no upper limit.
+type AgeRange struct {
+	Min int
+	Max int
+}
+
+var UnknownAges = AgeRange{Min: -1}
+
+func (r AgeRange) Known() bool {
+	return r.Min >= 0
+}
+
+// Whether someone of the given age can attend
+func (r AgeRange) Allows(age int) bool {
+	return r.Known() && r.Min <= age && (r.Max == 0 || age <= r.Max)
+}
+
+var (
+	agePlus  = regexp.MustCompile(`(\d+)\s*\+`)
+	ageUnder = regexp.MustCompile(`(\d+)\s*(?:and|&|or)\s*(?:under|younger)`)
+	ageSpan  = regexp.MustCompile(`(\d+)\s*(?:-|–|to)\s*(\d+)`)
+)
+
+// For labels that don't give the ages in numbers
+var ageKeywords = []struct {
+	prefix string
+	ages   AgeRange
+}{
+	{"everyone", AgeRange{}},
+	{"kids", AgeRange{Max: 12}},
+	{"teen", AgeRange{Min: 13}},
+	{"mature", AgeRange{Min: 18}},
+	{"adult", AgeRange{Min: 21}},
+	{"drinking", AgeRange{Min: 21}},
+}
+
+// Reads Gen Con's age labels, like "Everyone (6+)", "Kids Only (12 and
+// under)" or "Teen (13+)".
+func ParseAgeRange(label string) AgeRange {
+	lower := strings.ToLower(strings.TrimSpace(label))
+	if match := ageSpan.FindStringSubmatch(lower); match != nil {
+		low, _ := strconv.Atoi(match[1])
+		high, _ := strconv.Atoi(match[2])
+		if low <= high {
+			return AgeRange{Min: low, Max: high}
+		}
+	}
+	if match := ageUnder.FindStringSubmatch(lower); match != nil {
+		high, _ := strconv.Atoi(match[1])
+		return AgeRange{Max: high}
+	}
+	if match := agePlus.FindStringSubmatch(lower); match != nil {
+		low, _ := strconv.Atoi(match[1])
+		return AgeRange{Min: low}
+	}
+	for _, keyword := range ageKeywords {
+		if strings.HasPrefix(lower, keyword.prefix) {
+			return keyword.ages
+		}
+	}
+	return UnknownAges
+}
+
+// Works out the typed fields from the feed's labels
+func ParseDetails(event *GenconEvent) {
+	ages := ParseAgeRange(event.AgeRequired)
+	event.MinAge, event.MaxAge = ages.Min, ages.Max
+	event.ExperienceLevel = ParseExperienceLevel(event.ExperienceRequired)
+	event.Registration = ParseRegistration(event.AttendeeRegistration)
+}
+
+func (e *GenconEvent) Ages() AgeRange {
+	return AgeRange{Min: e.MinAge, Max: e.MaxAge}
+}
+
+// In dollars, whole dollars without the cents, 4 rather than 4.00
+func FormatCents(cents int) string {
+	if cents%100 == 0 {
+		return strconv.Itoa(cents / 100)
+	}
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+func (e *GenconEvent) Price() string {
+	return "$" + FormatCents(e.CostCents)
+}
@@ -0,0 +1,92 @@
+package events
+
+import "testing"
+
+func TestParseAgeRange(t *testing.T) {
+	cases := map[string]AgeRange{
+		"Everyone (6+)":            {Min: 6},
+		"Kids Only (12 and under)": {Max: 12},
+		"Teen (13+)":               {Min: 13},
+		"Mature (18+)":             {Min: 18},
+		"Adults Only (21+)":        {Min: 21},
+		"Ages 8-12":                {Min: 8, Max: 12},
+		"Everyone":                 {},
+		"Teen":                     {Min: 13},
+		"":                         UnknownAges,
+		"Ask the GM":               UnknownAges,
+	}
+	for label, expected := range cases {
+		if got := ParseAgeRange(label); got != expected {
+			t.Errorf("%q: expected %+v, got %+v", label, expected, got)
+		}
+	}
+}
+
+func TestAgeRangeAllows(t *testing.T) {
+	cases := []struct {
+		ages    AgeRange
+		age     int
+		allowed bool
+	}{
+		{AgeRange{Min: 6}, 8, true},
+		{AgeRange{Min: 13}, 8, false},
+		{AgeRange{Max: 12}, 8, true},
+		{AgeRange{Max: 12}, 13, false},
+		{AgeRange{}, 8, true},
+		{UnknownAges, 8, false},
+	}
+	for _, c := range cases {
+		if got := c.ages.Allows(c.age); got != c.allowed {
+			t.Errorf("%+v allows %d: expected %v, got %v", c.ages, c.age, c.allowed, got)
+		}
+	}
+}
+
+func TestParseExperienceLevel(t *testing.T) {
+	cases := map[string]ExperienceLevel{
+		"None (You've never played before - rules will be taught)": NoExperience,
+		"Some (You've played it a bit and understand the basics)":  SomeExperience,
+		"Expert (You play it regularly and know all the rules)":    ExpertExperience,
+		"none":       NoExperience,
+		"":           UnknownExperience,
+		"Nonesuch":   UnknownExperience,
+		"Beginners!": UnknownExperience,
+	}
+	for label, expected := range cases {
+		if got := ParseExperienceLevel(label); got != expected {
+			t.Errorf("%q: expected %v, got %v", label, expected, got)
+		}
+	}
+}
+
+func TestParseRegistration(t *testing.T) {
+	cases := map[string]Registration{
+		"Yes, they can register for this round without having played in any other events": TicketRegistration,
+		"No, this event does not require tickets!":                                        FreeRegistration,
+		"No, they must qualify in an earlier round":                                       ClosedRegistration,
+		"": UnknownRegistration,
+	}
+	for label, expected := range cases {
+		if got := ParseRegistration(label); got != expected {
+			t.Errorf("%q: expected %q, got %q", label, expected, got)
+		}
+	}
+}
+
+func TestCostInCents(t *testing.T) {
+	csv := "Game ID,Title,Start Date & Time,Duration,Tickets Available,Cost $\n" +
+		"RPG24ND1,Cheap,08/01/2024 03:00 PM,2,6,2.5\n" +
+		"RPG24ND2,Dear,08/01/2024 03:00 PM,2,6,4\n"
+	result, err := ParseGenconCsv([]byte(csv), Lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"$2.50", "$4"} {
+		if got := result.Events[i].Price(); got != expected {
+			t.Errorf("%v: expected %v, got %v", result.Events[i].EventId, expected, got)
+		}
+	}
+	if result.Events[0].Cost != 2 {
+		t.Errorf("expected whole dollars to round down, got %d", result.Events[0].Cost)
+	}
+}
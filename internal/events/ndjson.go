@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// An ndjson line can be a whole event
+const maxNdjsonLine = 1 << 20
+
+// One decoded line, values are nil when it couldn't be
+type ndjsonLine struct {
+	row    int
+	text   []byte
+	values map[string]interface{}
+	err    error
+}
+
+func (l *ndjsonLine) isRecord() bool {
+	_, found := l.values["schemaVersion"]
+	return found
+}
+
+// Cells are taken as text, like csv. Numbers especially, a JSON number in a
+// time column isn't a spreadsheet date.
+func ndjsonCell(value interface{}) (rawCell, error) {
+	switch v := value.(type) {
+	case nil:
+		return rawCell{}, nil
+	case string:
+		return rawCell{Text: v}, nil
+	case json.Number:
+		return rawCell{Text: v.String()}, nil
+	case bool:
+		if v {
+			return rawCell{Text: "Yes"}, nil
+		}
+		return rawCell{Text: "No"}, nil
+	}
+	return rawCell{}, fmt.Errorf("needs a string, number or boolean")
+}
+
+// Parses events written one JSON object per line, either EventRecords or rows
+// keyed by the sheet's headers. Keys in rows are matched the way headers are,
+// so "gameId" works as well as "Game ID". Blank lines are skipped, rows are
+// counted by line.
+func ParseGenconNdjson(rawBytes []byte, mode ParseMode) (*ParseResult, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(rawBytes))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNdjsonLine)
+
+	// Every key seen, since lines can leave out empty ones. Lines can spell
+	// a key differently, "gameId" and "Game ID" are the same column.
+	lines := make([]*ndjsonLine, 0)
+	headers := make([]string, 0)
+	keyIndexes := make(map[string]int)
+	for row := 1; scanner.Scan(); row++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		line := &ndjsonLine{row: row, text: append([]byte(nil), text...)}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		if line.err = decoder.Decode(&line.values); line.err == nil && decoder.More() {
+			line.err = fmt.Errorf("more than one value on the line")
+		}
+		lines = append(lines, line)
+		if line.isRecord() {
+			continue
+		}
+
+		keys := make([]string, 0, len(line.values))
+		for key := range line.values {
+			if _, found := keyIndexes[normalizeHeader(key)]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, found := keyIndexes[normalizeHeader(key)]; !found {
+				keyIndexes[normalizeHeader(key)] = len(headers)
+				headers = append(headers, key)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read ndjson: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no events in the ndjson")
+	}
+
+	// Files of nothing but records don't have headers to check
+	result := &ParseResult{Events: make([]*GenconEvent, 0)}
+	var columns *ColumnMap
+	if len(headers) > 0 {
+		columns, result.Headers = MapColumns(EventSchema, headers)
+		if err := result.Headers.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range lines {
+		if line.err != nil {
+			err := result.addRow(mode, line.row, nil, []*RowProblem{{Reason: "isn't a JSON object: " + line.err.Error()}})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if line.isRecord() {
+			event, problems := parseRecord(line.text)
+			if err := result.addRow(mode, line.row, event, problems); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		cells := make([]rawCell, len(headers))
+		var problems []*RowProblem
+		for key, value := range line.values {
+			cell, err := ndjsonCell(value)
+			if err != nil {
+				problems = append(problems, &RowProblem{Column: key, Value: fmt.Sprint(value), Reason: err.Error()})
+				continue
+			}
+			cells[keyIndexes[normalizeHeader(key)]] = cell
+		}
+		var event *GenconEvent
+		if len(problems) == 0 {
+			event, problems = columns.toEvent(cells)
+		}
+		if err := result.addRow(mode, line.row, event, problems); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func parseRecord(text []byte) (*GenconEvent, []*RowProblem) {
+	var record EventRecord
+	if err := json.Unmarshal(text, &record); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, []*RowProblem{{Column: typeErr.Field, Value: typeErr.Value, Reason: "needs a " + typeErr.Type.String()}}
+		}
+		return nil, []*RowProblem{{Reason: err.Error()}}
+	}
+	return record.Event()
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ParseMode int
+
+const (
+	// Skips rows that can't be imported, collecting why in the result
+	Lenient ParseMode = iota
+	// Fails on the first row that can't be imported
+	Strict
+)
+
+// Why one value kept a row out of the import
+type RowProblem struct {
+	// Row in the file, the header is row 1
+	Row     int
+	EventId string
+	Column  string
+	Value   string
+	Reason  string
+}
+
+func (p *RowProblem) Error() string {
+	where := fmt.Sprintf("row %d", p.Row)
+	if p.EventId != "" {
+		where += fmt.Sprintf(" (%s)", p.EventId)
+	}
+	if p.Column == "" {
+		return fmt.Sprintf("%s: %s", where, p.Reason)
+	}
+	return fmt.Sprintf("%s: %s %q %s", where, p.Column, p.Value, p.Reason)
+}
+
+type ParseResult struct {
+	Events  []*GenconEvent
+	Headers *HeaderReport
+	// Rows left out in lenient mode, with every problem found on them
+	Rejected     []*RowProblem
+	RejectedRows int
+	// Size of the content parsed
+	Bytes int64
+}
+
+// Adds a row's event, or its problems. Only returns an error in strict mode.
+func (r *ParseResult) addRow(mode ParseMode, row int, event *GenconEvent, problems []*RowProblem) error {
+	for _, problem := range problems {
+		problem.Row = row
+	}
+	if len(problems) > 0 {
+		if mode == Strict {
+			return problems[0]
+		}
+		r.Rejected = append(r.Rejected, problems...)
+		r.RejectedRows++
+		return nil
+	}
+	if event != nil {
+		r.Events = append(r.Events, event)
+	}
+	return nil
+}
+
+func (r *ParseResult) Summary() string {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Parsed %d events, rejected %d rows", len(r.Events), r.RejectedRows)
+	if r.Headers != nil && r.Headers.HasProblems() {
+		fmt.Fprintf(&summary, "\n%v", r.Headers)
+	}
+	for _, problem := range r.Rejected {
+		fmt.Fprintf(&summary, "\n  %v", problem)
+	}
+	return summary.String()
+}
+
+// Splits events up by year, returning the years in order
+func PartitionByYear(parsed []*GenconEvent) ([]int, map[int][]*GenconEvent) {
+	byYear := make(map[int][]*GenconEvent)
+	years := make([]int, 0)
+	for _, event := range parsed {
+		if _, found := byYear[event.Year]; !found {
+			years = append(years, event.Year)
+		}
+		byYear[event.Year] = append(byYear[event.Year], event)
+	}
+	sort.Ints(years)
+	return years, byYear
+}
@@ -0,0 +1,134 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const badRowsCsv = `Game ID,Title,Start Date & Time,Duration,Tickets Available,Cost $
+RPG15ND1,Fine,07/30/2015 03:00 PM,2,6,4
+RPG15ND2,Bad Duration,07/30/2015 03:00 PM,two,6,4
+,No Id,07/30/2015 03:00 PM,2,6,4
+RPG15ND4,Bad Start,sometime thursday,2,lots,4
+
+XYZ,Bad Id,07/30/2015 03:00 PM,2,6,4
+RPG15ND7,Also Fine,07/31/2015 03:00 PM,1,2,0
+`
+
+func TestLenientSkipsBadRows(t *testing.T) {
+	result, err := ParseGenconCsv([]byte(badRowsCsv), Lenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Events) != 2 || result.Events[0].EventId != "RPG15ND1" || result.Events[1].EventId != "RPG15ND7" {
+		t.Errorf("expected only the good rows, got %v", result.Events)
+	}
+	if result.RejectedRows != 4 {
+		t.Errorf("expected 4 rejected rows, got %d", result.RejectedRows)
+	}
+
+	expected := []RowProblem{
+		{Row: 3, EventId: "RPG15ND2", Column: "Duration", Value: "two", Reason: "needs a number"},
+		{Row: 4, EventId: "", Column: "Game ID", Value: "", Reason: "needs an event id"},
+		{Row: 5, EventId: "RPG15ND4", Column: "Start Date & Time", Value: "sometime thursday"},
+		{Row: 5, EventId: "RPG15ND4", Column: "Tickets Available", Value: "lots", Reason: "needs a number"},
+		{Row: 7, EventId: "XYZ", Column: "Game ID", Value: "XYZ"},
+	}
+	if len(result.Rejected) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), result.Rejected)
+	}
+	for i, want := range expected {
+		got := result.Rejected[i]
+		if got.Row != want.Row || got.EventId != want.EventId || got.Column != want.Column || got.Value != want.Value {
+			t.Errorf("problem %d: expected %+v, got %+v", i, want, *got)
+		}
+		if want.Reason != "" && got.Reason != want.Reason {
+			t.Errorf("problem %d: expected reason %q, got %q", i, want.Reason, got.Reason)
+		}
+	}
+
+	summary := result.Summary()
+	for _, want := range []string{"Parsed 2 events, rejected 4 rows", `row 3 (RPG15ND2): Duration "two" needs a number`} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+}
+
+func TestStrictFailsOnFirstBadRow(t *testing.T) {
+	result, err := ParseGenconCsv([]byte(badRowsCsv), Strict)
+	if result != nil {
+		t.Errorf("expected no result, got %v", result)
+	}
+	var problem *RowProblem
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected a row problem, got %v", err)
+	}
+	if problem.Row != 3 || problem.Column != "Duration" {
+		t.Errorf("expected the bad duration on row 3, got %v", problem)
+	}
+}
+
+func TestMalformedCsvLine(t *testing.T) {
+	csv := "Game ID,Title,Start Date & Time,Duration,Tickets Available\n" +
+		"RPG15ND1,\"Unclosed \"quote,07/30/2015 03:00 PM,2,6\n" +
+		"RPG15ND2,Fine,07/30/2015 03:00 PM,2,6\n"
+
+	result, err := ParseGenconCsv([]byte(csv), Lenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Events) != 1 || result.RejectedRows != 1 || result.Rejected[0].Row != 2 {
+		t.Errorf("expected the malformed line rejected, got %v and %v", result.Events, result.Rejected)
+	}
+
+	if _, err = ParseGenconCsv([]byte(csv), Strict); err == nil {
+		t.Error("expected strict mode to fail")
+	}
+}
+
+func TestParseGenconSheetErrors(t *testing.T) {
+	if _, err := ParseGenconSheet([]byte("not a zip"), Lenient); err == nil {
+		t.Error("expected an error for a non-zip file")
+	}
+
+	sheet := `<worksheet><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>Game ID</t></is></c><c r="B1" t="inlineStr"><is><t>Title</t></is></c><c r="C1" t="inlineStr"><is><t>Start Date &amp; Time</t></is></c><c r="D1" t="inlineStr"><is><t>Duration</t></is></c><c r="E1" t="inlineStr"><is><t>Tickets Available</t></is></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>RPG15ND1</t></is></c><c r="D2" t="inlineStr"><is><t>long</t></is></c></row>
+<row r="9"><c r="A9" t="inlineStr"><is><t>RPG15ND2</t></is></c><c r="C9" t="inlineStr"><is><t>07/30/2015 03:00 PM</t></is></c><c r="D9"><v>2</v></c></row>
+</sheetData></worksheet>`
+	result, err := ParseGenconSheet(buildSheet(t, "<sst></sst>", sheet), Lenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Events) != 1 || len(result.Rejected) != 1 || result.Rejected[0].Row != 2 {
+		t.Errorf("expected row 2 rejected, got %v and %v", result.Events, result.Rejected)
+	}
+}
+
+func TestYearFromBadId(t *testing.T) {
+	for _, eventId := range []string{"", "RPG", "RPG1", "RPG10ND1"} {
+		if year := YearFromEvent(eventId); year != 0 {
+			t.Errorf("YearFromEvent(%q) = %d, want 0", eventId, year)
+		}
+	}
+}
+
+func TestPartitionByYear(t *testing.T) {
+	result, err := ParseGenconCsv([]byte(`Game ID,Title,Start Date & Time,Duration,Tickets Available
+RPG16ND1,Later,07/30/2016 03:00 PM,2,6
+RPG15ND1,Earlier,07/30/2015 03:00 PM,2,6
+RPG16ND2,Later Again,07/31/2016 03:00 PM,2,6
+`), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	years, byYear := PartitionByYear(result.Events)
+	if len(years) != 2 || years[0] != 2015 || years[1] != 2016 {
+		t.Fatalf("expected 2015 then 2016, got %v", years)
+	}
+	if len(byYear[2015]) != 1 || len(byYear[2016]) != 2 || byYear[2016][1].EventId != "RPG16ND2" {
+		t.Errorf("wrong partitions: %v", byYear)
+	}
+}
@@ -0,0 +1,228 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Bumped when a field changes meaning or is removed. Adding a field doesn't
+// bump it, readers ignore fields they don't know.
+const RecordSchemaVersion = 1
+
+// One event as a line of ndjson, the format cmd/export writes and the
+// importer reads, for moving events between environments. Unlike the sheet,
+// values are typed and kept as the site stores them:
+//
+//   - times are RFC 3339 with their offset, and lastModified is left out
+//     when the feed didn't say
+//   - duration is in minutes, cost in whole dollars and costCents exact,
+//     older exports only have cost
+//   - minAge, maxAge, experienceLevel and registration are informational,
+//     they're worked out again from the labels on import
+//   - gameSystem has aliases applied, feedGameSystem is as gencon had it,
+//     and imports apply their own aliases to feedGameSystem
+//   - year and shortCategory are checked against eventId
+//   - orgId and clusterId are what the exporting site had worked out, and
+//     are only informational, the importing site works out its own
+//
+// Every line carries schemaVersion, which is how the importer tells records
+// from rows keyed by the sheet's headers.
+type EventRecord struct {
+	SchemaVersion        int        `json:"schemaVersion"`
+	EventId              string     `json:"eventId"`
+	Year                 int        `json:"year"`
+	Active               bool       `json:"active"`
+	Group                string     `json:"group"`
+	Title                string     `json:"title"`
+	ShortDescription     string     `json:"shortDescription"`
+	LongDescription      string     `json:"longDescription"`
+	EventType            string     `json:"eventType"`
+	GameSystem           string     `json:"gameSystem"`
+	FeedGameSystem       string     `json:"feedGameSystem"`
+	RulesEdition         string     `json:"rulesEdition"`
+	MinPlayers           int        `json:"minPlayers"`
+	MaxPlayers           int        `json:"maxPlayers"`
+	AgeRequired          string     `json:"ageRequired"`
+	ExperienceRequired   string     `json:"experienceRequired"`
+	MaterialsProvided    bool       `json:"materialsProvided"`
+	StartTime            time.Time  `json:"startTime"`
+	Duration             int        `json:"duration"`
+	EndTime              time.Time  `json:"endTime"`
+	GMNames              string     `json:"gmNames"`
+	Website              string     `json:"website"`
+	Email                string     `json:"email"`
+	Tournament           bool       `json:"tournament"`
+	RoundNumber          int        `json:"roundNumber"`
+	TotalRounds          int        `json:"totalRounds"`
+	MinPlayTime          int        `json:"minPlayTime"`
+	AttendeeRegistration string     `json:"attendeeRegistration"`
+	Cost                 int        `json:"cost"`
+	CostCents            int        `json:"costCents"`
+	Location             string     `json:"location"`
+	RoomName             string     `json:"roomName"`
+	TableNumber          string     `json:"tableNumber"`
+	SpecialCategory      string     `json:"specialCategory"`
+	TicketsAvailable     int        `json:"ticketsAvailable"`
+	LastModified         *time.Time `json:"lastModified,omitempty"`
+	ShortCategory        string     `json:"shortCategory"`
+	OrgId                int64      `json:"orgId,omitempty"`
+	ClusterId            string     `json:"clusterId,omitempty"`
+	MinAge               int        `json:"minAge"`
+	MaxAge               int        `json:"maxAge"`
+	ExperienceLevel      string     `json:"experienceLevel"`
+	Registration         string     `json:"registration"`
+}
+
+func NewEventRecord(event *GenconEvent) *EventRecord {
+	record := &EventRecord{
+		SchemaVersion:        RecordSchemaVersion,
+		EventId:              event.EventId,
+		Year:                 event.Year,
+		Active:               event.Active,
+		Group:                event.Group,
+		Title:                event.Title,
+		ShortDescription:     event.ShortDescription,
+		LongDescription:      event.LongDescription,
+		EventType:            event.EventType,
+		GameSystem:           event.GameSystem,
+		FeedGameSystem:       event.FeedGameSystem,
+		RulesEdition:         event.RulesEdition,
+		MinPlayers:           event.MinPlayers,
+		MaxPlayers:           event.MaxPlayers,
+		AgeRequired:          event.AgeRequired,
+		ExperienceRequired:   event.ExperienceRequired,
+		MaterialsProvided:    event.MaterialsProvided,
+		StartTime:            event.StartTime,
+		Duration:             event.Duration,
+		EndTime:              event.EndTime,
+		GMNames:              event.GMNames,
+		Website:              event.Website,
+		Email:                event.Email,
+		Tournament:           event.Tournament,
+		RoundNumber:          event.RoundNumber,
+		TotalRounds:          event.TotalRounds,
+		MinPlayTime:          event.MinPlayTime,
+		AttendeeRegistration: event.AttendeeRegistration,
+		Cost:                 event.Cost,
+		CostCents:            event.CostCents,
+		Location:             event.Location,
+		RoomName:             event.RoomName,
+		TableNumber:          event.TableNumber,
+		SpecialCategory:      event.SpecialCategory,
+		TicketsAvailable:     event.TicketsAvailable,
+		ShortCategory:        event.ShortCategory,
+		OrgId:                event.OrgId,
+		MinAge:               event.MinAge,
+		MaxAge:               event.MaxAge,
+		ExperienceLevel:      event.ExperienceLevel.String(),
+		Registration:         string(event.Registration),
+	}
+	if !event.LastModified.IsZero() {
+		lastModified := event.LastModified
+		record.LastModified = &lastModified
+	}
+	return record
+}
+
+// The event the record describes, or why it can't be imported
+func (r *EventRecord) Event() (*GenconEvent, []*RowProblem) {
+	if r.SchemaVersion > RecordSchemaVersion {
+		return nil, []*RowProblem{{
+			EventId: r.EventId,
+			Column:  "schemaVersion",
+			Value:   fmt.Sprint(r.SchemaVersion),
+			Reason:  fmt.Sprintf("is newer than this site reads, up to %d", RecordSchemaVersion),
+		}}
+	}
+
+	var problems []*RowProblem
+	problem := func(column string, value interface{}, reason string) {
+		problems = append(problems, &RowProblem{EventId: r.EventId, Column: column, Value: fmt.Sprint(value), Reason: reason})
+	}
+	category, year, _, _, err := splitId(r.EventId)
+	switch {
+	case r.EventId == "":
+		problem("eventId", r.EventId, "needs an event id")
+	case err != nil:
+		problem("eventId", r.EventId, err.Error())
+	case r.Year != 0 && r.Year != year:
+		problem("year", r.Year, fmt.Sprintf("doesn't match the event id's %d", year))
+	}
+	if r.StartTime.IsZero() {
+		problem("startTime", "", "needs a time, like 2024-08-01T15:00:00-04:00")
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	event := &GenconEvent{
+		EventId:              r.EventId,
+		Year:                 year,
+		Active:               r.Active,
+		Group:                r.Group,
+		Title:                r.Title,
+		ShortDescription:     r.ShortDescription,
+		LongDescription:      r.LongDescription,
+		EventType:            r.EventType,
+		GameSystem:           r.GameSystem,
+		FeedGameSystem:       r.FeedGameSystem,
+		RulesEdition:         r.RulesEdition,
+		MinPlayers:           r.MinPlayers,
+		MaxPlayers:           r.MaxPlayers,
+		AgeRequired:          r.AgeRequired,
+		ExperienceRequired:   r.ExperienceRequired,
+		MaterialsProvided:    r.MaterialsProvided,
+		StartTime:            r.StartTime,
+		Duration:             r.Duration,
+		EndTime:              r.EndTime,
+		GMNames:              r.GMNames,
+		Website:              r.Website,
+		Email:                r.Email,
+		Tournament:           r.Tournament,
+		RoundNumber:          r.RoundNumber,
+		TotalRounds:          r.TotalRounds,
+		MinPlayTime:          r.MinPlayTime,
+		AttendeeRegistration: r.AttendeeRegistration,
+		Cost:                 r.Cost,
+		CostCents:            r.CostCents,
+		Location:             r.Location,
+		RoomName:             r.RoomName,
+		TableNumber:          r.TableNumber,
+		SpecialCategory:      r.SpecialCategory,
+		TicketsAvailable:     r.TicketsAvailable,
+		ShortCategory:        category,
+	}
+	if r.EndTime.IsZero() {
+		event.EndTime = event.StartTime.Add(time.Duration(event.Duration) * time.Minute)
+	}
+	if r.LastModified != nil {
+		event.LastModified = *r.LastModified
+	}
+	// Hand written records can leave it out
+	if event.FeedGameSystem == "" {
+		event.FeedGameSystem = event.GameSystem
+	}
+	if r.CostCents == 0 {
+		event.CostCents = r.Cost * 100
+	}
+	ParseDetails(event)
+	return event, nil
+}
+
+// Writes events as ndjson records, one per line
+type EventWriter struct {
+	encoder *json.Encoder
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	encoder := json.NewEncoder(w)
+	// Descriptions have plenty of & and <, keep them readable
+	encoder.SetEscapeHTML(false)
+	return &EventWriter{encoder: encoder}
+}
+
+func (w *EventWriter) Write(record *EventRecord) error {
+	return w.encoder.Encode(record)
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventRecordsRoundTrip(t *testing.T) {
+	start := time.Date(2024, 8, 1, 15, 0, 0, 0, Indianapolis)
+	original := []*GenconEvent{
+		{
+			EventId: "RPG24ND1", Year: 2024, Active: true, Group: "Dragon Co & Friends", Title: "Dragons <Live>",
+			GameSystem: "Dungeons & Dragons", FeedGameSystem: "D&D", StartTime: start, Duration: 120,
+			EndTime: start.Add(2 * time.Hour), Cost: 4, CostCents: 450, TicketsAvailable: 6, ShortCategory: "RPG",
+			LastModified: time.Date(2024, 5, 1, 0, 0, 0, 0, Indianapolis), OrgId: 12,
+		},
+		{
+			EventId: "BGM24ND2", Year: 2024, Title: "Cancelled", GameSystem: "Catan", FeedGameSystem: "Catan",
+			StartTime: start, Duration: 60, EndTime: start.Add(time.Hour), ShortCategory: "BGM",
+		},
+	}
+
+	var out bytes.Buffer
+	writer := NewEventWriter(&out)
+	for _, event := range original {
+		record := NewEventRecord(event)
+		record.ClusterId = event.EventId
+		if err := writer.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), "Dragons <Live>") {
+		t.Errorf("expected two readable lines, got %s", out.String())
+	}
+
+	result, err := ParseContent("export.ndjson", out.Bytes(), "", Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Headers != nil {
+		t.Errorf("records shouldn't be checked against the sheet's headers, got %v", result.Headers)
+	}
+	if len(result.Events) != len(original) {
+		t.Fatalf("expected %d events, got %d", len(original), len(result.Events))
+	}
+	for i, event := range result.Events {
+		// Org ids are worked out by the importing site
+		want := *original[i]
+		want.OrgId = 0
+		ParseDetails(&want)
+		if !event.StartTime.Equal(want.StartTime) || !event.LastModified.Equal(want.LastModified) {
+			t.Errorf("%v: times changed, got %v", want.EventId, event)
+		}
+		event.StartTime, event.EndTime, event.LastModified = want.StartTime, want.EndTime, want.LastModified
+		if !reflect.DeepEqual(*event, want) {
+			t.Errorf("expected %+v, got %+v", want, *event)
+		}
+	}
+}
+
+func TestEventRecordProblems(t *testing.T) {
+	content := `{"schemaVersion": 1, "eventId": "RPG24ND1", "year": 2023, "startTime": "2024-08-01T15:00:00-04:00"}
+{"schemaVersion": 1, "eventId": "RPG24ND2", "startTime": "2024-08-01T15:00:00-04:00", "duration": 90}
+{"schemaVersion": 2, "eventId": "RPG24ND3"}
+{"schemaVersion": 1, "eventId": "RPG24ND4", "startTime": "2024-08-01T15:00:00-04:00", "cost": "four"}
+{"schemaVersion": 1, "eventId": "RPG24ND5"}
+`
+	result, err := ParseGenconNdjson([]byte(content), Lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Events) != 1 || result.Events[0].EventId != "RPG24ND2" {
+		t.Fatalf("expected only RPG24ND2, got %v", result.Events)
+	}
+	if end := result.Events[0].EndTime; !end.Equal(result.Events[0].StartTime.Add(90 * time.Minute)) {
+		t.Errorf("expected the end worked out from the duration, got %v", end)
+	}
+
+	columns := make([]string, 0)
+	for _, problem := range result.Rejected {
+		columns = append(columns, problem.Column)
+	}
+	if want := []string{"year", "schemaVersion", "cost", "startTime"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("expected problems with %v, got %v", want, result.Rejected)
+	}
+}
@@ -0,0 +1,425 @@
+package events
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The columns we expect in Gen Con's event exports, matched by header rather
+// than position so reordered or added columns don't scramble the import.
+// Bump the version whenever the expected headers or types change, it's
+// reported with every import so a bad load can be traced to a schema.
+const SchemaVersion = 1
+
+type ColumnType int
+
+const (
+	TextColumn ColumnType = iota
+	NumberColumn
+	// Yes, or TRUE in spreadsheets, and anything else is no
+	YesNoColumn
+	// Full date and time, like 07/30/2015 03:00 PM
+	TimeColumn
+	// Just a date, like 07-30-15, or an Excel serial date
+	DateColumn
+)
+
+type Column struct {
+	// As Gen Con names it
+	Header string
+	// Names it's gone by in other years
+	Aliases  []string
+	Type     ColumnType
+	Required bool
+	// Nil for columns we recognise but don't import
+	set func(event *GenconEvent, value *cellValue)
+	// The cell written for an event, nil for columns left empty
+	get func(event *GenconEvent) rawCell
+}
+
+var EventSchema = []*Column{
+	{Header: "Game ID", Aliases: []string{"Event ID"}, Type: TextColumn, Required: true,
+		get: func(e *GenconEvent) rawCell { return textCell(e.EventId) },
+		set: func(e *GenconEvent, v *cellValue) { e.EventId = v.Text }},
+	{Header: "Group", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.Group) },
+		set: func(e *GenconEvent, v *cellValue) { e.Group = v.Text }},
+	{Header: "Title", Aliases: []string{"Event Title"}, Type: TextColumn, Required: true,
+		get: func(e *GenconEvent) rawCell { return textCell(e.Title) },
+		set: func(e *GenconEvent, v *cellValue) { e.Title = v.Text }},
+	{Header: "Short Description", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.ShortDescription) },
+		set: func(e *GenconEvent, v *cellValue) { e.ShortDescription = v.Text }},
+	{Header: "Long Description", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.LongDescription) },
+		set: func(e *GenconEvent, v *cellValue) { e.LongDescription = v.Text }},
+	{Header: "Event Type", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.EventType) },
+		set: func(e *GenconEvent, v *cellValue) { e.EventType = v.Text }},
+	{Header: "Game System", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(feedGameSystem(e)) },
+		set: func(e *GenconEvent, v *cellValue) { e.GameSystem = v.Text }},
+	{Header: "Rules Edition", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.RulesEdition) },
+		set: func(e *GenconEvent, v *cellValue) { e.RulesEdition = v.Text }},
+	{Header: "Minimum Players", Aliases: []string{"Min Players"}, Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.MinPlayers)) },
+		set: func(e *GenconEvent, v *cellValue) { e.MinPlayers = int(v.Number) }},
+	{Header: "Maximum Players", Aliases: []string{"Max Players"}, Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.MaxPlayers)) },
+		set: func(e *GenconEvent, v *cellValue) { e.MaxPlayers = int(v.Number) }},
+	{Header: "Age Required", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.AgeRequired) },
+		set: func(e *GenconEvent, v *cellValue) { e.AgeRequired = v.Text }},
+	{Header: "Experience Required", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.ExperienceRequired) },
+		set: func(e *GenconEvent, v *cellValue) { e.ExperienceRequired = v.Text }},
+	{Header: "Materials Provided", Type: YesNoColumn,
+		get: func(e *GenconEvent) rawCell { return yesNoCell(e.MaterialsProvided) },
+		set: func(e *GenconEvent, v *cellValue) { e.MaterialsProvided = v.Yes }},
+	{Header: "Materials Required", Type: TextColumn},
+	{Header: "Materials Required Details", Type: TextColumn},
+	{Header: "Start Date & Time", Aliases: []string{"Start Time"}, Type: TimeColumn, Required: true,
+		get: func(e *GenconEvent) rawCell { return timeCell(e.StartTime) },
+		set: func(e *GenconEvent, v *cellValue) { e.StartTime = v.Time }},
+	// In hours
+	{Header: "Duration", Type: NumberColumn, Required: true,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.Duration) / 60) },
+		set: func(e *GenconEvent, v *cellValue) { e.Duration = int(60 * v.Number) }},
+	// We don't trust the end time supplied in the sheet, it's disagreed
+	// with what gencon.com listed, so it's calculated from the duration
+	{Header: "End Date & Time", Aliases: []string{"End Time"}, Type: TimeColumn,
+		get: func(e *GenconEvent) rawCell { return timeCell(e.EndTime) }},
+	{Header: "GM Names", Aliases: []string{"GMs"}, Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.GMNames) },
+		set: func(e *GenconEvent, v *cellValue) { e.GMNames = v.Text }},
+	{Header: "Website", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.Website) },
+		set: func(e *GenconEvent, v *cellValue) { e.Website = v.Text }},
+	{Header: "Email", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.Email) },
+		set: func(e *GenconEvent, v *cellValue) { e.Email = v.Text }},
+	{Header: "Tournament?", Aliases: []string{"Tournament"}, Type: YesNoColumn,
+		get: func(e *GenconEvent) rawCell { return yesNoCell(e.Tournament) },
+		set: func(e *GenconEvent, v *cellValue) { e.Tournament = v.Yes }},
+	{Header: "Round Number", Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.RoundNumber)) },
+		set: func(e *GenconEvent, v *cellValue) { e.RoundNumber = int(v.Number) }},
+	{Header: "Total Rounds", Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.TotalRounds)) },
+		set: func(e *GenconEvent, v *cellValue) { e.TotalRounds = int(v.Number) }},
+	// In hours
+	{Header: "Minimum Play Time", Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.MinPlayTime) / 60) },
+		set: func(e *GenconEvent, v *cellValue) { e.MinPlayTime = int(60 * v.Number) }},
+	{Header: "Attendee Registration?", Aliases: []string{"Attendee Registration"}, Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.AttendeeRegistration) },
+		set: func(e *GenconEvent, v *cellValue) { e.AttendeeRegistration = v.Text }},
+	{Header: "Cost $", Aliases: []string{"Cost"}, Type: NumberColumn,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.CostCents) / 100) },
+		set: func(e *GenconEvent, v *cellValue) {
+			e.CostCents = int(math.Round(v.Number * 100))
+			e.Cost = e.CostCents / 100
+		}},
+	{Header: "Location", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.Location) },
+		set: func(e *GenconEvent, v *cellValue) { e.Location = v.Text }},
+	{Header: "Room Name", Aliases: []string{"Room"}, Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.RoomName) },
+		set: func(e *GenconEvent, v *cellValue) {
+			// Bare room numbers come through as numbers in the sheets
+			if v.IsNumber && v.Number != 0 {
+				e.RoomName = "Room " + v.Text
+			} else {
+				e.RoomName = v.Text
+			}
+		}},
+	{Header: "Table Number", Aliases: []string{"Table"}, Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.TableNumber) },
+		set: func(e *GenconEvent, v *cellValue) { e.TableNumber = v.Text }},
+	{Header: "Special Category", Type: TextColumn,
+		get: func(e *GenconEvent) rawCell { return textCell(e.SpecialCategory) },
+		set: func(e *GenconEvent, v *cellValue) { e.SpecialCategory = v.Text }},
+	{Header: "Tickets Available", Type: NumberColumn, Required: true,
+		get: func(e *GenconEvent) rawCell { return numberCell(float64(e.TicketsAvailable)) },
+		set: func(e *GenconEvent, v *cellValue) { e.TicketsAvailable = int(v.Number) }},
+	{Header: "Last Modified", Type: DateColumn,
+		get: func(e *GenconEvent) rawCell { return timeCell(e.LastModified) },
+		set: func(e *GenconEvent, v *cellValue) { e.LastModified = v.Time }},
+}
+
+// Lowercase letters and digits only, so "Tournament?" matches "tournament"
+func normalizeHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+// What didn't line up between a file's headers and the schema
+type HeaderReport struct {
+	Version int
+	// Required columns that weren't found, the import can't go ahead
+	Missing []string
+	// Optional columns we import that weren't found, left empty
+	MissingOptional []string
+	// Headers the schema doesn't know, likely a rename when something's
+	// also missing
+	Unrecognized []string
+	// Headers that appeared more than once, only the first is used
+	Duplicates []string
+}
+
+func (r *HeaderReport) HasProblems() bool {
+	return len(r.Missing)+len(r.MissingOptional)+len(r.Unrecognized)+len(r.Duplicates) > 0
+}
+
+func (r *HeaderReport) String() string {
+	var parts []string
+	if len(r.Missing) > 0 {
+		parts = append(parts, "missing required columns: "+strings.Join(r.Missing, ", "))
+	}
+	if len(r.MissingOptional) > 0 {
+		parts = append(parts, "missing optional columns: "+strings.Join(r.MissingOptional, ", "))
+	}
+	if len(r.Unrecognized) > 0 {
+		parts = append(parts, "unrecognized columns: "+strings.Join(r.Unrecognized, ", "))
+	}
+	if len(r.Duplicates) > 0 {
+		parts = append(parts, "duplicate columns: "+strings.Join(r.Duplicates, ", "))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("schema v%d: all columns found", r.Version)
+	}
+	return fmt.Sprintf("schema v%d: %s", r.Version, strings.Join(parts, "; "))
+}
+
+func appendUnique(to []string, values []string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range to {
+			found = found || existing == value
+		}
+		if !found {
+			to = append(to, value)
+		}
+	}
+	return to
+}
+
+// Combines the reports for files loaded together, a column counts as missing
+// if any of them is missing it
+func (r *HeaderReport) merge(other *HeaderReport) *HeaderReport {
+	if other == nil {
+		return r
+	}
+	if r == nil {
+		r = &HeaderReport{Version: other.Version}
+	}
+	r.Missing = appendUnique(r.Missing, other.Missing)
+	r.MissingOptional = appendUnique(r.MissingOptional, other.MissingOptional)
+	r.Unrecognized = appendUnique(r.Unrecognized, other.Unrecognized)
+	r.Duplicates = appendUnique(r.Duplicates, other.Duplicates)
+	return r
+}
+
+// Non-nil when the file can't be imported
+func (r *HeaderReport) Err() error {
+	if len(r.Missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("event export doesn't match the expected columns, %s", r)
+}
+
+// Where each schema column lives in a file, -1 when it isn't there
+type ColumnMap struct {
+	schema  []*Column
+	indexes []int
+}
+
+func MapColumns(schema []*Column, headers []string) (*ColumnMap, *HeaderReport) {
+	report := &HeaderReport{Version: SchemaVersion}
+	positions := make(map[string]int)
+	for i, header := range headers {
+		normalized := normalizeHeader(header)
+		if normalized == "" {
+			continue
+		}
+		if _, seen := positions[normalized]; seen {
+			report.Duplicates = append(report.Duplicates, header)
+			continue
+		}
+		positions[normalized] = i
+	}
+
+	columns := &ColumnMap{schema: schema, indexes: make([]int, len(schema))}
+	claimed := make(map[int]bool)
+	for c, column := range schema {
+		columns.indexes[c] = -1
+		for _, name := range append([]string{column.Header}, column.Aliases...) {
+			if i, found := positions[normalizeHeader(name)]; found {
+				columns.indexes[c] = i
+				claimed[i] = true
+				break
+			}
+		}
+		if columns.indexes[c] >= 0 {
+			continue
+		}
+		if column.Required {
+			report.Missing = append(report.Missing, column.Header)
+		} else if column.set != nil {
+			report.MissingOptional = append(report.MissingOptional, column.Header)
+		}
+	}
+
+	for i, header := range headers {
+		normalized := normalizeHeader(header)
+		if normalized != "" && positions[normalized] == i && !claimed[i] {
+			report.Unrecognized = append(report.Unrecognized, header)
+		}
+	}
+	return columns, report
+}
+
+// One cell as the file stored it, CSVs only ever have text
+type rawCell struct {
+	Text     string
+	Number   float64
+	IsNumber bool
+	// Spreadsheet cells formatted as dates
+	Time   time.Time
+	IsTime bool
+}
+
+// A cell converted to its column's type
+type cellValue struct {
+	rawCell
+	Yes  bool
+	Time time.Time
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{"01-02-06", "01/02/2006", "01/02/2006 03:04 PM"} {
+		if parsed, err := time.ParseInLocation(layout, value, Indianapolis); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("needs a date, like 07-30-15")
+}
+
+func convertCell(column *Column, cell rawCell) (*cellValue, error) {
+	value := &cellValue{rawCell: cell}
+	if cell.IsNumber && value.Text == "" && cell.Number != 0 {
+		value.Text = strconv.FormatFloat(cell.Number, 'f', -1, 64)
+	}
+	value.Text = strings.TrimSpace(value.Text)
+
+	switch column.Type {
+	case NumberColumn:
+		if cell.IsNumber || value.Text == "" {
+			return value, nil
+		}
+		number, err := strconv.ParseFloat(strings.TrimPrefix(value.Text, "$"), 64)
+		if err != nil {
+			return value, fmt.Errorf("needs a number")
+		}
+		value.Number = number
+	case YesNoColumn:
+		value.Yes = strings.EqualFold(value.Text, "Yes") || strings.EqualFold(value.Text, "True")
+	case TimeColumn, DateColumn:
+		if value.Text == "" {
+			return value, nil
+		}
+		if cell.IsTime {
+			value.Time = cell.Time
+			return value, nil
+		}
+		if cell.IsNumber {
+			// A date serial the sheet didn't style as a date
+			value.Time = excelDate(cell.Number, false)
+			return value, nil
+		}
+		var err error
+		if column.Type == TimeColumn {
+			value.Time = parseTime(value.Text)
+			if value.Time.IsZero() {
+				err = fmt.Errorf("needs a date and time, like 07/30/2015 03:00 PM")
+			}
+		} else {
+			value.Time, err = parseDate(value.Text)
+		}
+		return value, err
+	}
+	return value, nil
+}
+
+// Builds an event from a row's cells, in the file's column order. Returns
+// nil and no problems for blank rows, and nil with the reasons for rows that
+// can't be imported.
+func (m *ColumnMap) toEvent(cells []rawCell) (*GenconEvent, []*RowProblem) {
+	blank := true
+	for _, cell := range cells {
+		if cell.IsNumber || strings.TrimSpace(cell.Text) != "" {
+			blank = false
+			break
+		}
+	}
+	if blank {
+		return nil, nil
+	}
+
+	event := GenconEvent{Active: true}
+	var problems []*RowProblem
+	for c, column := range m.schema {
+		i := m.indexes[c]
+		if column.set == nil || i < 0 {
+			continue
+		}
+		var cell rawCell
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		value, err := convertCell(column, cell)
+		if err != nil {
+			problems = append(problems, &RowProblem{
+				Column: column.Header,
+				Value:  value.Text,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		column.set(&event, value)
+	}
+
+	var err error
+	event.ShortCategory, event.Year, _, _, err = splitId(event.EventId)
+	if event.EventId == "" {
+		err = fmt.Errorf("needs an event id")
+	}
+	if err != nil {
+		problems = append(problems, &RowProblem{
+			Column: EventSchema[0].Header,
+			Value:  event.EventId,
+			Reason: err.Error(),
+		})
+	}
+
+	for _, problem := range problems {
+		problem.EventId = event.EventId
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	// time.Duration is in nano seconds, convert minutes to seconds
+	event.EndTime = event.StartTime.Add((time.Duration)(1e9 * 60 * event.Duration))
+	event.FeedGameSystem = event.GameSystem
+	ParseDetails(&event)
+	return NormalizeEvent(&event), nil
+}
@@ -0,0 +1,202 @@
+package events
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHeaders = []string{
+	"Game ID", "Group", "Title", "Short Description", "Long Description",
+	"Event Type", "Game System", "Rules Edition", "Minimum Players",
+	"Maximum Players", "Age Required", "Experience Required",
+	"Materials Provided", "Materials Required", "Materials Required Details",
+	"Start Date & Time", "Duration", "End Date & Time", "GM Names", "Website",
+	"Email", "Tournament?", "Round Number", "Total Rounds",
+	"Minimum Play Time", "Attendee Registration?", "Cost $", "Location",
+	"Room Name", "Table Number", "Special Category", "Tickets Available",
+	"Last Modified",
+}
+
+func TestMapColumnsAllFound(t *testing.T) {
+	_, report := MapColumns(EventSchema, testHeaders)
+	if report.HasProblems() {
+		t.Errorf("unexpected problems: %v", report)
+	}
+	if report.Err() != nil {
+		t.Errorf("unexpected error: %v", report.Err())
+	}
+}
+
+func TestMapColumnsMatchesLooselyAndByAlias(t *testing.T) {
+	columns, report := MapColumns(EventSchema, []string{
+		"tickets available", "Start Time", "DURATION", "Event Title", "Event ID",
+	})
+	if report.Err() != nil {
+		t.Fatalf("unexpected error: %v", report.Err())
+	}
+	if len(report.Unrecognized) > 0 {
+		t.Errorf("unexpected unrecognized columns: %v", report.Unrecognized)
+	}
+
+	event, problems := columns.toEvent([]rawCell{
+		{Text: "4"}, {Text: "07/30/2015 03:00 PM"}, {Text: "1.5"}, {Text: "Reordered"}, {Text: "RPG15ND123"},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if event.EventId != "RPG15ND123" || event.Title != "Reordered" || event.TicketsAvailable != 4 {
+		t.Errorf("columns weren't mapped by header: %+v", event)
+	}
+	if event.Duration != 90 || event.EndTime.Sub(event.StartTime) != 90*time.Minute {
+		t.Errorf("expected a 90 minute event, got %v to %v", event.StartTime, event.EndTime)
+	}
+	if event.Year != 2015 || event.ShortCategory != "RPG" {
+		t.Errorf("expected the year and category from the id, got %v %v", event.Year, event.ShortCategory)
+	}
+}
+
+func TestMapColumnsReportsMissingAndRenamed(t *testing.T) {
+	headers := make([]string, 0, len(testHeaders))
+	for _, header := range testHeaders {
+		switch header {
+		case "Duration":
+			headers = append(headers, "Length (hours)")
+		case "Website":
+		default:
+			headers = append(headers, header)
+		}
+	}
+	headers = append(headers, "Title")
+
+	_, report := MapColumns(EventSchema, headers)
+	if report.Err() == nil {
+		t.Fatal("expected an error for the missing duration")
+	}
+	if len(report.Missing) != 1 || report.Missing[0] != "Duration" {
+		t.Errorf("missing: %v", report.Missing)
+	}
+	if len(report.MissingOptional) != 1 || report.MissingOptional[0] != "Website" {
+		t.Errorf("missing optional: %v", report.MissingOptional)
+	}
+	if len(report.Unrecognized) != 1 || report.Unrecognized[0] != "Length (hours)" {
+		t.Errorf("unrecognized: %v", report.Unrecognized)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0] != "Title" {
+		t.Errorf("duplicates: %v", report.Duplicates)
+	}
+	for _, want := range []string{"schema v1", "Duration", "Length (hours)"} {
+		if !strings.Contains(report.Err().Error(), want) {
+			t.Errorf("expected %q in %v", want, report.Err())
+		}
+	}
+}
+
+func TestParseGenconCsvByHeader(t *testing.T) {
+	csv := `Tickets Available,Title,Game ID,Start Date & Time,Duration,Cost $,Tournament?,Room Name,Last Modified,Something New
+6,"Dragons, Again",RPG15ND123,07/30/2015 03:00 PM,2,$4,Yes,Room 5,07-01-15,ignored
+,No Tickets,BGM15ND1,07/31/2015 10:00 AM,1,,No,,,
+`
+	result, err := ParseGenconCsv([]byte(csv), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed := result.Events
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(parsed))
+	}
+
+	event := parsed[0]
+	if event.EventId != "RPG15ND123" || event.Title != "Dragons, Again" {
+		t.Errorf("wrong event: %+v", event)
+	}
+	if event.TicketsAvailable != 6 || event.Cost != 4 || !event.Tournament || event.RoomName != "Room 5" {
+		t.Errorf("wrong values: %+v", event)
+	}
+	if event.LastModified.Month() != time.July || event.LastModified.Day() != 1 {
+		t.Errorf("wrong last modified: %v", event.LastModified)
+	}
+	if parsed[1].TicketsAvailable != 0 || parsed[1].Tournament {
+		t.Errorf("empty cells should be empty: %+v", parsed[1])
+	}
+}
+
+func TestParseGenconCsvMissingColumns(t *testing.T) {
+	_, err := ParseGenconCsv([]byte("Game ID,Title\nRPG15ND123,Dragons\n"), Lenient)
+	if err == nil {
+		t.Fatal("expected an error for missing columns")
+	}
+}
+
+// Zips up the parts of a spreadsheet
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// The smallest workbook with one sheet and shared strings
+func buildSheet(t *testing.T, sharedStrings, sheet string) []byte {
+	return zipFiles(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml":     sharedStrings,
+		"xl/worksheets/sheet1.xml": sheet,
+	})
+}
+
+func TestParseGenconSheetByHeader(t *testing.T) {
+	sharedStrings := `<sst>
+<si><t>Game ID</t></si>
+<si><t>Title</t></si>
+<si><r><t>Start Date</t></r><r><t> &amp; Time</t></r></si>
+<si><t>Duration</t></si>
+<si><t>Tickets Available</t></si>
+<si><t>Room Name</t></si>
+</sst>`
+	// The empty Title cell in the second row is left out, like Excel does
+	sheet := `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>RPG15ND123</t></is></c><c r="B2" t="inlineStr"><is><t>1776</t></is></c><c r="C2" t="inlineStr"><is><t>07/30/2015 03:00 PM</t></is></c><c r="D2"><v>1.5</v></c><c r="E2"><v>3</v></c><c r="F2"><v>12</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>BGM15ND1</t></is></c><c r="C3" t="inlineStr"><is><t>07/31/2015 10:00 AM</t></is></c><c r="D3"><v>1</v></c><c r="E3"><v>8</v></c></row>
+</sheetData></worksheet>`
+
+	result, err := ParseGenconSheet(buildSheet(t, sharedStrings, sheet), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed := result.Events
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(parsed))
+	}
+	if parsed[0].Title != "1776" || parsed[0].Duration != 90 || parsed[0].TicketsAvailable != 3 || parsed[0].RoomName != "Room 12" {
+		t.Errorf("wrong first event: %+v", parsed[0])
+	}
+	if parsed[1].Title != "" || parsed[1].TicketsAvailable != 8 || parsed[1].StartTime.Day() != 31 {
+		t.Errorf("sparse cells landed in the wrong columns: %+v", parsed[1])
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for cellId, want := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "AF2": 31, "": -1} {
+		if got := columnIndex(cellId); got != want {
+			t.Errorf("columnIndex(%q) = %d, want %d", cellId, got, want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writes events laid out like Gen Con's export, every column of EventSchema
+// in order. ParseGenconSheet and ParseGenconCsv read them back, which makes
+// them good for test feeds.
+
+func textCell(text string) rawCell {
+	return rawCell{Text: text}
+}
+
+func numberCell(number float64) rawCell {
+	return rawCell{Number: number, IsNumber: true}
+}
+
+func yesNoCell(yes bool) rawCell {
+	if yes {
+		return rawCell{Text: "Yes"}
+	}
+	return rawCell{Text: "No"}
+}
+
+// Zero times are left empty
+func timeCell(t time.Time) rawCell {
+	if t.IsZero() {
+		return rawCell{}
+	}
+	return rawCell{Time: t, IsTime: true}
+}
+
+// The sheet has the game system before we cleaned it up
+func feedGameSystem(e *GenconEvent) string {
+	if e.FeedGameSystem != "" {
+		return e.FeedGameSystem
+	}
+	return e.GameSystem
+}
+
+// The inverse of excelDate, days since 1899-12-30 on Indianapolis' clock
+func excelSerial(t time.Time) float64 {
+	local := t.In(Indianapolis)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	days := day.Sub(time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	seconds := local.Hour()*60*60 + local.Minute()*60 + local.Second()
+	return days + float64(seconds)/(24*60*60)
+}
+
+// Text the way the csv export has it
+func (c rawCell) csvText(columnType ColumnType) string {
+	switch {
+	case c.IsTime && columnType == DateColumn:
+		return c.Time.Format("01/02/2006")
+	case c.IsTime:
+		return c.Time.Format("01/02/2006 03:04 PM")
+	case c.IsNumber:
+		return strconv.FormatFloat(c.Number, 'f', -1, 64)
+	}
+	return c.Text
+}
+
+func sheetRow(event *GenconEvent) []rawCell {
+	cells := make([]rawCell, len(EventSchema))
+	for i, column := range EventSchema {
+		if column.get != nil {
+			cells[i] = column.get(event)
+		}
+	}
+	return cells
+}
+
+func WriteGenconCsv(w io.Writer, events []*GenconEvent) error {
+	writer := csv.NewWriter(w)
+	headers := make([]string, len(EventSchema))
+	for i, column := range EventSchema {
+		headers[i] = column.Header
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+	for _, event := range events {
+		cells := sheetRow(event)
+		line := make([]string, len(cells))
+		for i, cell := range cells {
+			line[i] = cell.csvText(EventSchema[i].Type)
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Cell styles in sheetStyles, by index
+const (
+	generalStyle = iota
+	timeStyle
+	dateStyle
+)
+
+const sheetContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const sheetRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const sheetWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="` + eventsSheetName + `" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const sheetWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// Built in formats 22, m/d/yyyy h:mm, and 14, m/d/yyyy
+const sheetStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="1"><fill><patternFill patternType="none"/></fill></fills>
+<borders count="1"><border/></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+// Column letters for a zero based index, 0 is A and 26 is AA
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+func escapeXml(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
+
+// Writes an xlsx the way Excel saves one, text in shared strings and times
+// as date styled serials. Empty cells are left out.
+func WriteGenconSheet(w io.Writer, events []*GenconEvent) error {
+	sharedStrings := make([]string, 0)
+	stringIndexes := make(map[string]int)
+	stringCell := func(text string) string {
+		i, found := stringIndexes[text]
+		if !found {
+			i = len(sharedStrings)
+			stringIndexes[text] = i
+			sharedStrings = append(sharedStrings, text)
+		}
+		return strconv.Itoa(i)
+	}
+
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	fmt.Fprintf(&sheet, `<row r="1">`)
+	for i, column := range EventSchema {
+		fmt.Fprintf(&sheet, `<c r="%s1" t="s"><v>%s</v></c>`, columnName(i), stringCell(column.Header))
+	}
+	sheet.WriteString(`</row>`)
+	for r, event := range events {
+		row := r + 2
+		fmt.Fprintf(&sheet, `<row r="%d">`, row)
+		for i, cell := range sheetRow(event) {
+			ref := fmt.Sprintf("%s%d", columnName(i), row)
+			switch {
+			case cell.IsTime:
+				style := timeStyle
+				if EventSchema[i].Type == DateColumn {
+					style = dateStyle
+				}
+				fmt.Fprintf(&sheet, `<c r="%s" s="%d"><v>%s</v></c>`,
+					ref, style, strconv.FormatFloat(excelSerial(cell.Time), 'f', -1, 64))
+			case cell.IsNumber:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(cell.Number, 'f', -1, 64))
+			case cell.Text != "":
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%s</v></c>`, ref, stringCell(cell.Text))
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var stringsXml strings.Builder
+	fmt.Fprintf(&stringsXml, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="%d" uniqueCount="%d">`,
+		len(sharedStrings), len(sharedStrings))
+	for _, text := range sharedStrings {
+		fmt.Fprintf(&stringsXml, `<si><t xml:space="preserve">%s</t></si>`, escapeXml(text))
+	}
+	stringsXml.WriteString(`</sst>`)
+
+	archive := zip.NewWriter(w)
+	for _, part := range []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", sheetContentTypes},
+		{"_rels/.rels", sheetRootRels},
+		{"xl/workbook.xml", sheetWorkbook},
+		{"xl/_rels/workbook.xml.rels", sheetWorkbookRels},
+		{"xl/styles.xml", sheetStyles},
+		{"xl/sharedStrings.xml", stringsXml.String()},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	} {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
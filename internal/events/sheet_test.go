@@ -0,0 +1,99 @@
+package events
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func sheetEvents() []*GenconEvent {
+	start := time.Date(2024, 8, 1, 15, 30, 0, 0, Indianapolis)
+	return []*GenconEvent{
+		{
+			EventId: "RPG24ND290001", Year: 2024, Active: true, Group: "Dragon Co.", Title: "Dragons & <Dungeons>",
+			ShortDescription: "  Leading space kept by the sheet, trimmed by the parser", LongDescription: "Line one\nLine two",
+			EventType: "RPG - Role Playing Game", GameSystem: "Dungeons & Dragons", FeedGameSystem: "Dungeons & Dragons",
+			RulesEdition: "5th", MinPlayers: 3, MaxPlayers: 6, AgeRequired: "Teen (13+)", ExperienceRequired: "None",
+			MaterialsProvided: true, StartTime: start, Duration: 90, EndTime: start.Add(90 * time.Minute),
+			GMNames: "Alex Smith", Website: "https://example.com", Email: "gm@example.com", RoundNumber: 1,
+			TotalRounds: 1, MinPlayTime: 90, AttendeeRegistration: "Yes, they can register for this round without having played in any other events",
+			Cost: 4, CostCents: 400, Location: "ICC", RoomName: "Room 12", TableNumber: "7", SpecialCategory: "none",
+			TicketsAvailable: 6, LastModified: time.Date(2024, 7, 15, 0, 0, 0, 0, Indianapolis), ShortCategory: "RPG",
+		},
+		{
+			EventId: "TCG24ND290002", Year: 2024, Active: true, Title: "Finals", EventType: "TCG - Tradeable Card Game",
+			Tournament: true, StartTime: start.Add(28 * time.Hour), Duration: 240, EndTime: start.Add(32 * time.Hour),
+			Cost: 2, CostCents: 250, ShortCategory: "TCG",
+		},
+	}
+}
+
+func normalizedSheetEvents() []*GenconEvent {
+	expected := sheetEvents()
+	expected[0].ShortDescription = "Leading space kept by the sheet, trimmed by the parser"
+	for _, event := range expected {
+		ParseDetails(event)
+		NormalizeEvent(event)
+		if event.FeedGameSystem == "" {
+			event.FeedGameSystem = event.GameSystem
+		}
+	}
+	return expected
+}
+
+func compareSheetEvents(t *testing.T, expected, got []*GenconEvent) {
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(got))
+	}
+	for i, event := range got {
+		want := *expected[i]
+		if !event.StartTime.Equal(want.StartTime) || !event.EndTime.Equal(want.EndTime) || !event.LastModified.Equal(want.LastModified) {
+			t.Errorf("%v: expected times %v %v %v, got %v %v %v", want.EventId,
+				want.StartTime, want.EndTime, want.LastModified, event.StartTime, event.EndTime, event.LastModified)
+		}
+		copied := *event
+		copied.StartTime, copied.EndTime, copied.LastModified = want.StartTime, want.EndTime, want.LastModified
+		if !reflect.DeepEqual(copied, want) {
+			t.Errorf("expected %+v\ngot %+v", want, copied)
+		}
+	}
+}
+
+func TestSheetRoundTrip(t *testing.T) {
+	var sheet bytes.Buffer
+	if err := WriteGenconSheet(&sheet, sheetEvents()); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseGenconSheet(sheet.Bytes(), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Headers.HasProblems() {
+		t.Errorf("expected every column, got %v", result.Headers)
+	}
+	compareSheetEvents(t, normalizedSheetEvents(), result.Events)
+}
+
+func TestCsvRoundTrip(t *testing.T) {
+	var csv bytes.Buffer
+	if err := WriteGenconCsv(&csv, sheetEvents()); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseGenconCsv(csv.Bytes(), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Headers.HasProblems() {
+		t.Errorf("expected every column, got %v", result.Headers)
+	}
+	compareSheetEvents(t, normalizedSheetEvents(), result.Events)
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != want || columnIndex(got) != index {
+			t.Errorf("%d: expected %v, got %v", index, want, got)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Somewhere event listings can be loaded from, like the feed on gencon.com,
+// a mirror of it or fixture files.
+type Source interface {
+	// Names the source in logs and the import ledger
+	String() string
+	// Reads and parses every event in the source
+	Load(mode ParseMode) (*ParseResult, error)
+}
+
+// The source couldn't be read, as opposed to being read and not parsing
+type SourceError struct {
+	Source string
+	// Set when a server answered with something other than the feed
+	StatusCode int
+	Err        error
+}
+
+func (e *SourceError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("fetching %v: %d %v", e.Source, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("reading %v: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// The content isn't a format we import, or isn't the one the source expects
+type FormatError struct {
+	Source string
+	Reason string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("not importing %v: %v", e.Source, e.Reason)
+}
+
+// The content is in a format we know but its events couldn't be parsed, the
+// columns are wrong or, in strict mode, a row is bad
+type ParseError struct {
+	Source string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("not importing %v: %v", e.Source, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+type Format int
+
+const (
+	UnknownFormat Format = iota
+	XlsxFormat
+	CsvFormat
+	// One JSON object per line, keyed by the same headers as the sheets
+	NdjsonFormat
+)
+
+func (f Format) String() string {
+	switch f {
+	case XlsxFormat:
+		return "xlsx"
+	case CsvFormat:
+		return "csv"
+	case NdjsonFormat:
+		return "ndjson"
+	}
+	return "unknown"
+}
+
+var extensionFormats = map[string]Format{
+	".xlsx":   XlsxFormat,
+	".csv":    CsvFormat,
+	".ndjson": NdjsonFormat,
+	".jsonl":  NdjsonFormat,
+}
+
+var mediaTypeFormats = map[string]Format{
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": XlsxFormat,
+	"text/csv":             CsvFormat,
+	"application/csv":      CsvFormat,
+	"application/x-ndjson": NdjsonFormat,
+	"application/ndjson":   NdjsonFormat,
+	"application/jsonl":    NdjsonFormat,
+	"application/json":     NdjsonFormat,
+}
+
+// xlsx files are zips
+var zipMagic = []byte("PK\x03\x04")
+
+// Works out what format content is in. The content itself wins, then the
+// Content-Type it was served with, then the name's extension. Anything else
+// that looks like text is taken for csv, unless it's HTML, which is usually
+// an error page served in place of the feed.
+func DetectFormat(content []byte, contentType string, name string) Format {
+	if bytes.HasPrefix(content, zipMagic) {
+		return XlsxFormat
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if format, found := mediaTypeFormats[mediaType]; found && format != XlsxFormat {
+		return format
+	}
+	if format, found := extensionFormats[strings.ToLower(filepath.Ext(name))]; found && format != XlsxFormat {
+		return format
+	}
+
+	sniffed := http.DetectContentType(content)
+	switch {
+	case strings.HasPrefix(sniffed, "text/html"), strings.HasPrefix(sniffed, "text/xml"):
+		return UnknownFormat
+	case !strings.HasPrefix(sniffed, "text/plain"):
+		return UnknownFormat
+	case bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")):
+		return NdjsonFormat
+	}
+	return CsvFormat
+}
+
+// Parses content that's expected to be in the given format
+func Parse(source string, content []byte, format Format, mode ParseMode) (*ParseResult, error) {
+	if len(content) == 0 {
+		return nil, &FormatError{Source: source, Reason: "it's empty"}
+	}
+	isZip := bytes.HasPrefix(content, zipMagic)
+	if isZip != (format == XlsxFormat) {
+		return nil, &FormatError{
+			Source: source,
+			Reason: fmt.Sprintf("expected %v but it looks like %v", format, DetectFormat(content, "", "")),
+		}
+	}
+
+	var result *ParseResult
+	var err error
+	switch format {
+	case XlsxFormat:
+		result, err = ParseGenconSheet(content, mode)
+	case CsvFormat:
+		result, err = ParseGenconCsv(content, mode)
+	case NdjsonFormat:
+		result, err = ParseGenconNdjson(content, mode)
+	default:
+		return nil, &FormatError{Source: source, Reason: "can't tell what format it's in"}
+	}
+	if err != nil {
+		return nil, &ParseError{Source: source, Err: err}
+	}
+	result.Bytes = int64(len(content))
+	return result, nil
+}
+
+// Parses content in whatever format it turns out to be in
+func ParseContent(source string, content []byte, contentType string, mode ParseMode) (*ParseResult, error) {
+	return Parse(source, content, DetectFormat(content, contentType, source), mode)
+}
+
+// Fetches the feed over HTTP. That's the xlsx on gencon.com, but mirrors can
+// serve any format we import, the Content-Type and content say which.
+type HttpSource struct {
+	Url    string
+	Client *http.Client
+}
+
+// Long enough for the full feed on a slow connection. Imports hold a lock
+// while fetching, a stalled one would keep the next import waiting forever.
+const FetchTimeout = 5 * time.Minute
+
+func NewHttpSource(url string) *HttpSource {
+	return &HttpSource{Url: url, Client: &http.Client{Timeout: FetchTimeout}}
+}
+
+func (s *HttpSource) String() string {
+	return s.Url
+}
+
+func (s *HttpSource) Load(mode ParseMode) (*ParseResult, error) {
+	resp, err := s.Client.Get(s.Url)
+	if err != nil {
+		return nil, &SourceError{Source: s.Url, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &SourceError{Source: s.Url, StatusCode: resp.StatusCode}
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &SourceError{Source: s.Url, StatusCode: resp.StatusCode, Err: err}
+	}
+	return ParseContent(s.Url, content, resp.Header.Get("Content-Type"), mode)
+}
+
+func readSourceFile(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &SourceError{Source: path, Err: err}
+	}
+	return content, nil
+}
+
+// A spreadsheet saved from the feed
+type XlsxFile struct {
+	Path string
+}
+
+func (f *XlsxFile) String() string {
+	return f.Path
+}
+
+func (f *XlsxFile) Load(mode ParseMode) (*ParseResult, error) {
+	content, err := readSourceFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(f.Path, content, XlsxFormat, mode)
+}
+
+// Events exported as csv, with the same headers as the spreadsheet
+type CsvFile struct {
+	Path string
+}
+
+func (f *CsvFile) String() string {
+	return f.Path
+}
+
+func (f *CsvFile) Load(mode ParseMode) (*ParseResult, error) {
+	content, err := readSourceFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(f.Path, content, CsvFormat, mode)
+}
+
+// Events as one JSON object per line
+type NdjsonFile struct {
+	Path string
+}
+
+func (f *NdjsonFile) String() string {
+	return f.Path
+}
+
+func (f *NdjsonFile) Load(mode ParseMode) (*ParseResult, error) {
+	content, err := readSourceFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(f.Path, content, NdjsonFormat, mode)
+}
+
+func fileSource(path string, format Format) Source {
+	switch format {
+	case XlsxFormat:
+		return &XlsxFile{Path: path}
+	case CsvFormat:
+		return &CsvFile{Path: path}
+	case NdjsonFormat:
+		return &NdjsonFile{Path: path}
+	}
+	return nil
+}
+
+// Every event file under a directory, loaded as one listing
+type DirSource struct {
+	Path string
+}
+
+func (d *DirSource) String() string {
+	return d.Path
+}
+
+// The files under the directory with extensions we import, in name order.
+// Other files are left alone, so notes can sit next to the listings.
+func (d *DirSource) Files() ([]Source, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(d.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if _, found := extensionFormats[strings.ToLower(filepath.Ext(path))]; found {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &SourceError{Source: d.Path, Err: err}
+	}
+	sort.Strings(paths)
+
+	files := make([]Source, len(paths))
+	for i, path := range paths {
+		files[i] = fileSource(path, extensionFormats[strings.ToLower(filepath.Ext(path))])
+	}
+	return files, nil
+}
+
+// Loads the files in name order. An event in more than one file gets the
+// version from the last, so later listings should sort after earlier ones.
+func (d *DirSource) Load(mode ParseMode) (*ParseResult, error) {
+	files, err := d.Files()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, &SourceError{Source: d.Path, Err: errors.New("no event files in it")}
+	}
+
+	combined := &ParseResult{Events: make([]*GenconEvent, 0)}
+	positions := make(map[string]int)
+	for _, file := range files {
+		result, err := file.Load(mode)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range result.Events {
+			if i, found := positions[event.EventId]; found {
+				combined.Events[i] = event
+				continue
+			}
+			positions[event.EventId] = len(combined.Events)
+			combined.Events = append(combined.Events, event)
+		}
+		combined.Headers = combined.Headers.merge(result.Headers)
+		combined.Rejected = append(combined.Rejected, result.Rejected...)
+		combined.RejectedRows += result.RejectedRows
+		combined.Bytes += result.Bytes
+	}
+	return combined, nil
+}
+
+// Picks the source for a url, a directory or a file. Files are told apart by
+// their first bytes, falling back on their extension.
+func OpenSource(location string) (Source, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return NewHttpSource(location), nil
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, &SourceError{Source: location, Err: err}
+	}
+	if info.IsDir() {
+		return &DirSource{Path: location}, nil
+	}
+
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, &SourceError{Source: location, Err: err}
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, &SourceError{Source: location, Err: err}
+	}
+
+	format := DetectFormat(head[:n], "", location)
+	if format == UnknownFormat {
+		return nil, &FormatError{Source: location, Reason: "can't tell what format it's in"}
+	}
+	return fileSource(location, format), nil
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const sourceCsv = `Game ID,Title,Start Date & Time,Duration,Tickets Available
+RPG24ND1,Dragons,08/01/2024 03:00 PM,2,6
+`
+
+const sourceNdjson = `{"Game ID": "RPG24ND1", "Title": "Dragons", "Start Date & Time": "08/01/2024 03:00 PM", "Duration": 2, "Tickets Available": 6}
+
+{"gameId": "RPG24ND2", "title": "Dragons Return", "startDateTime": "08/01/2024 07:00 PM", "duration": 2, "ticketsAvailable": 0, "tournament": true}
+["not", "an", "event"]
+`
+
+func TestDetectFormat(t *testing.T) {
+	for _, test := range []struct {
+		content     string
+		contentType string
+		name        string
+		want        Format
+	}{
+		{"PK\x03\x04rest of the zip", "text/csv", "events.csv", XlsxFormat},
+		{sourceCsv, "", "events.xlsx", CsvFormat},
+		{sourceCsv, "application/octet-stream", "https://www.gencon.com/downloads/events.xlsx", CsvFormat},
+		{sourceNdjson, "", "events.txt", NdjsonFormat},
+		{sourceCsv, "application/x-ndjson; charset=utf-8", "", NdjsonFormat},
+		{sourceNdjson, "", "events.csv", CsvFormat},
+		{"<!DOCTYPE html><html><body>Down for maintenance</body></html>", "text/html", "events.xlsx", UnknownFormat},
+		{"\x00\x01\x02binary", "", "", UnknownFormat},
+	} {
+		if got := DetectFormat([]byte(test.content), test.contentType, test.name); got != test.want {
+			t.Errorf("%q as %q named %q: expected %v, got %v", test.content[:10], test.contentType, test.name, test.want, got)
+		}
+	}
+}
+
+func TestParseNdjson(t *testing.T) {
+	result, err := ParseGenconNdjson([]byte(sourceNdjson), Lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Events) != 2 || result.Events[1].EventId != "RPG24ND2" || !result.Events[1].Tournament {
+		t.Errorf("expected both events with camelCase keys matched, got %+v", result.Events)
+	}
+	if result.Events[0].Duration != 120 || result.Events[0].TicketsAvailable != 6 {
+		t.Errorf("numbers weren't read: %+v", result.Events[0])
+	}
+	if result.RejectedRows != 1 || result.Rejected[0].Row != 4 {
+		t.Errorf("expected line 4 rejected, got %v", result.Rejected)
+	}
+
+	if _, err = ParseGenconNdjson([]byte(sourceNdjson), Strict); err == nil {
+		t.Errorf("expected strict mode to fail on line 4")
+	}
+}
+
+func TestHttpSourceTimesOut(t *testing.T) {
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	source := NewHttpSource(server.URL)
+	if source.Client.Timeout != FetchTimeout {
+		t.Errorf("expected the fetch timeout, got %v", source.Client.Timeout)
+	}
+	source.Client.Timeout = 10 * time.Millisecond
+	var sourceErr *SourceError
+	if _, err := source.Load(Strict); !errors.As(err, &sourceErr) {
+		t.Errorf("expected a stalled fetch to be a source error, got %v", err)
+	}
+}
+
+func TestSourceErrorsAreTyped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events.csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte(sourceCsv))
+		case "/maintenance":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Back soon</body></html>"))
+		case "/columns":
+			w.Write([]byte("Game ID,Title\nRPG24ND1,Dragons\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	result, err := NewHttpSource(server.URL + "/events.csv").Load(Strict)
+	if err != nil || len(result.Events) != 1 || result.Bytes != int64(len(sourceCsv)) {
+		t.Errorf("expected the csv, got %v %v", result, err)
+	}
+
+	var sourceErr *SourceError
+	_, err = NewHttpSource(server.URL + "/missing").Load(Strict)
+	if !errors.As(err, &sourceErr) || sourceErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a source error with the status, got %v", err)
+	}
+	var formatErr *FormatError
+	if _, err = NewHttpSource(server.URL + "/maintenance").Load(Strict); !errors.As(err, &formatErr) {
+		t.Errorf("expected an HTML page to be a format error, got %v", err)
+	}
+	var parseErr *ParseError
+	if _, err = NewHttpSource(server.URL + "/columns").Load(Strict); !errors.As(err, &parseErr) {
+		t.Errorf("expected missing columns to be a parse error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if _, err = (&XlsxFile{Path: filepath.Join(dir, "missing.xlsx")}).Load(Strict); !errors.As(err, &sourceErr) {
+		t.Errorf("expected a missing file to be a source error, got %v", err)
+	}
+	path := filepath.Join(dir, "events.xlsx")
+	if err = os.WriteFile(path, []byte(sourceCsv), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (&XlsxFile{Path: path}).Load(Strict); !errors.As(err, &formatErr) {
+		t.Errorf("expected a csv loaded as xlsx to be a format error, got %v", err)
+	}
+}
+
+func TestOpenSource(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"2024/a.csv":       sourceCsv,
+		"2024/b.ndjson":    sourceNdjson,
+		"2024/readme":      "notes",
+		"mislabeled.xlsx":  sourceCsv,
+		"maintenance.html": "<html><body>Back soon</body></html>",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if source, _ := OpenSource("https://www.gencon.com/downloads/events.xlsx"); source == nil {
+		t.Errorf("expected an http source")
+	} else if _, ok := source.(*HttpSource); !ok {
+		t.Errorf("expected an http source, got %T", source)
+	}
+	if source, err := OpenSource(filepath.Join(dir, "mislabeled.xlsx")); err != nil {
+		t.Error(err)
+	} else if _, ok := source.(*CsvFile); !ok {
+		t.Errorf("expected the content to win over the extension, got %T", source)
+	}
+	var formatErr *FormatError
+	if _, err := OpenSource(filepath.Join(dir, "maintenance.html")); !errors.As(err, &formatErr) {
+		t.Errorf("expected a format error, got %v", err)
+	}
+	var sourceErr *SourceError
+	if _, err := OpenSource(filepath.Join(dir, "nope.csv")); !errors.As(err, &sourceErr) {
+		t.Errorf("expected a source error, got %v", err)
+	}
+
+	// The later file's version of an event wins
+	source, err := OpenSource(filepath.Join(dir, "2024"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := source.Load(Lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Events) != 2 || result.Events[0].Title != "Dragons" || result.RejectedRows != 1 {
+		t.Errorf("expected the files combined, got %v events, %d rejected", len(result.Events), result.RejectedRows)
+	}
+}
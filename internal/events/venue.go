@@ -0,0 +1,157 @@
+package events
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A building events are held in, like the ICC or the JW Marriott
+type Building struct {
+	// Short and stable, used to key aliases and walking times
+	Code string
+	Name string
+	// Getting from one room to another inside the building, 0 when every
+	// room is close enough not to matter
+	InsideWalk time.Duration
+}
+
+// A room name as it shows up in the feed, and the room it means. Building is
+// set when the room name alone says where it is.
+type RoomAlias struct {
+	Alias    string
+	Building string
+	Room     string
+}
+
+// How long it takes to walk between two buildings, the same either way
+type Walk struct {
+	From string
+	To   string
+	Time time.Duration
+}
+
+// The buildings Gen Con uses and how to get between them, so the feed's
+// locations can be matched up and schedules can leave time for the walk.
+type Venue struct {
+	buildings       map[string]*Building
+	buildingAliases map[string]string
+	roomAliases     map[string]*RoomAlias
+	walks           map[[2]string]time.Duration
+}
+
+// Drops case, punctuation and extra spaces, so "J.W. Marriott" and
+// "jw  marriott" are the same place
+func NormalizePlace(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '.' || r == '\'':
+			return -1
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+func walkKey(from, to string) [2]string {
+	if to < from {
+		from, to = to, from
+	}
+	return [2]string{from, to}
+}
+
+// Building aliases map a name the feed uses to a building's code. Codes and
+// names match their building without an alias.
+func NewVenue(buildings []*Building, buildingAliases map[string]string, roomAliases []*RoomAlias, walks []*Walk) *Venue {
+	v := &Venue{
+		buildings:       make(map[string]*Building),
+		buildingAliases: make(map[string]string),
+		roomAliases:     make(map[string]*RoomAlias),
+		walks:           make(map[[2]string]time.Duration),
+	}
+	for _, building := range buildings {
+		v.buildings[building.Code] = building
+		v.buildingAliases[NormalizePlace(building.Code)] = building.Code
+		v.buildingAliases[NormalizePlace(building.Name)] = building.Code
+	}
+	for alias, code := range buildingAliases {
+		if _, found := v.buildings[code]; found {
+			v.buildingAliases[NormalizePlace(alias)] = code
+		}
+	}
+	for _, alias := range roomAliases {
+		v.roomAliases[NormalizePlace(alias.Alias)] = alias
+	}
+	for _, walk := range walks {
+		v.walks[walkKey(walk.From, walk.To)] = walk.Time
+	}
+	return v
+}
+
+// nil for codes the venue doesn't have
+func (v *Venue) Building(code string) *Building {
+	return v.buildings[code]
+}
+
+// The building a location from the feed means, or nil
+func (v *Venue) FindBuilding(location string) *Building {
+	return v.buildings[v.buildingAliases[NormalizePlace(location)]]
+}
+
+// Sets the event's Building and Room from its Location and RoomName,
+// returning whether either changed. Events in buildings the venue doesn't
+// know get no building, and keep the feed's room name tidied up.
+func (v *Venue) Locate(event *GenconEvent) bool {
+	building, room := "", strings.Join(strings.Fields(event.RoomName), " ")
+	if found := v.FindBuilding(event.Location); found != nil {
+		building = found.Code
+	}
+	alias, found := v.roomAliases[NormalizePlace(event.RoomName)]
+	// Like building aliases, ones for buildings that aren't in the venue are
+	// ignored
+	if found && (alias.Building == "" || v.buildings[alias.Building] != nil) {
+		if alias.Building == "" || building == "" || alias.Building == building {
+			room = alias.Room
+			if building == "" {
+				building = alias.Building
+			}
+		}
+	}
+
+	changed := event.Building != building || event.Room != room
+	event.Building, event.Room = building, room
+	return changed
+}
+
+func (v *Venue) LocateAll(events []*GenconEvent) {
+	for _, event := range events {
+		v.Locate(event)
+	}
+}
+
+// How long it takes to get from one event to the other, and whether the
+// venue knows. It doesn't for events outside its buildings, which includes
+// ones placed in a building that's since been deleted, or buildings with no
+// walking time between them.
+func (v *Venue) WalkingTime(from, to *GenconEvent) (time.Duration, bool) {
+	fromBuilding, toBuilding := v.buildings[from.Building], v.buildings[to.Building]
+	if fromBuilding == nil || toBuilding == nil {
+		return 0, false
+	}
+	if from.Building == to.Building {
+		if from.Room == "" || to.Room == "" || NormalizePlace(from.Room) == NormalizePlace(to.Room) {
+			return 0, true
+		}
+		return fromBuilding.InsideWalk, true
+	}
+	walk, found := v.walks[walkKey(from.Building, to.Building)]
+	return walk, found
+}
+
+// The walking time between two buildings, and whether it's known
+func (v *Venue) Walk(from, to string) (time.Duration, bool) {
+	walk, found := v.walks[walkKey(from, to)]
+	return walk, found
+}
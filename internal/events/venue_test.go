@@ -0,0 +1,82 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizePlace(t *testing.T) {
+	cases := map[string]string{
+		"J.W. Marriott":      "jw marriott",
+		"  jw   MARRIOTT ":   "jw marriott",
+		"Lucas Oil Stadium":  "lucas oil stadium",
+		"Crowne Plaza (UnS)": "crowne plaza uns",
+		"Hall-A":             "hall a",
+	}
+	for name, expected := range cases {
+		if got := NormalizePlace(name); got != expected {
+			t.Errorf("%q: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	venue := NewVenue(
+		[]*Building{{Code: "ICC", Name: "Indiana Convention Center"}, {Code: "JW", Name: "JW Marriott"}},
+		map[string]string{"Convention Center": "ICC", "Nowhere": "XYZ"},
+		[]*RoomAlias{
+			{Alias: "Sagamore 1", Building: "ICC", Room: "Sagamore Ballroom 1"},
+			{Alias: "Grand 1", Building: "JW", Room: "Grand Ballroom 1"},
+			{Alias: "Ballroom X", Building: "XYZ", Room: "Ballroom X"},
+		},
+		[]*Walk{{From: "ICC", To: "JW", Time: 6 * time.Minute}},
+	)
+
+	cases := []struct {
+		location, roomName string
+		building, room     string
+	}{
+		{"ICC", "Room  101", "ICC", "Room 101"},
+		{"convention center", "sagamore 1", "ICC", "Sagamore Ballroom 1"},
+		{"Indiana Convention Center", "Hall A", "ICC", "Hall A"},
+		// The room alias places it when the location doesn't
+		{"", "Grand 1", "JW", "Grand Ballroom 1"},
+		// A room alias for another building doesn't apply
+		{"ICC", "Grand 1", "ICC", "Grand 1"},
+		// Aliases for buildings that aren't in the venue are ignored
+		{"Nowhere", "Room 1", "", "Room 1"},
+		{"", "ballroom  x", "", "ballroom x"},
+	}
+	for _, c := range cases {
+		event := &GenconEvent{Location: c.location, RoomName: c.roomName}
+		if !venue.Locate(event) {
+			t.Errorf("%q %q: expected a change", c.location, c.roomName)
+		}
+		if event.Building != c.building || event.Room != c.room {
+			t.Errorf("%q %q: expected %q %q, got %q %q", c.location, c.roomName, c.building, c.room, event.Building, event.Room)
+		}
+		if venue.Locate(event) {
+			t.Errorf("%q %q: locating again shouldn't change anything", c.location, c.roomName)
+		}
+	}
+
+	if walk, known := venue.Walk("JW", "ICC"); !known || walk != 6*time.Minute {
+		t.Errorf("walks should work both ways, got %v %v", walk, known)
+	}
+}
+
+func TestWalkingTimeUnknownBuilding(t *testing.T) {
+	venue := NewVenue([]*Building{{Code: "ICC", Name: "Indiana Convention Center", InsideWalk: 5 * time.Minute}}, nil, nil, nil)
+	inside := &GenconEvent{Building: "ICC", Room: "Hall A"}
+	if walk, known := venue.WalkingTime(inside, &GenconEvent{Building: "ICC", Room: "Hall B"}); !known || walk != 5*time.Minute {
+		t.Errorf("expected the inside walk, got %v %v", walk, known)
+	}
+	// Placed before the building was deleted
+	deleted := &GenconEvent{Building: "XYZ", Room: "Room 1"}
+	if _, known := venue.WalkingTime(deleted, &GenconEvent{Building: "XYZ", Room: "Room 2"}); known {
+		t.Error("expected no walking time inside a building the venue doesn't have")
+	}
+	if _, known := venue.WalkingTime(inside, deleted); known {
+		t.Error("expected no walking time to a building the venue doesn't have")
+	}
+}
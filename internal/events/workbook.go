@@ -0,0 +1,457 @@
+package events
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Just enough of the OOXML spreadsheet format to read Gen Con's event
+// export, whichever app last saved it. Sheets are found through the
+// workbook's relationships rather than assuming file names, and rows are
+// streamed so large sheets don't have to be held in memory as xml.
+
+const (
+	worksheetRelation     = "/worksheet"
+	sharedStringsRelation = "/sharedStrings"
+	stylesRelation        = "/styles"
+	officeDocRelation     = "/officeDocument"
+)
+
+type relationship struct {
+	Id     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+type relationships struct {
+	Relationships []relationship `xml:"Relationship"`
+}
+
+// Text made of one or more runs, as in shared strings and inline strings
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t *richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+type excelCell struct {
+	Type   string   `xml:"t,attr"`
+	CellId string   `xml:"r,attr"`
+	Style  int      `xml:"s,attr"`
+	Inline richText `xml:"is"`
+	Value  string   `xml:"v"`
+}
+
+type excelRow struct {
+	Number int         `xml:"r,attr"`
+	Cells  []excelCell `xml:"c"`
+}
+
+type workbookSheet struct {
+	Name   string
+	Hidden bool
+	path   string
+}
+
+type workbook struct {
+	files         map[string]*zip.File
+	sheets        []workbookSheet
+	sharedStrings []string
+	// Cell styles that display numbers as dates
+	dateStyles map[int]bool
+	// Dates count from 1904 rather than 1900, old Mac workbooks do this
+	date1904 bool
+}
+
+func openWorkbook(rawBytes []byte) (*workbook, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(rawBytes), (int64)(len(rawBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("not a spreadsheet: %w", err)
+	}
+	book := &workbook{files: make(map[string]*zip.File), dateStyles: make(map[int]bool)}
+	for _, file := range zipReader.File {
+		book.files[file.Name] = file
+	}
+
+	workbookPath := "xl/workbook.xml"
+	rootRels, err := book.relationships("")
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range rootRels {
+		if strings.HasSuffix(rel.Type, officeDocRelation) {
+			workbookPath = resolveTarget("", rel.Target)
+		}
+	}
+
+	var parsed struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			State string     `xml:"state,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = book.unmarshal(workbookPath, &parsed); err != nil {
+		return nil, err
+	}
+	book.date1904 = parsed.Properties.Date1904 == "1" || parsed.Properties.Date1904 == "true"
+
+	workbookDir := path.Dir(workbookPath)
+	workbookRels, err := book.relationships(workbookPath)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	stringsPath, stylesPath := "", ""
+	for _, rel := range workbookRels {
+		target := resolveTarget(workbookDir, rel.Target)
+		switch {
+		case strings.HasSuffix(rel.Type, worksheetRelation):
+			targets[rel.Id] = target
+		case strings.HasSuffix(rel.Type, sharedStringsRelation):
+			stringsPath = target
+		case strings.HasSuffix(rel.Type, stylesRelation):
+			stylesPath = target
+		}
+	}
+
+	for _, sheet := range parsed.Sheets {
+		// The relationship id is namespaced, and which namespace depends on
+		// whether the file was saved as strict or transitional OOXML
+		for _, attr := range sheet.Attrs {
+			if attr.Name.Local == "id" {
+				if target, found := targets[attr.Value]; found {
+					book.sheets = append(book.sheets, workbookSheet{
+						Name:   sheet.Name,
+						Hidden: sheet.State == "hidden" || sheet.State == "veryHidden",
+						path:   target,
+					})
+				}
+			}
+		}
+	}
+	if len(book.sheets) == 0 {
+		return nil, fmt.Errorf("no worksheets in the spreadsheet")
+	}
+
+	if stringsPath != "" {
+		if err = book.loadSharedStrings(stringsPath); err != nil {
+			return nil, err
+		}
+	}
+	if stylesPath != "" {
+		if err = book.loadStyles(stylesPath); err != nil {
+			return nil, err
+		}
+	}
+	return book, nil
+}
+
+// Relationship targets are relative to the part's directory, unless they
+// start with / which is the root of the package.
+func resolveTarget(dir string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Clean(path.Join(dir, target))
+}
+
+func (w *workbook) open(name string) (io.ReadCloser, error) {
+	file, found := w.files[name]
+	if !found {
+		return nil, fmt.Errorf("spreadsheet is missing %v", name)
+	}
+	return file.Open()
+}
+
+func (w *workbook) unmarshal(name string, into interface{}) error {
+	reader, err := w.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err = xml.NewDecoder(reader).Decode(into); err != nil {
+		return fmt.Errorf("unable to read %v: %w", name, err)
+	}
+	return nil
+}
+
+// The relationships of a part, none if it doesn't have any
+func (w *workbook) relationships(part string) ([]relationship, error) {
+	relsPath := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	if part == "" {
+		relsPath = "_rels/.rels"
+	}
+	if _, found := w.files[relsPath]; !found {
+		return nil, nil
+	}
+	var rels relationships
+	if err := w.unmarshal(relsPath, &rels); err != nil {
+		return nil, err
+	}
+	return rels.Relationships, nil
+}
+
+func (w *workbook) loadSharedStrings(name string) error {
+	reader, err := w.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read %v: %w", name, err)
+		}
+		if start, isStart := token.(xml.StartElement); isStart && start.Name.Local == "si" {
+			var item richText
+			if err = decoder.DecodeElement(&item, &start); err != nil {
+				return fmt.Errorf("unable to read %v: %w", name, err)
+			}
+			w.sharedStrings = append(w.sharedStrings, item.String())
+		}
+	}
+}
+
+// Number formats Excel has built in which show dates or times
+func builtinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// Whether a custom number format shows a date or time, ignoring quoted text,
+// escaped characters and [colour] or [$-409] sections.
+func dateFormatCode(code string) bool {
+	var stripped strings.Builder
+	quoted, bracketed, escaped := false, false, false
+	for _, r := range code {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracketed = true
+		case r == ']':
+			bracketed = false
+		case bracketed:
+		default:
+			stripped.WriteRune(r)
+		}
+	}
+	normalized := strings.ToLower(stripped.String())
+	if normalized == "general" {
+		return false
+	}
+	return strings.ContainsAny(normalized, "ymdhs")
+}
+
+func (w *workbook) loadStyles(name string) error {
+	var styles struct {
+		NumberFormats []struct {
+			Id   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellFormats []struct {
+			NumberFormat int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := w.unmarshal(name, &styles); err != nil {
+		return err
+	}
+
+	customDates := make(map[int]bool)
+	for _, format := range styles.NumberFormats {
+		customDates[format.Id] = dateFormatCode(format.Code)
+	}
+	for i, format := range styles.CellFormats {
+		isDate, custom := customDates[format.NumberFormat]
+		if !custom {
+			isDate = builtinDateFormat(format.NumberFormat)
+		}
+		if isDate {
+			w.dateStyles[i] = true
+		}
+	}
+	return nil
+}
+
+// Excel counts days from 1899-12-30, so that its pretend 29th of February
+// 1900 works out. Fractions of a day are the time, in Gen Con's time zone.
+func excelDate(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, Indianapolis)
+	if date1904 {
+		epoch = time.Date(1904, time.January, 1, 0, 0, 0, 0, Indianapolis)
+	}
+	days := math.Floor(serial)
+	seconds := int(math.Round((serial - days) * 24 * 60 * 60))
+	return time.Date(epoch.Year(), epoch.Month(), epoch.Day()+int(days), 0, 0, seconds, 0, Indianapolis)
+}
+
+// Zero based column from a reference like AF12, -1 if there's no column
+func columnIndex(cellId string) int {
+	index := 0
+	for _, r := range cellId {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
+
+func (w *workbook) toRawCell(cell *excelCell) rawCell {
+	var raw rawCell
+	switch cell.Type {
+	case "inlineStr":
+		raw.Text = cell.Inline.String()
+	case "s":
+		if i, err := strconv.Atoi(cell.Value); err == nil && i >= 0 && i < len(w.sharedStrings) {
+			raw.Text = w.sharedStrings[i]
+		}
+	case "str":
+		raw.Text = cell.Value
+	case "b":
+		raw.Text = "FALSE"
+		if cell.Value == "1" {
+			raw.Text = "TRUE"
+		}
+	case "d":
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+			if parsed, err := time.ParseInLocation(layout, cell.Value, Indianapolis); err == nil {
+				raw.Time, raw.IsTime = parsed, true
+				break
+			}
+		}
+	case "e":
+		// Formula errors import as empty
+	default:
+		number, err := strconv.ParseFloat(cell.Value, 64)
+		if err != nil {
+			break
+		}
+		if w.dateStyles[cell.Style] {
+			raw.Time, raw.IsTime = excelDate(number, w.date1904), true
+		} else {
+			raw.Number, raw.IsNumber = number, true
+		}
+	}
+	if raw.IsTime {
+		raw.Text = raw.Time.Format("01/02/2006 03:04 PM")
+	}
+	return raw
+}
+
+// Lays the row's cells out by column, empty cells are left out of the sheet
+// entirely so position in the row isn't enough.
+func (w *workbook) rawCells(row *excelRow) []rawCell {
+	cells := make([]rawCell, 0, len(row.Cells))
+	for i := range row.Cells {
+		column := columnIndex(row.Cells[i].CellId)
+		if column < 0 {
+			// Without a reference, cells follow on from the last one
+			column = len(cells)
+		}
+		for len(cells) <= column {
+			cells = append(cells, rawCell{})
+		}
+		cells[column] = w.toRawCell(&row.Cells[i])
+	}
+	return cells
+}
+
+// The first sheet a person opening the file would see
+func (w *workbook) firstVisibleSheet() *workbookSheet {
+	for i := range w.sheets {
+		if !w.sheets[i].Hidden {
+			return &w.sheets[i]
+		}
+	}
+	return &w.sheets[0]
+}
+
+// What Gen Con calls the sheet with the events on it
+const eventsSheetName = "Events"
+
+// The events sheet, or the first one showing when it's been renamed
+func (w *workbook) eventsSheet() *workbookSheet {
+	if sheet := w.sheet(eventsSheetName); sheet != nil {
+		return sheet
+	}
+	return w.firstVisibleSheet()
+}
+
+func (w *workbook) sheet(name string) *workbookSheet {
+	for i := range w.sheets {
+		if w.sheets[i].Name == name {
+			return &w.sheets[i]
+		}
+	}
+	return nil
+}
+
+// Calls fn for each row of the sheet in order, with the row's number in the
+// sheet. Stops at the first error fn returns.
+func (w *workbook) eachRow(sheet *workbookSheet, fn func(row int, cells []rawCell) error) error {
+	reader, err := w.open(sheet.path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(reader)
+	rowNumber := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read sheet %v: %w", sheet.Name, err)
+		}
+
+		start, isStart := token.(xml.StartElement)
+		if !isStart || start.Name.Local != "row" {
+			continue
+		}
+		var row excelRow
+		if err = decoder.DecodeElement(&row, &start); err != nil {
+			return fmt.Errorf("unable to read sheet %v: %w", sheet.Name, err)
+		}
+		rowNumber++
+		if row.Number > 0 {
+			rowNumber = row.Number
+		}
+		if err = fn(rowNumber, w.rawCells(&row)); err != nil {
+			return err
+		}
+	}
+}
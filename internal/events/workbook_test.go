@@ -0,0 +1,206 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Reads a saved workbook from testdata. Each follows how its app lays out a
+// saved file, see testdata/README.md for where they came from.
+func loadFixture(t *testing.T, name string) []byte {
+	content, err := os.ReadFile(filepath.Join("testdata", name+".xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestParseSavedWorkbooks(t *testing.T) {
+	type expected struct {
+		eventId     string
+		title       string
+		start       time.Time
+		duration    int
+		tickets     int
+		cost        int
+		tournament  bool
+		room        string
+		lastUpdated time.Time
+	}
+	want := []expected{
+		{"RPG24ND290001", "Dungeon Delve", time.Date(2024, 8, 1, 10, 0, 0, 0, Indianapolis), 240, 6, 4, false, "Sagamore 1", time.Date(2024, 7, 15, 0, 0, 0, 0, Indianapolis)},
+		{"BGM24ND290002", "Ticket & Ride", time.Date(2024, 8, 2, 15, 30, 0, 0, Indianapolis), 120, 0, 2, false, "Room 12", time.Date(2024, 7, 16, 0, 0, 0, 0, Indianapolis)},
+		{"TCG24ND290003", "Finals", time.Date(2024, 8, 3, 20, 0, 0, 0, Indianapolis), 90, 32, 10, true, "Hall E", time.Date(2024, 7, 17, 0, 0, 0, 0, Indianapolis)},
+	}
+
+	for _, fixture := range []string{"excel", "excel_strict", "libreoffice", "google_sheets"} {
+		t.Run(fixture, func(t *testing.T) {
+			result, err := ParseGenconSheet(loadFixture(t, fixture), Strict)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Headers.Unrecognized) > 0 {
+				t.Errorf("unexpected unrecognized headers: %v", result.Headers.Unrecognized)
+			}
+			if len(result.Events) != len(want) {
+				t.Fatalf("expected %d events, got %d", len(want), len(result.Events))
+			}
+			for i, w := range want {
+				got := result.Events[i]
+				if got.EventId != w.eventId || got.Title != w.title {
+					t.Errorf("event %d: expected %v %q, got %v %q", i, w.eventId, w.title, got.EventId, got.Title)
+				}
+				if !got.StartTime.Equal(w.start) {
+					t.Errorf("%v: expected start %v, got %v", w.eventId, w.start, got.StartTime)
+				}
+				if got.Duration != w.duration || !got.EndTime.Equal(w.start.Add(time.Duration(w.duration)*time.Minute)) {
+					t.Errorf("%v: expected %d minutes, got %d ending %v", w.eventId, w.duration, got.Duration, got.EndTime)
+				}
+				if got.TicketsAvailable != w.tickets || got.Cost != w.cost || got.Tournament != w.tournament {
+					t.Errorf("%v: expected %d tickets at $%d tournament %v, got %d at $%d tournament %v",
+						w.eventId, w.tickets, w.cost, w.tournament, got.TicketsAvailable, got.Cost, got.Tournament)
+				}
+				if got.RoomName != w.room {
+					t.Errorf("%v: expected room %q, got %q", w.eventId, w.room, got.RoomName)
+				}
+				if !got.LastModified.Equal(w.lastUpdated) {
+					t.Errorf("%v: expected last modified %v, got %v", w.eventId, w.lastUpdated, got.LastModified)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkbookSheetDiscovery(t *testing.T) {
+	book, err := openWorkbook(loadFixture(t, "excel"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(book.sheets) != 2 {
+		t.Fatalf("expected 2 sheets, got %v", book.sheets)
+	}
+	if sheet := book.firstVisibleSheet(); sheet.Name != "Events" || sheet.path != "xl/worksheets/sheet1.xml" {
+		t.Errorf("expected the visible Events sheet, got %+v", sheet)
+	}
+	lookups := book.sheet("Lookups")
+	if lookups == nil || !lookups.Hidden || lookups.path != "xl/worksheets/sheet2.xml" {
+		t.Fatalf("expected the hidden Lookups sheet, got %+v", lookups)
+	}
+	var rows [][]rawCell
+	err = book.eachRow(lookups, func(row int, cells []rawCell) error {
+		rows = append(rows, cells)
+		return nil
+	})
+	if err != nil || len(rows) != 2 || rows[1][0].Text != "RPG" {
+		t.Errorf("expected to read the Lookups sheet by name, got %v %v", rows, err)
+	}
+	if book.sheet("Missing") != nil {
+		t.Error("expected no sheet for an unknown name")
+	}
+}
+
+func TestWorkbookGoogleSheetsExport(t *testing.T) {
+	book, err := openWorkbook(loadFixture(t, "google_sheets_export"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var text []string
+	err = book.eachRow(book.firstVisibleSheet(), func(row int, cells []rawCell) error {
+		for _, cell := range cells {
+			text = append(text, cell.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The trailing spaces are only kept because of xml:space
+	if got := strings.Join(text, ""); got != "this is anexamplespreadsheet" {
+		t.Errorf("expected the shared strings with their spaces, got %q", got)
+	}
+}
+
+func TestWorkbookEventsSheet(t *testing.T) {
+	book := &workbook{sheets: []workbookSheet{{Name: "Notes"}, {Name: "Events"}}}
+	if sheet := book.eventsSheet(); sheet.Name != "Events" {
+		t.Errorf("expected the Events sheet even though it isn't first, got %+v", sheet)
+	}
+	book = &workbook{sheets: []workbookSheet{{Name: "Lookups", Hidden: true}, {Name: "Sheet1"}}}
+	if sheet := book.eventsSheet(); sheet.Name != "Sheet1" {
+		t.Errorf("expected the first visible sheet without an Events sheet, got %+v", sheet)
+	}
+}
+
+func TestWorkbookMissingParts(t *testing.T) {
+	// Only a worksheet, nothing saying it's there
+	_, err := ParseGenconSheet(zipFiles(t, map[string]string{
+		"xl/worksheets/sheet1.xml": "<worksheet><sheetData/></worksheet>",
+	}), Lenient)
+	if err == nil {
+		t.Error("expected an error without a workbook")
+	}
+
+	// A workbook pointing at a sheet that isn't in the file
+	_, err = ParseGenconSheet(zipFiles(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet9.xml"/>
+</Relationships>`,
+	}), Lenient)
+	if err == nil {
+		t.Error("expected an error for the missing sheet")
+	}
+}
+
+func TestDateFormatCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"General":                  false,
+		"0.00":                     false,
+		`"$"#,##0`:                 false,
+		`#,##0 "days"`:             false,
+		"[Red]0.00":                false,
+		"m/d/yyyy h:mm:ss":         true,
+		`MM/DD/YYYY\ HH:MM\ AM/PM`: true,
+		`MM\-DD\-YY`:               true,
+		"[$-409]h:mm AM/PM":        true,
+	} {
+		if got := dateFormatCode(code); got != want {
+			t.Errorf("dateFormatCode(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestExcelDate(t *testing.T) {
+	for _, test := range []struct {
+		serial   float64
+		date1904 bool
+		want     time.Time
+	}{
+		{45505, false, time.Date(2024, 8, 1, 0, 0, 0, 0, Indianapolis)},
+		{45505.4166666667, false, time.Date(2024, 8, 1, 10, 0, 0, 0, Indianapolis)},
+		// Across the end of daylight saving time
+		{45599.75, false, time.Date(2024, 11, 3, 18, 0, 0, 0, Indianapolis)},
+		{44043, true, time.Date(2024, 8, 1, 0, 0, 0, 0, Indianapolis)},
+	} {
+		if got := excelDate(test.serial, test.date1904); !got.Equal(test.want) {
+			t.Errorf("excelDate(%v, %v) = %v, want %v", test.serial, test.date1904, got, test.want)
+		}
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	for _, test := range []struct{ dir, target, want string }{
+		{"xl", "worksheets/sheet1.xml", "xl/worksheets/sheet1.xml"},
+		{"xl", "/xl/worksheets/sheet1.xml", "xl/worksheets/sheet1.xml"},
+		{"xl", "../customXml/item1.xml", "customXml/item1.xml"},
+		{"", "xl/workbook.xml", "xl/workbook.xml"},
+	} {
+		if got := resolveTarget(test.dir, test.target); got != test.want {
+			t.Errorf("resolveTarget(%q, %q) = %q, want %q", test.dir, test.target, got, test.want)
+		}
+	}
+}
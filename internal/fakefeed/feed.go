@@ -0,0 +1,267 @@
+package fakefeed
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// Made up, but shaped like the real thing: a category, a game system and a
+// few titles to run it under
+var games = []struct {
+	category    string
+	eventType   string
+	gameSystem  string
+	titles      []string
+	minPlayers  int
+	maxPlayers  int
+	hours       []float64
+	tournaments bool
+}{
+	{"RPG", "RPG - Role Playing Game", "Dungeons & Dragons", []string{"Tomb of Ash", "The Sunken Keep", "Dragons of the Marsh"}, 3, 6, []float64{2, 4}, false},
+	{"RPG", "RPG - Role Playing Game", "Call of Cthulhu", []string{"The Lighthouse Keeper", "Midnight at Arkham Station"}, 3, 6, []float64{4}, false},
+	{"RPG", "RPG - Role Playing Game", "Pathfinder", []string{"Society Scenario: Ember Road", "Beginner Box Bash"}, 4, 6, []float64{2, 4}, false},
+	{"BGM", "BGM - Board Game", "Catan", []string{"Learn to Play Catan", "Catan Qualifier"}, 3, 4, []float64{1, 2}, true},
+	{"BGM", "BGM - Board Game", "Ticket to Ride", []string{"Ticket to Ride: Learn to Play", "Ticket to Ride Open"}, 2, 5, []float64{1, 2}, true},
+	{"BGM", "BGM - Board Game", "Wingspan", []string{"Wingspan Demo", "Wingspan: Oceania"}, 1, 5, []float64{1.5}, false},
+	{"TCG", "TCG - Tradeable Card Game", "Magic: The Gathering", []string{"Draft Night", "Modern Showdown"}, 8, 32, []float64{3, 5}, true},
+	{"NMN", "NMN - Non-Historical Miniatures", "Warhammer 40,000", []string{"Kill Team Skirmish", "Grand Tournament"}, 2, 16, []float64{3, 8}, true},
+	{"SEM", "SEM - Seminar", "none", []string{"Writing for Games", "Running Your First Con Game"}, 1, 60, []float64{1}, false},
+	{"LRP", "LRP - LARP", "none", []string{"Masquerade at the Manor", "The Last Starship"}, 10, 30, []float64{4}, false},
+	{"ENT", "ENT - Entertainment Events", "none", []string{"Costume Contest", "Improv Comedy Night"}, 1, 200, []float64{1.5, 2}, false},
+	{"KID", "KID - Kids Activities", "none", []string{"Dice Painting", "Build a Dragon"}, 1, 12, []float64{1}, false},
+}
+
+var locations = []struct {
+	location string
+	rooms    []string
+}{
+	{"ICC", []string{"Hall A", "Hall E", "Room 101", "Room 127", "Sagamore 1"}},
+	{"JW", []string{"Grand Ballroom 1", "White River A"}},
+	{"Westin", []string{"Capitol I", "Caucus"}},
+}
+
+// How the listing moved in one step
+type Changes struct {
+	Version     int
+	Added       []string
+	Removed     []string
+	Rescheduled []string
+	SoldOut     []string
+	// Sold some tickets, but not all of them
+	Sold []string
+}
+
+func (c *Changes) String() string {
+	return fmt.Sprintf("version %d: %d added, %d removed, %d rescheduled, %d sold out, %d selling",
+		c.Version, len(c.Added), len(c.Removed), len(c.Rescheduled), len(c.SoldOut), len(c.Sold))
+}
+
+// A synthetic listing for one convention that moves a little with every
+// step, the way the real one does in the weeks before the con. The same seed
+// always gives the same listing and the same changes.
+type Feed struct {
+	mu      sync.Mutex
+	random  *rand.Rand
+	year    int
+	first   time.Time
+	nextId  int
+	version int
+	events  map[string]*events.GenconEvent
+}
+
+// The Thursday the convention opens, it runs through Sunday in early August
+func conventionStart(year int) time.Time {
+	start := time.Date(year, time.July, 30, 0, 0, 0, 0, events.Indianapolis)
+	for start.Weekday() != time.Thursday {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+func NewFeed(year int, size int, seed int64) *Feed {
+	feed := &Feed{
+		random:  rand.New(rand.NewSource(seed)),
+		year:    year,
+		first:   conventionStart(year),
+		nextId:  1,
+		version: 1,
+		events:  make(map[string]*events.GenconEvent),
+	}
+	for len(feed.events) < size {
+		feed.addEvents()
+	}
+	return feed
+}
+
+// When the listing last changed, what the feed puts in Last Modified
+func (f *Feed) modified() time.Time {
+	// A day per version, counting back from a month before the con
+	return f.first.AddDate(0, -1, f.version-1)
+}
+
+func (f *Feed) newId(category string) string {
+	id := fmt.Sprintf("%s%02dND%06d", category, f.year%100, f.nextId)
+	f.nextId++
+	return id
+}
+
+func (f *Feed) schedule(event *events.GenconEvent, start time.Time) {
+	event.StartTime = start
+	event.EndTime = start.Add(time.Duration(event.Duration) * time.Minute)
+}
+
+// Adds a game's sessions, one or a few of the same title, which the site
+// clusters together
+func (f *Feed) addEvents() []string {
+	game := games[f.random.Intn(len(games))]
+	title := game.titles[f.random.Intn(len(game.titles))]
+	hours := game.hours[f.random.Intn(len(game.hours))]
+	place := locations[f.random.Intn(len(locations))]
+	room := place.rooms[f.random.Intn(len(place.rooms))]
+	players := game.minPlayers + f.random.Intn(game.maxPlayers-game.minPlayers+1)
+	group := fmt.Sprintf("%s Guild", strings.Fields(title)[0])
+	gm := fmt.Sprintf("GM %c. Example", 'A'+rune(f.random.Intn(26)))
+
+	sessions := 1 + f.random.Intn(3)
+	added := make([]string, 0, sessions)
+	for i := 0; i < sessions; i++ {
+		day := f.random.Intn(4)
+		// Half hours from 8am to 10pm
+		halfHours := 16 + f.random.Intn(29)
+		event := &events.GenconEvent{
+			EventId:              f.newId(game.category),
+			Year:                 f.year,
+			Active:               true,
+			Group:                group,
+			Title:                title,
+			ShortDescription:     fmt.Sprintf("%s, a synthetic event for testing imports.", title),
+			LongDescription:      fmt.Sprintf("%s\n\nNothing here is real, it's generated by the fake feed.", title),
+			EventType:            game.eventType,
+			GameSystem:           game.gameSystem,
+			FeedGameSystem:       game.gameSystem,
+			MinPlayers:           game.minPlayers,
+			MaxPlayers:           players,
+			AgeRequired:          "Everyone (6+)",
+			ExperienceRequired:   "None (You've never played before - rules will be taught)",
+			MaterialsProvided:    true,
+			Duration:             int(hours * 60),
+			GMNames:              gm,
+			Email:                "gm@example.com",
+			Tournament:           game.tournaments && f.random.Intn(2) == 0,
+			CostCents:            200 * f.random.Intn(5),
+			AttendeeRegistration: "Yes, they can register for this round without having played in any other events",
+			Location:             place.location,
+			RoomName:             room,
+			TableNumber:          fmt.Sprint(1 + f.random.Intn(40)),
+			TicketsAvailable:     players,
+			LastModified:         f.modified(),
+			ShortCategory:        game.category,
+		}
+		event.Cost = event.CostCents / 100
+		events.ParseDetails(event)
+		f.schedule(event, f.first.AddDate(0, 0, day).Add(time.Duration(halfHours)*30*time.Minute))
+		f.events[event.EventId] = event
+		added = append(added, event.EventId)
+	}
+	return added
+}
+
+// Ids in order, so picks from the same seed don't depend on map order
+func (f *Feed) ids() []string {
+	ids := make([]string, 0, len(f.events))
+	for id := range f.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Up to n different events, picked at random
+func (f *Feed) pick(n int, include func(*events.GenconEvent) bool) []*events.GenconEvent {
+	picked := make([]*events.GenconEvent, 0, n)
+	ids := f.ids()
+	for _, i := range f.random.Perm(len(ids)) {
+		if len(picked) == n {
+			break
+		}
+		if event := f.events[ids[i]]; include(event) {
+			picked = append(picked, event)
+		}
+	}
+	return picked
+}
+
+func anyEvent(*events.GenconEvent) bool { return true }
+
+// Moves the listing on a step: a few events added and removed, a few moved
+// to other times, and tickets sold, some selling out.
+func (f *Feed) Advance() *Changes {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version++
+	changes := &Changes{Version: f.version}
+	churn := 1 + len(f.events)/100
+
+	for _, event := range f.pick(churn, anyEvent) {
+		delete(f.events, event.EventId)
+		changes.Removed = append(changes.Removed, event.EventId)
+	}
+	for len(changes.Added) < churn {
+		changes.Added = append(changes.Added, f.addEvents()...)
+	}
+	for _, event := range f.pick(churn, anyEvent) {
+		// An hour or two either way, staying on the same day
+		shift := time.Duration(1+f.random.Intn(2)) * time.Hour
+		if event.StartTime.Hour() >= 12 {
+			shift = -shift
+		}
+		f.schedule(event, event.StartTime.Add(shift))
+		event.LastModified = f.modified()
+		changes.Rescheduled = append(changes.Rescheduled, event.EventId)
+	}
+	onSale := func(event *events.GenconEvent) bool { return event.TicketsAvailable > 0 }
+	for _, event := range f.pick(churn, onSale) {
+		event.TicketsAvailable = 0
+		changes.SoldOut = append(changes.SoldOut, event.EventId)
+	}
+	for _, event := range f.pick(5*churn, onSale) {
+		event.TicketsAvailable -= 1 + f.random.Intn(event.TicketsAvailable)
+		if event.TicketsAvailable == 0 {
+			changes.SoldOut = append(changes.SoldOut, event.EventId)
+		} else {
+			changes.Sold = append(changes.Sold, event.EventId)
+		}
+	}
+	return changes
+}
+
+// When the listing last changed
+func (f *Feed) Modified() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.modified()
+}
+
+func (f *Feed) Version() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.version
+}
+
+// A copy of the current listing in id order, and its version
+func (f *Feed) Events() ([]*events.GenconEvent, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	listing := make([]*events.GenconEvent, 0, len(f.events))
+	for _, id := range f.ids() {
+		copied := *f.events[id]
+		listing = append(listing, &copied)
+	}
+	return listing, f.version
+}
@@ -0,0 +1,103 @@
+package fakefeed
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestFeedIsRepeatable(t *testing.T) {
+	first, second := NewFeed(2024, 200, 7), NewFeed(2024, 200, 7)
+	if !reflect.DeepEqual(first.Advance(), second.Advance()) {
+		t.Errorf("expected the same seed to make the same changes")
+	}
+	a, _ := first.Events()
+	b, _ := second.Events()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same seed to make the same listing")
+	}
+}
+
+func TestFeedAdvance(t *testing.T) {
+	feed := NewFeed(2024, 300, 1)
+	before, _ := feed.Events()
+	byId := make(map[string]*events.GenconEvent)
+	for _, event := range before {
+		byId[event.EventId] = event
+	}
+
+	changes := feed.Advance()
+	if len(changes.Added) == 0 || len(changes.Removed) == 0 || len(changes.Rescheduled) == 0 || len(changes.SoldOut) == 0 {
+		t.Fatalf("expected every kind of change, got %v", changes)
+	}
+	after, version := feed.Events()
+	if version != 2 || changes.Version != 2 {
+		t.Errorf("expected version 2, got %d", version)
+	}
+	current := make(map[string]*events.GenconEvent)
+	for _, event := range after {
+		current[event.EventId] = event
+	}
+	for _, id := range changes.Removed {
+		if current[id] != nil {
+			t.Errorf("%v was removed but is still listed", id)
+		}
+	}
+	for _, id := range changes.Rescheduled {
+		if event := current[id]; event != nil && event.StartTime.Equal(byId[id].StartTime) {
+			t.Errorf("%v was rescheduled but didn't move", id)
+		}
+	}
+	for _, id := range changes.SoldOut {
+		if event := current[id]; event != nil && event.TicketsAvailable != 0 {
+			t.Errorf("%v sold out but has %d tickets", id, event.TicketsAvailable)
+		}
+	}
+}
+
+func TestServerFeedsTheImporter(t *testing.T) {
+	feed := NewFeed(2024, 100, 1)
+	server := httptest.NewServer(NewServer(feed, 2))
+	defer server.Close()
+
+	fetch := func(path string, etag string) (*http.Response, []byte) {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		content, _ := io.ReadAll(resp.Body)
+		return resp, content
+	}
+
+	resp, content := fetch("/downloads/events.xlsx", "")
+	result, err := events.ParseContent("events.xlsx", content, resp.Header.Get("Content-Type"), events.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listing, _ := feed.Events()
+	if len(result.Events) != len(listing) {
+		t.Errorf("expected %d events, got %d", len(listing), len(result.Events))
+	}
+
+	// Two downloads of each version, the csv is the same version too
+	etag := resp.Header.Get("ETag")
+	if resp, _ = fetch("/downloads/events.csv", etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected the same version, got %v", resp.Status)
+	}
+	resp, content = fetch("/downloads/events.csv", etag)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Errorf("expected a new version, got %v %v", resp.Status, resp.Header.Get("ETag"))
+	}
+	if _, err = events.ParseContent("events.csv", content, resp.Header.Get("Content-Type"), events.Strict); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,113 @@
+package fakefeed
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+const (
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	csvContentType  = "text/csv; charset=utf-8"
+)
+
+// Serves a feed where gencon.com has it, /downloads/events.xlsx, along with
+// /downloads/events.csv. The feed moves on every AdvanceEvery downloads,
+// or when something POSTs to /advance. Downloads between changes get the
+// same bytes with an ETag, so conditional fetches see it unchanged.
+type Server struct {
+	Feed *Feed
+	// 0 to only advance when asked
+	AdvanceEvery int
+
+	mu        sync.Mutex
+	downloads int
+	// Rendered files for the current version, by path
+	rendered map[string][]byte
+	version  int
+}
+
+func NewServer(feed *Feed, advanceEvery int) *Server {
+	return &Server{Feed: feed, AdvanceEvery: advanceEvery}
+}
+
+func (s *Server) advance() *Changes {
+	changes := s.Feed.Advance()
+	log.Print(changes)
+	return changes
+}
+
+// The file for the feed's current version, rendering it the first time
+func (s *Server) render(path string) ([]byte, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	listing, version := s.Feed.Events()
+	if version != s.version {
+		s.rendered = make(map[string][]byte)
+		s.version = version
+	}
+	if content, found := s.rendered[path]; found {
+		return content, version, nil
+	}
+
+	var content bytes.Buffer
+	var err error
+	if path == "/downloads/events.csv" {
+		err = events.WriteGenconCsv(&content, listing)
+	} else {
+		err = events.WriteGenconSheet(&content, listing)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	s.rendered[path] = content.Bytes()
+	return content.Bytes(), version, nil
+}
+
+// Whether this download should move the feed on first
+func (s *Server) countDownload() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downloads++
+	// The first download is the listing as generated
+	return s.AdvanceEvery > 0 && s.downloads > 1 && (s.downloads-1)%s.AdvanceEvery == 0
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/advance" && r.Method == http.MethodPost:
+		fmt.Fprintln(w, s.advance())
+		return
+	case r.URL.Path != "/downloads/events.xlsx" && r.URL.Path != "/downloads/events.csv":
+		http.NotFound(w, r)
+		return
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.countDownload() {
+		s.advance()
+	}
+	content, version, err := s.render(r.URL.Path)
+	if err != nil {
+		log.Printf("Error writing the feed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := xlsxContentType
+	if r.URL.Path == "/downloads/events.csv" {
+		contentType = csvContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+	// Whole seconds, since that's all Last-Modified can say
+	modified := s.Feed.Modified().UTC().Truncate(time.Second)
+	http.ServeContent(w, r, r.URL.Path, modified, bytes.NewReader(content))
+}
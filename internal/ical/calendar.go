@@ -0,0 +1,171 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// All of gencon happens in Indianapolis, so every event is written in local
+// time with this zone rather than UTC. That keeps the times readable if
+// someone opens the file in a text editor, and calendar apps handle it fine.
+const TimeZoneId = "America/Indianapolis"
+
+// How often subscribed calendars should come back for changes. Gencon
+// updates the event list hourly, no point in asking more often.
+const refreshInterval = "PT1H"
+
+const localTimeFormat = "20060102T150405"
+const utcTimeFormat = "20060102T150405Z"
+
+// Indiana has followed US daylight saving rules since 2006, which is
+// before any year this planner knows about.
+const vtimezone = `BEGIN:VTIMEZONE
+TZID:America/Indianapolis
+BEGIN:DAYLIGHT
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+TZNAME:EDT
+DTSTART:19700308T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+TZNAME:EST
+DTSTART:19701101T020000
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU
+END:STANDARD
+END:VTIMEZONE`
+
+type Calendar struct {
+	// Shown as the calendar name when subscribing
+	Name string
+	// Absolute url the planner is served from, used to turn PlannerLink into
+	// something clickable from a phone.
+	BaseUrl string
+	Events  []*events.GenconEvent
+}
+
+// Escapes text values per RFC 5545 section 3.3.11
+func escapeText(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, ";", "\\;")
+	value = strings.ReplaceAll(value, ",", "\\,")
+	value = strings.ReplaceAll(value, "\r\n", "\\n")
+	value = strings.ReplaceAll(value, "\n", "\\n")
+	return value
+}
+
+// Content lines can't be longer than 75 octets, longer lines are continued
+// on the next line with a leading space. We're careful to not split in the
+// middle of a multi-byte character.
+func foldLine(line string) string {
+	const maxOctets = 75
+	if len(line) <= maxOctets {
+		return line
+	}
+
+	var folded strings.Builder
+	lineLength := 0
+	for _, r := range line {
+		runeLength := len(string(r))
+		if lineLength+runeLength > maxOctets {
+			folded.WriteString("\r\n ")
+			// The leading space counts towards the line length
+			lineLength = 1
+		}
+		folded.WriteRune(r)
+		lineLength += runeLength
+	}
+	return folded.String()
+}
+
+// Location, room and table as they'd be printed on the badge schedule,
+// skipping any gencon didn't fill in.
+func FormatLocation(event *events.GenconEvent) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{event.Location, event.RoomName} {
+		if part = strings.TrimSpace(part); len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	if table := strings.TrimSpace(event.TableNumber); len(table) > 0 {
+		parts = append(parts, "Table "+table)
+	}
+	return strings.Join(parts, ", ")
+}
+
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) write(line string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.WriteString(foldLine(line) + "\r\n")
+}
+
+func (lw *lineWriter) property(name, value string) {
+	lw.write(name + ":" + value)
+}
+
+func (cal *Calendar) writeEvent(lw *lineWriter, event *events.GenconEvent, stamp time.Time) {
+	plannerLink := strings.TrimSuffix(cal.BaseUrl, "/") + event.PlannerLink()
+	description := strings.TrimSpace(event.ShortDescription)
+	description = fmt.Sprintf("%s\n\nGen Con: %s\nPlanner: %s",
+		description, event.GenconLink(), plannerLink)
+
+	lw.write("BEGIN:VEVENT")
+	lw.property("UID", event.EventId+"@genconplanner.com")
+	lw.property("DTSTAMP", stamp.UTC().Format(utcTimeFormat))
+	if !event.LastModified.IsZero() {
+		lw.property("LAST-MODIFIED", event.LastModified.UTC().Format(utcTimeFormat))
+	}
+	lw.property("DTSTART;TZID="+TimeZoneId, event.StartTime.In(events.Indianapolis).Format(localTimeFormat))
+	lw.property("DTEND;TZID="+TimeZoneId, event.EndTime.In(events.Indianapolis).Format(localTimeFormat))
+	lw.property("SUMMARY", escapeText(event.Title))
+	lw.property("LOCATION", escapeText(FormatLocation(event)))
+	lw.property("DESCRIPTION", escapeText(description))
+	lw.property("URL", event.GenconLink())
+	lw.property("CATEGORIES", escapeText(events.LongCategory(event.ShortCategory)))
+	if !event.Active {
+		lw.property("STATUS", "CANCELLED")
+	}
+	lw.write("END:VEVENT")
+}
+
+// Write renders the calendar as an RFC 5545 VCALENDAR. The same output
+// works for a one-off download and for a subscribed feed.
+func (cal *Calendar) Write(w io.Writer) error {
+	lw := &lineWriter{w: bufio.NewWriter(w)}
+	stamp := time.Now()
+
+	lw.write("BEGIN:VCALENDAR")
+	lw.property("VERSION", "2.0")
+	lw.property("PRODID", "-//genconplanner.com//Gen Con Planner//EN")
+	lw.property("CALSCALE", "GREGORIAN")
+	lw.property("METHOD", "PUBLISH")
+	lw.property("X-WR-CALNAME", escapeText(cal.Name))
+	lw.property("X-WR-TIMEZONE", TimeZoneId)
+	lw.property("REFRESH-INTERVAL;VALUE=DURATION", refreshInterval)
+	lw.property("X-PUBLISHED-TTL", refreshInterval)
+	for _, line := range strings.Split(vtimezone, "\n") {
+		lw.write(line)
+	}
+	for _, event := range cal.Events {
+		cal.writeEvent(lw, event, stamp)
+	}
+	lw.write("END:VCALENDAR")
+
+	if lw.err != nil {
+		return lw.err
+	}
+	return lw.w.Flush()
+}
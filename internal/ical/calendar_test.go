@@ -0,0 +1,86 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestFoldLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := foldLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("Line is %d octets, expected at most 75: %q", len(part), part)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Errorf("Unfolding didn't give back the original line")
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	start := time.Date(2025, time.July, 31, 10, 0, 0, 0, events.Indianapolis)
+	cal := Calendar{
+		Name:    "Starred events",
+		BaseUrl: "https://www.genconplanner.com/",
+		Events: []*events.GenconEvent{{
+			EventId:          "RPG25ND12345",
+			Active:           true,
+			Title:            "Dragons; and, more dragons",
+			ShortDescription: "Bring dice",
+			StartTime:        start,
+			EndTime:          start.Add(4 * time.Hour),
+			Location:         "ICC",
+			RoomName:         "Room 101",
+			TableNumber:      "12",
+			ShortCategory:    "RPG",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := buf.String()
+
+	expectedLines := []string{
+		"DTSTART;TZID=America/Indianapolis:20250731T100000",
+		"DTEND;TZID=America/Indianapolis:20250731T140000",
+		"SUMMARY:Dragons\\; and\\, more dragons",
+		"LOCATION:ICC\\, Room 101\\, Table 12",
+		"URL:http://gencon.com/events/12345",
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(output, expected+"\r\n") {
+			t.Errorf("Missing %q in output", expected)
+		}
+	}
+	unfolded := strings.ReplaceAll(output, "\r\n ", "")
+	if !strings.Contains(unfolded, "https://www.genconplanner.com/event/RPG25ND12345") {
+		t.Errorf("Missing planner link in output")
+	}
+}
+
+func TestWriteCancelledEvent(t *testing.T) {
+	start := time.Date(2025, time.July, 31, 10, 0, 0, 0, events.Indianapolis)
+	cal := Calendar{
+		Name: "Starred events",
+		Events: []*events.GenconEvent{
+			{EventId: "RPG25ND1", Active: true, Title: "Still on", StartTime: start, EndTime: start.Add(time.Hour)},
+			{EventId: "RPG25ND2", Active: false, Title: "Called off", StartTime: start, EndTime: start.Add(time.Hour)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count := strings.Count(buf.String(), "STATUS:CANCELLED\r\n"); count != 1 {
+		t.Errorf("Expected only the inactive event cancelled, got %d", count)
+	}
+}
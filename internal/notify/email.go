@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type EmailNotifier struct {
+	Addr    string
+	Auth    smtp.Auth
+	From    string
+	BaseUrl string
+}
+
+// Builds an email notifier from SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD and NOTIFY_FROM. Returns nil when there's no SMTP_HOST.
+func EmailNotifierFromEnv() *EmailNotifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if _, err := strconv.Atoi(port); err != nil {
+		port = "587"
+	}
+
+	notifier := &EmailNotifier{
+		Addr:    net.JoinHostPort(host, port),
+		From:    os.Getenv("NOTIFY_FROM"),
+		BaseUrl: "https://www.genconplanner.com",
+	}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		notifier.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return notifier
+}
+
+// Titles come from the feed. A line break would start another header, and
+// anything outside ascii has to be encoded to go in one.
+func encodeSubject(subject string) string {
+	subject = strings.Join(strings.FieldsFunc(subject, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	}), " ")
+	return mime.QEncoding.Encode("utf-8", subject)
+}
+
+func (n *EmailNotifier) message(recipient *Preferences, notifications []*Notification) []byte {
+	subject := fmt.Sprintf("%s changed", notifications[0].Title)
+	if len(notifications) > 1 {
+		subject = fmt.Sprintf("%d changes to your starred events", len(notifications))
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", n.From)
+	fmt.Fprintf(&body, "To: %s\r\n", recipient.Email)
+	fmt.Fprintf(&body, "Subject: %s\r\n", encodeSubject(subject))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	body.WriteString("\r\n")
+	for _, notification := range notifications {
+		fmt.Fprintf(&body, "%s (%s): %s\r\n", notification.Title, notification.EventId, notification.Message)
+		fmt.Fprintf(&body, "%s/event/%s\r\n\r\n", n.BaseUrl, notification.EventId)
+	}
+	fmt.Fprintf(&body, "Change what you get at %s/user\r\n", n.BaseUrl)
+	return body.Bytes()
+}
+
+func (n *EmailNotifier) Channel() string {
+	return "email"
+}
+
+func (n *EmailNotifier) Send(recipient *Preferences, notifications []*Notification) error {
+	if !recipient.SendEmail || len(notifications) == 0 {
+		return nil
+	}
+	return smtp.SendMail(n.Addr, n.Auth, n.From, []string{recipient.Email}, n.message(recipient, notifications))
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// Accepts a single message over a bare bones SMTP conversation and hands
+// back its data.
+func fakeSmtpServer(t *testing.T) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost fake")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.Fields(line + " x")[0])
+			switch command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, received := fakeSmtpServer(t)
+	notifier := &EmailNotifier{Addr: addr, From: "planner@example.com", BaseUrl: "https://example.com"}
+
+	recipient := DefaultPreferences("player@example.com")
+	recipient.SendEmail = true
+	err := notifier.Send(recipient, []*Notification{
+		{EventId: "RPG25ND1", Title: "Dungeon Crawl", Reason: Moved, Message: "Room changed from 101 to 105"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-received
+	for _, expected := range []string{
+		"To: player@example.com",
+		"Subject: Dungeon Crawl changed",
+		"Room changed from 101 to 105",
+		"https://example.com/event/RPG25ND1",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected %q in message:\n%s", expected, message)
+		}
+	}
+}
+
+func TestEmailSubjectFromTheFeed(t *testing.T) {
+	notifier := &EmailNotifier{From: "planner@example.com", BaseUrl: "https://example.com"}
+	recipient := DefaultPreferences("player@example.com")
+
+	message := string(notifier.message(recipient, []*Notification{
+		{EventId: "RPG25ND1", Title: "Dungeon Crawl\r\nBcc: everyone@example.com"},
+	}))
+	headers, _, _ := strings.Cut(message, "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("Expected the title kept to the subject line:\n%s", headers)
+	}
+	if !strings.Contains(message, "Subject: Dungeon Crawl Bcc: everyone@example.com changed\r\n") {
+		t.Errorf("Expected the line break replaced in the subject:\n%s", message)
+	}
+
+	message = string(notifier.message(recipient, []*Notification{
+		{EventId: "RPG25ND1", Title: "Café Crawl"},
+	}))
+	if !strings.Contains(message, "Subject: =?utf-8?q?Caf=C3=A9_Crawl_changed?=\r\n") {
+		t.Errorf("Expected an encoded subject:\n%s", message)
+	}
+}
+
+func TestEmailNotifierSkipsOptedOut(t *testing.T) {
+	// Nothing is listening here, so sending would fail
+	notifier := &EmailNotifier{Addr: "127.0.0.1:1"}
+	recipient := DefaultPreferences("player@example.com")
+	recipient.SendEmail = false
+	if err := notifier.Send(recipient, []*Notification{{Title: "x"}}); err != nil {
+		t.Errorf("Expected no send, got %v", err)
+	}
+}
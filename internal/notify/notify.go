@@ -0,0 +1,147 @@
+// Package notify tells users when events they've starred change in ways
+// that could ruin their plans.
+package notify
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+type Reason string
+
+const (
+	Cancelled   Reason = "cancelled"
+	Rescheduled Reason = "rescheduled"
+	Moved       Reason = "moved"
+	LowTickets  Reason = "low_tickets"
+	SoldOut     Reason = "sold_out"
+)
+
+// Dropping to this many tickets or fewer counts as running low
+const FewTickets = events.FewTickets
+
+// How often digest users hear from us
+const DigestInterval = 24 * time.Hour
+
+// Classify decides whether a change is worth telling anyone about.
+func Classify(change *events.EventChange) (Reason, bool) {
+	switch change.Field {
+	case "active":
+		if change.NewValue == "false" {
+			return Cancelled, true
+		}
+	case "start_time":
+		return Rescheduled, true
+	case "location", "room_name":
+		return Moved, true
+	case "tickets_available":
+		oldTickets, err := strconv.Atoi(change.OldValue)
+		if err != nil {
+			return "", false
+		}
+		newTickets, err := strconv.Atoi(change.NewValue)
+		if err != nil {
+			return "", false
+		}
+		// Only on the way down past the threshold, otherwise every ticket
+		// sold would send another one.
+		if newTickets == 0 && oldTickets > 0 {
+			return SoldOut, true
+		}
+		if newTickets <= FewTickets && oldTickets > FewTickets {
+			return LowTickets, true
+		}
+	}
+	return "", false
+}
+
+type Notification struct {
+	Id      int64     `json:"-"`
+	EventId string    `json:"eventId"`
+	Title   string    `json:"title"`
+	Reason  Reason    `json:"reason"`
+	Message string    `json:"message"`
+	Created time.Time `json:"created"`
+	// How far it's gotten on each channel, keyed by Notifier.Channel
+	Deliveries map[string]*Delivery `json:"-"`
+}
+
+// Failed deliveries are retried this many times before giving up
+const MaxAttempts = 5
+
+// Wait this long after the first failure, doubling after each one after
+const RetryDelay = 10 * time.Minute
+
+// How one notification is doing on one channel.
+type Delivery struct {
+	Attempts    int
+	LastAttempt time.Time
+	Sent        bool
+}
+
+// Done once it's gone out or we've given up on it.
+func (d *Delivery) Done() bool {
+	return d != nil && (d.Sent || d.Attempts >= MaxAttempts)
+}
+
+// Whether to try the channel at now, backing off after each failure.
+func (d *Delivery) DueAt(now time.Time) bool {
+	if d.Done() {
+		return false
+	}
+	if d == nil || d.Attempts == 0 {
+		return true
+	}
+	return now.Sub(d.LastAttempt) >= RetryDelay<<(d.Attempts-1)
+}
+
+func (n *Notification) Delivery(channel string) *Delivery {
+	if n.Deliveries == nil {
+		n.Deliveries = make(map[string]*Delivery)
+	}
+	if n.Deliveries[channel] == nil {
+		n.Deliveries[channel] = &Delivery{}
+	}
+	return n.Deliveries[channel]
+}
+
+// What a user wants to hear about, and where.
+type Preferences struct {
+	Email      string    `json:"email"`
+	SendEmail  bool      `json:"sendEmail"`
+	WebhookUrl string    `json:"webhookUrl"`
+	Digest     bool      `json:"digest"`
+	Tickets    bool      `json:"tickets"`
+	LastDigest time.Time `json:"-"`
+}
+
+// Email is opt in, nobody gets any until they turn it on.
+func DefaultPreferences(email string) *Preferences {
+	return &Preferences{
+		Email:   email,
+		Tickets: true,
+	}
+}
+
+// Wants filters out notifications the user has turned off.
+func (p *Preferences) Wants(notification *Notification) bool {
+	if notification.Reason == LowTickets || notification.Reason == SoldOut {
+		return p.Tickets
+	}
+	return true
+}
+
+// Digest users get everything at once, no more than once per interval.
+func (p *Preferences) DueAt(now time.Time) bool {
+	return !p.Digest || now.Sub(p.LastDigest) >= DigestInterval
+}
+
+// A Notifier delivers a batch of notifications over one channel. It should
+// do nothing if the recipient hasn't set that channel up.
+type Notifier interface {
+	// Names the channel so deliveries over it are tracked apart from others
+	Channel() string
+	Send(recipient *Preferences, notifications []*Notification) error
+}
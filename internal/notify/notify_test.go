@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		field, oldValue, newValue string
+		reason                    Reason
+		notable                   bool
+	}{
+		{"active", "true", "false", Cancelled, true},
+		{"active", "false", "true", "", false},
+		{"start_time", "2025-07-31T10:00:00-04:00", "2025-07-31T14:00:00-04:00", Rescheduled, true},
+		{"room_name", "101", "105", Moved, true},
+		{"table_number", "1", "2", "", false},
+		{"tickets_available", "6", "3", LowTickets, true},
+		{"tickets_available", "3", "2", "", false},
+		{"tickets_available", "2", "0", SoldOut, true},
+		{"tickets_available", "0", "4", "", false},
+	}
+	for _, tc := range cases {
+		reason, notable := Classify(&events.EventChange{Field: tc.field, OldValue: tc.oldValue, NewValue: tc.newValue})
+		if reason != tc.reason || notable != tc.notable {
+			t.Errorf("%v %v -> %v: expected %v %v, got %v %v",
+				tc.field, tc.oldValue, tc.newValue, tc.reason, tc.notable, reason, notable)
+		}
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	prefs := DefaultPreferences("a@example.com")
+	if prefs.SendEmail {
+		t.Errorf("Email should be opt in")
+	}
+	prefs.Tickets = false
+	if prefs.Wants(&Notification{Reason: SoldOut}) {
+		t.Errorf("Ticket notifications are turned off")
+	}
+	if !prefs.Wants(&Notification{Reason: Cancelled}) {
+		t.Errorf("Cancellations should always go out")
+	}
+
+	now := time.Now()
+	if !prefs.DueAt(now) {
+		t.Errorf("Non-digest users should always be due")
+	}
+	prefs.Digest = true
+	prefs.LastDigest = now.Add(-time.Hour)
+	if prefs.DueAt(now) {
+		t.Errorf("Digest sent an hour ago shouldn't be due")
+	}
+	prefs.LastDigest = now.Add(-DigestInterval)
+	if !prefs.DueAt(now) {
+		t.Errorf("Digest should be due after the interval")
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Posts notifications as json to the url each user configured.
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+// Carrier grade NAT, private in practice though net doesn't count it
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Anyone can set a webhook, so it mustn't reach anything only the server
+// can, like the database or the cloud metadata service.
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !sharedAddressSpace.Contains(ip)
+}
+
+// Checks the address being connected to, after DNS, so a public name that
+// resolves somewhere private is caught too.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("webhooks can't connect to %v", host)
+	}
+	return nil
+}
+
+// Only connects to public addresses, without following redirects or any
+// proxy from the environment.
+func NewWebhookNotifier() *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}
+	return &WebhookNotifier{Client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		// A redirect would get around the https check on the url
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+type webhookPayload struct {
+	Email         string          `json:"email"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return "webhook"
+}
+
+func (n *WebhookNotifier) Send(recipient *Preferences, notifications []*Notification) error {
+	if recipient.WebhookUrl == "" || len(notifications) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		Email:         recipient.Email,
+		Notifications: notifications,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(recipient.WebhookUrl, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %v returned %v", recipient.WebhookUrl, resp.Status)
+	}
+	return nil
+}
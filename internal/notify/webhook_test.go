@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	recipient := DefaultPreferences("player@example.com")
+	recipient.WebhookUrl = server.URL
+	// The test server is on loopback, which the real client won't reach
+	notifier := &WebhookNotifier{Client: server.Client()}
+	err := notifier.Send(recipient, []*Notification{
+		{EventId: "RPG25ND1", Reason: Cancelled, Message: "Cancelled"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Email != "player@example.com" || len(payload.Notifications) != 1 ||
+		payload.Notifications[0].Reason != Cancelled {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	recipient := DefaultPreferences("player@example.com")
+	recipient.WebhookUrl = server.URL
+	notifier := &WebhookNotifier{Client: server.Client()}
+	if err := notifier.Send(recipient, []*Notification{{}}); err == nil {
+		t.Errorf("Expected an error from a failing webhook")
+	}
+}
+
+func TestWebhookNotifierOnlyReachesPublicAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	recipient := DefaultPreferences("player@example.com")
+	recipient.WebhookUrl = server.URL
+	if err := NewWebhookNotifier().Send(recipient, []*Notification{{}}); err == nil || called {
+		t.Errorf("Expected loopback to be refused, got %v", err)
+	}
+
+	for _, address := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.1", "169.254.169.254", "100.64.0.1", "::1", "fe80::1", "fd00::1"} {
+		if isPublic(net.ParseIP(address)) {
+			t.Errorf("Expected %v to be refused", address)
+		}
+	}
+	for _, address := range []string{"8.8.8.8", "2606:4700:4700::1111"} {
+		if !isPublic(net.ParseIP(address)) {
+			t.Errorf("Expected %v to be allowed", address)
+		}
+	}
+}
+
+func TestWebhookNotifierDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	recipient := DefaultPreferences("player@example.com")
+	recipient.WebhookUrl = server.URL
+	notifier := NewWebhookNotifier()
+	// Let the test server through, the redirect still mustn't be followed
+	notifier.Client.Transport = server.Client().Transport
+	if err := notifier.Send(recipient, []*Notification{{}}); err == nil {
+		t.Errorf("Expected a redirect to be an error")
+	}
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+)
+
+// Calendar tokens let calendar apps fetch a user's starred events without
+// a signin cookie. Anyone with the token can read the feed, so they're long
+// and random, and users can reset them if the url leaks.
+func newCalendarToken() (string, error) {
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func LoadOrCreateCalendarToken(db *sql.DB, email string) (string, error) {
+	var token sql.NullString
+	err := db.QueryRow(`
+SELECT calendar_token FROM users WHERE email = $1`, email).Scan(&token)
+	if err != nil {
+		return "", err
+	}
+	if token.Valid && len(token.String) > 0 {
+		return token.String, nil
+	}
+
+	return ResetCalendarToken(db, email)
+}
+
+// Replaces the user's calendar token, which breaks any existing
+// subscriptions using the old one.
+func ResetCalendarToken(db *sql.DB, email string) (string, error) {
+	token, err := newCalendarToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(`
+UPDATE users SET calendar_token = $2 WHERE email = $1`, email, token)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Returns nil if no user has this token.
+func LoadUserByCalendarToken(db *sql.DB, token string) (*User, error) {
+	var user User
+	err := db.QueryRow(`
+SELECT
+		email,
+		CASE WHEN length(display_name) > 0
+            THEN display_name
+            ELSE split_part(email, '@', 1)
+            END
+FROM users
+WHERE calendar_token = $1
+`, token).Scan(&user.Email, &user.DisplayName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/lib/pq"
+)
+
+func scanChanges(rows *sql.Rows) ([]*events.EventChange, error) {
+	defer rows.Close()
+	changes := make([]*events.EventChange, 0)
+	for rows.Next() {
+		var change events.EventChange
+		var oldValue, newValue sql.NullString
+		err := rows.Scan(&change.EventId, &change.Changed, &change.Field, &oldValue, &newValue)
+		if err != nil {
+			return nil, err
+		}
+		change.OldValue = oldValue.String
+		change.NewValue = newValue.String
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// Changes to the events made after since, newest first. Pass the zero time
+// for the full history.
+func LoadEventChanges(db *sql.DB, eventIds []string, since time.Time) ([]*events.EventChange, error) {
+	rows, err := db.Query(`
+SELECT event_id, changed, field, old_value, new_value
+FROM event_changes
+WHERE event_id = ANY($1)
+  AND changed > $2
+ORDER BY changed DESC
+`, pq.Array(eventIds), since)
+	if err != nil {
+		return nil, err
+	}
+	return scanChanges(rows)
+}
+
+// Changes since the given time to anything on the user's starred list,
+// including events which have since been cancelled. Also returns the title
+// of each changed event, keyed by event id.
+func LoadStarredChanges(db *sql.DB, email string, year int, since time.Time) ([]*events.EventChange, map[string]string, error) {
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT e1.event_id, e1.title
+FROM events e1
+WHERE e1.year = $2
+  AND %s
+  AND EXISTS (SELECT 1 FROM event_changes c WHERE c.event_id = e1.event_id AND c.changed > $3)
+`, starredByCondition("$1")), email, year, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	titles := make(map[string]string)
+	eventIds := make([]string, 0)
+	for rows.Next() {
+		var eventId, title string
+		if err = rows.Scan(&eventId, &title); err != nil {
+			return nil, nil, err
+		}
+		titles[eventId] = title
+		eventIds = append(eventIds, eventId)
+	}
+	if len(eventIds) == 0 {
+		return []*events.EventChange{}, titles, nil
+	}
+
+	changes, err := LoadEventChanges(db, eventIds, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	return changes, titles, nil
+}
+
+// When the user last looked at the page, or the zero time if they never
+// have. Pages are free form keys, like "event:RPG25ND1".
+func LastVisit(db *sql.DB, email string, page string) (time.Time, error) {
+	var visited time.Time
+	err := db.QueryRow(`
+SELECT visited FROM user_visits WHERE email = $1 AND page = $2`, email, page).Scan(&visited)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return visited, err
+}
+
+func RecordVisit(db *sql.DB, email string, page string) error {
+	_, err := db.Exec(`
+INSERT INTO user_visits (email, page, visited)
+VALUES ($1, $2, now())
+ON CONFLICT (email, page)
+    DO UPDATE SET visited = now()
+`, email, page)
+	return err
+}
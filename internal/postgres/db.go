@@ -4,12 +4,15 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
-	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
 )
 
 var dbConnectString = flag.String("db", "", "postgres connect string")
 
-var INDIANAPOLIS, _ = time.LoadLocation("America/Indiana/Indianapolis")
+// The same location as events.Indianapolis, the database package has always
+// had its own name for it
+var INDIANAPOLIS = events.Indianapolis
 
 func OpenDb() (*sql.DB, error) {
 	fmt.Println("dbString", *dbConnectString)
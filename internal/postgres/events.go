@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/search"
 	"github.com/lib/pq"
 )
 
@@ -60,9 +61,10 @@ type EventGroup struct {
 }
 
 type ParsedQuery struct {
-	// TODO(alek): make a significantly more robust query parser
-	// add exact match on fields,
+	// Terms in websearch_to_tsquery syntax
 	TextQueries     []string
+	Filters         []search.Filter
+	Errors          []string
 	Year            int
 	DaysOfWeek      map[string]bool
 	RawQuery        string
@@ -82,6 +84,14 @@ type SearchQuery struct {
 	MinSatTickets     int
 	MinSunTickets     int
 	RawQuery          string
+	// Someone's age, 0 for any
+	Age int
+	// Unknown for any
+	MaxExperience events.ExperienceLevel
+	// Unknown for any
+	Registration events.Registration
+	// nil for any
+	MaxCostCents *int
 }
 
 func rowToGroup(rows *sql.Rows) (*EventGroup, error) {
@@ -114,9 +124,47 @@ func rowToGroup(rows *sql.Rows) (*EventGroup, error) {
 func SearchEvents(db *sql.DB, query SearchQuery) ([]*EventGroup, error) {
 	results := make([]*EventGroup, 0)
 
-	// Optional search terms should be incorporated into the WHERE clause as
-	// AND (<term was omitted> OR <apply term>)
-	rows, err := db.Query(`
+	// Optional search terms only make it into the WHERE clause when present
+	var b queryBuilder
+	where := b.where().and("active")
+	if len(query.CategoryShortCode) > 0 {
+		where.and("short_category = ?", query.CategoryShortCode)
+	}
+	if query.Year != 0 {
+		where.and("year = ?", query.Year)
+	}
+	minTickets := []struct {
+		dayOfWeek int
+		tickets   int
+	}{
+		{3, query.MinWedTickets},
+		{4, query.MinThuTickets},
+		{5, query.MinFriTickets},
+		{6, query.MinSatTickets},
+		{0, query.MinSunTickets},
+	}
+	for _, day := range minTickets {
+		if day.tickets != 0 {
+			where.and("day_of_week = ? AND tickets_available >= ?", day.dayOfWeek, day.tickets)
+		}
+	}
+	if len(query.RawQuery) > 0 {
+		where.and("search_key @@ websearch_to_tsquery('english', ?)", query.RawQuery)
+	}
+	if query.Age > 0 {
+		where.and(ageCondition(&b, query.Age))
+	}
+	if query.MaxExperience != events.UnknownExperience {
+		where.and("experience_level > 0 AND experience_level <= ?", query.MaxExperience)
+	}
+	if query.Registration != events.UnknownRegistration {
+		where.and("registration = ?", query.Registration)
+	}
+	if query.MaxCostCents != nil {
+		where.and("cost_cents <= ?", *query.MaxCostCents)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
 SELECT
 	MIN(e.event_id) AS anchor_event,
 	e.title, 
@@ -135,21 +183,10 @@ SELECT
 	0 as search_rank
 FROM
   events AS e
-WHERE
-	active
-  AND (LENGTH($1) = 0 OR short_category = $1)
-	AND ($2 = 0 OR year = $2)
-	AND ($3 = 0 OR (day_of_week = 3 AND tickets_available >= $3))
-	AND ($4 = 0 OR (day_of_week = 4 AND tickets_available >= $4))
-	AND ($5 = 0 OR (day_of_week = 5 AND tickets_available >= $5))
-	AND ($6 = 0 OR (day_of_week = 6 AND tickets_available >= $6))
-	AND ($7 = 0 OR (day_of_week = 0 AND tickets_available >= $7))
-	AND (LENGTH($8) = 0 OR (search_key @@ websearch_to_tsquery('english', $8)))
+WHERE %v
 GROUP BY
   cluster_key, short_description, short_category, game_system, org_group, title 
-	`, query.CategoryShortCode, query.Year, query.MinWedTickets,
-		query.MinThuTickets, query.MinFriTickets, query.MinSatTickets,
-		query.MinSunTickets, query.RawQuery)
+	`, where), b.args...)
 
 	if err != nil {
 		return nil, err
@@ -168,7 +205,10 @@ GROUP BY
 }
 
 func LoadEventGroupsForCategory(db *sql.DB, short_category string, year int) ([]*EventGroup, error) {
-	rows, err := db.Query(`
+	var b queryBuilder
+	innerWhere := b.where().and("active AND year = ? AND short_category = ?", year, short_category)
+	outerWhere := b.where().and("e.year = ?", year)
+	rows, err := db.Query(fmt.Sprintf(`
 SELECT 
 	e.event_id,
 	e.title,
@@ -200,11 +240,11 @@ FROM events e
 			sum(CASE WHEN day_of_week = 6 THEN tickets_available ELSE 0 END) as saturday_tickets,
 			sum(CASE WHEN day_of_week = 0 THEN tickets_available ELSE 0 END) as sunday_tickets	   
 		FROM events
-		WHERE active and year=$1 and short_category=$2
+		WHERE %v
 		GROUP BY cluster_key, short_category, title
 		) as c ON e.event_id = c.event_id
-WHERE e.year = $1
-ORDER BY c.tickets_available > 0 desc, title`, year, short_category)
+WHERE %v
+ORDER BY c.tickets_available > 0 desc, title`, innerWhere, outerWhere), b.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -328,30 +368,76 @@ func LoadSimilarEvents(db *sql.DB, eventId string, userEmail string) ([]*events.
 	return loadedEvents, nil
 }
 
-func FindEvents(db *sql.DB, query *ParsedQuery) ([]*EventGroup, error) {
+// The sessions of several clusters in one query, keyed by the event id each
+// cluster was asked for. Like LoadSimilarEvents, each cluster includes the
+// event itself.
+func LoadClusterSessions(db *sql.DB, eventIds []string, year int, userEmail string) (map[string][]*events.GenconEvent, error) {
+	fields := "e1." + strings.Join(eventFields(), ", e1.")
+	rows, err := db.Query(fmt.Sprintf(`
+	SELECT distinct %s, se.event_id is not null, o.id, e2.event_id
+	FROM events e1
+		 JOIN events e2 on e1.year = e2.year
+			  AND e1.short_category = e2.short_category
+			  AND e1.title = e2.title
+			  AND e1.cluster_key = e2.cluster_key
+		 LEFT JOIN starred_events se ON se.event_id = e1.event_id AND se.email = $3
+		 LEFT JOIN orgs o ON lower(o.alias) = lower(e1.org_group)
+	WHERE e2.event_id = ANY($1)
+	  AND e1.year = $2
+	ORDER BY e2.event_id, e1.start_time`, fields), pq.Array(eventIds), year, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make(map[string][]*events.GenconEvent)
+	for rows.Next() {
+		var clusterId string
+		event, err := scanEvent(rows, &clusterId)
+		if err != nil {
+			return nil, err
+		}
+		sessions[clusterId] = append(sessions[clusterId], events.NormalizeEvent(event))
+	}
+	return sessions, rows.Err()
+}
+
+// Only these day names make it into the SQL text
+var dayTicketColumns = map[string]string{
+	"wed": "c.wed_tickets",
+	"thu": "c.thu_tickets",
+	"fri": "c.fri_tickets",
+	"sat": "c.sat_tickets",
+	"sun": "c.sun_tickets",
+}
+
+func buildFindEventsQuery(query *ParsedQuery) (string, []interface{}) {
+	var b queryBuilder
 	innerFrom := "events"
-	innerWhere := fmt.Sprintf("active AND year = %v", query.Year)
+	innerWhere := b.where().and("active AND year = ?", query.Year)
 	if query.StartBeforeHour >= 0 {
-		innerWhere = fmt.Sprintf("%v AND EXTRACT(HOUR FROM start_time AT TIME ZONE 'EDT') <= %v", innerWhere, query.StartBeforeHour)
+		innerWhere.and("EXTRACT(HOUR FROM start_time AT TIME ZONE 'EDT') <= ?", query.StartBeforeHour)
 	}
 	if query.StartAfterHour >= 0 {
-		innerWhere = fmt.Sprintf("%v AND EXTRACT(HOUR FROM start_time AT TIME ZONE 'EDT') >= %v", innerWhere, query.StartAfterHour)
+		innerWhere.and("EXTRACT(HOUR FROM start_time AT TIME ZONE 'EDT') >= ?", query.StartAfterHour)
 	}
 	if query.EndBeforeHour >= 0 {
-		innerWhere = fmt.Sprintf("%v AND EXTRACT(HOUR FROM end_time AT TIME ZONE 'EDT') <= %v", innerWhere, query.EndBeforeHour)
+		innerWhere.and("EXTRACT(HOUR FROM end_time AT TIME ZONE 'EDT') <= ?", query.EndBeforeHour)
 	}
 	if query.EndAfterHour >= 0 {
-		innerWhere = fmt.Sprintf("%v AND EXTRACT(HOUR FROM end_time AT TIME ZONE 'EDT') >= %v", innerWhere, query.EndAfterHour)
+		innerWhere.and("EXTRACT(HOUR FROM end_time AT TIME ZONE 'EDT') >= ?", query.EndAfterHour)
+	}
+	for _, filter := range query.Filters {
+		innerWhere.and(compileFilter(filter, &b))
 	}
 
 	titleRank := "1"
 	searchRank := "1"
 
-	tsquery := strings.Join(query.TextQueries, " & ")
-	tsquery = strings.ReplaceAll(tsquery, "'", "")
+	tsquery := strings.Join(query.TextQueries, " ")
 	if len(tsquery) > 0 {
-		innerFrom = fmt.Sprintf("%v, websearch_to_tsquery('english', '%v') q", innerFrom, tsquery)
-		innerWhere = fmt.Sprintf("%v AND search_key @@ q", innerWhere)
+		innerFrom = b.bind("events, websearch_to_tsquery('english', ?) q", tsquery)
+		innerWhere.and("search_key @@ q")
 		titleRank = "min(ts_rank(title_tsv, q))"
 		searchRank = "min(ts_rank(search_key, q))"
 	}
@@ -377,22 +463,16 @@ WHERE %v
 GROUP BY cluster_key, short_category, title
 `, titleRank, searchRank, innerFrom, innerWhere)
 
-	// Default to true so we don't filter anything out
-	// if no days were requested
-	dayPart := "true"
-	if len(query.DaysOfWeek) > 0 {
-		var days []string
-		for d := range query.DaysOfWeek {
-			if query.DaysOfWeek[d] {
-				days = append(days, fmt.Sprintf("c.%v_tickets > 0", d))
-			}
+	// No days requested doesn't filter anything out
+	var days []string
+	for _, day := range []string{"wed", "thu", "fri", "sat", "sun"} {
+		if query.DaysOfWeek[day] {
+			days = append(days, dayTicketColumns[day]+" > 0")
 		}
-		dayPart = strings.Join(days, " OR ")
 	}
-	fullWhere := fmt.Sprintf("e.year = %v AND (%v)", query.Year, dayPart)
-
+	fullWhere := b.where().and("e.year = ?", query.Year).anyOf(days...)
 	if query.OrgId > 0 {
-		fullWhere = fmt.Sprintf("(%v) AND o.id = %v", fullWhere, query.OrgId)
+		fullWhere.and("o.id = ?", query.OrgId)
 	}
 
 	fullQuery := fmt.Sprintf(`
@@ -418,8 +498,13 @@ WHERE %v
 ORDER BY c.title_rank desc, c.search_rank desc, c.tickets_available desc
 `, innerQuery, fullWhere)
 
+	return fullQuery, b.args
+}
+
+func FindEvents(db *sql.DB, query *ParsedQuery) ([]*EventGroup, error) {
+	fullQuery, args := buildFindEventsQuery(query)
 	loadedEvents := make([]*EventGroup, 0)
-	rows, err := db.Query(fullQuery)
+	rows, err := db.Query(fullQuery, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -482,17 +567,12 @@ func bulkDelete(tx *sql.Tx, deletedEvents []string) error {
 		if len(deletedEvents) < batchSize {
 			batchSize = len(deletedEvents)
 		}
-		batch := make([]string, 0, batchSize)
-		for _, eventId := range deletedEvents[0:batchSize:batchSize] {
-			batch = append(batch, "'"+eventId+"'")
-		}
-
+		batch := deletedEvents[0:batchSize:batchSize]
 		deletedEvents = deletedEvents[batchSize:]
-		updateStatement := fmt.Sprintf(
-			"UPDATE events SET active = FALSE WHERE event_id in (%s)",
-			strings.Join(batch, ","))
 
-		_, err := tx.Exec(updateStatement)
+		_, err := tx.Exec(
+			"UPDATE events SET active = FALSE WHERE event_id = ANY($1)",
+			pq.Array(batch))
 
 		if err != nil {
 			log.Printf("Error on processing event: %s %v", batch, err.(pq.PGError))
@@ -502,8 +582,153 @@ func bulkDelete(tx *sql.Tx, deletedEvents []string) error {
 	return nil
 }
 
-func BulkUpdateEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent) error {
+// The stored version of every event in the year, so imports can tell what
+// changed.
+func loadPersistedEvents(tx *sql.Tx, year int) (map[string]*events.GenconEvent, error) {
+	rows, err := tx.Query(fmt.Sprintf(`
+SELECT %s, false, 0
+FROM events
+WHERE year = $1`, strings.Join(eventFields(), ", ")), year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	persisted := make(map[string]*events.GenconEvent)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		persisted[event.EventId] = event
+	}
+	return persisted, nil
+}
+
+func recordChanges(tx *sql.Tx, year int, changes []*events.EventChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	eventIds := make([]string, 0, len(changes))
+	changed := make([]string, 0, len(changes))
+	fields := make([]string, 0, len(changes))
+	oldValues := make([]string, 0, len(changes))
+	newValues := make([]string, 0, len(changes))
+	for _, change := range changes {
+		eventIds = append(eventIds, change.EventId)
+		changed = append(changed, change.Changed.Format(time.RFC3339Nano))
+		fields = append(fields, change.Field)
+		oldValues = append(oldValues, change.OldValue)
+		newValues = append(newValues, change.NewValue)
+	}
+
+	_, err := tx.Exec(`
+INSERT INTO event_changes (event_id, year, changed, field, old_value, new_value)
+SELECT c.event_id, $1, c.changed, c.field, c.old_value, c.new_value
+FROM unnest($2::text[], $3::timestamptz[], $4::text[], $5::text[], $6::text[])
+    AS c(event_id, changed, field, old_value, new_value)
+`, year, pq.Array(eventIds), pq.Array(changed), pq.Array(fields), pq.Array(oldValues), pq.Array(newValues))
+	return err
+}
+
+// Writes an import's events, returning what changed. Roll the transaction
+// back instead of committing for a dry run. With limits, an import that
+// would deactivate too much returns a *LimitError before writing anything.
+// The events must all be from one year, anything missing from them is
+// deactivated unless it's one of the rejected rows.
+func BulkUpdateEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent, rejected []*events.RowProblem, limits *ImportLimits) (*ImportReport, error) {
+	return updateYear(tx, parsedEvents, rejected, limits, true)
+}
+
+// Writes a past year's events from an archived file. Unlike a live import
+// there's no change history, ticket snapshots or notifications, which would
+// all be stamped with today.
+// With limits, a file that would deactivate too much of what's there returns
+// a *LimitError before writing anything.
+func BackfillEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent, rejected []*events.RowProblem, limits *ImportLimits) (*ImportReport, error) {
+	return updateYear(tx, parsedEvents, rejected, limits, false)
+}
+
+// Writes events from an archived snapshot of the live feed. Like a backfill
+// there's no change history, ticket snapshots or notifications, people were
+// told about those changes when the snapshot was first imported.
+func ReplayEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent, rejected []*events.RowProblem, limits *ImportLimits) (*ImportReport, error) {
+	return updateYear(tx, parsedEvents, rejected, limits, false)
+}
+
+// The active events missing from an import. A rejected row is still in the
+// feed, just unreadable, so its event is left as it is. When a rejected row
+// doesn't even have an id there's no telling which event it was, so nothing
+// is deactivated.
+func eventsToDeactivate(activeEvents map[string]time.Time, rejected []*events.RowProblem) []string {
+	kept := make(map[string]bool, len(rejected))
+	for _, problem := range rejected {
+		if problem.EventId == "" {
+			log.Printf("Not deactivating anything, %v", problem)
+			return []string{}
+		}
+		kept[problem.EventId] = true
+	}
+
+	deactivated := make([]string, 0, len(activeEvents))
+	for eventId := range activeEvents {
+		if !kept[eventId] {
+			deactivated = append(deactivated, eventId)
+		}
+	}
+	return deactivated
+}
+
+// What changed about each event in a live import, for the history and
+// notifications
+func importChanges(updatedEvents []*events.GenconEvent, deletedEvents []string,
+	persisted map[string]*events.GenconEvent, importTime time.Time) []*events.EventChange {
+	changes := make([]*events.EventChange, 0)
+	for _, updated := range updatedEvents {
+		if previous, found := persisted[updated.EventId]; found {
+			changes = append(changes, events.DiffEvents(previous, updated, importTime)...)
+		}
+	}
+	for _, eventId := range deletedEvents {
+		changes = append(changes, events.CancelledChange(eventId, importTime))
+	}
+	return changes
+}
+
+// The changes and ticket snapshots an import records, which notifications
+// are queued from. Only live imports have them, anything else would be
+// stamped with today.
+func importHistory(parsedEvents, updatedEvents []*events.GenconEvent, deletedEvents []string,
+	persisted map[string]*events.GenconEvent, importTime time.Time, live bool) ([]*events.EventChange, []*events.GenconEvent) {
+	if !live {
+		return nil, nil
+	}
+	changes := importChanges(updatedEvents, deletedEvents, persisted, importTime)
+
+	// Snapshots only when the count moves, otherwise every import would
+	// add a row for every event.
+	snapshotEvents := make([]*events.GenconEvent, 0)
+	for _, parsedEvent := range parsedEvents {
+		previous, found := persisted[parsedEvent.EventId]
+		if !found || previous.TicketsAvailable != parsedEvent.TicketsAvailable {
+			snapshotEvents = append(snapshotEvents, parsedEvent)
+		}
+	}
+	return changes, snapshotEvents
+}
+
+func updateYear(tx *sql.Tx, parsedEvents []*events.GenconEvent, rejected []*events.RowProblem, limits *ImportLimits, live bool) (*ImportReport, error) {
+	if len(parsedEvents) == 0 {
+		return nil, fmt.Errorf("no events to import")
+	}
 	year := parsedEvents[0].Year
+	for _, parsedEvent := range parsedEvents {
+		// Deactivating would go by the first event's year, so the rest of
+		// another year would be switched off
+		if parsedEvent.Year != year {
+			return nil, fmt.Errorf("events from %d and %d in one import, split them by year first", year, parsedEvent.Year)
+		}
+	}
 	activeEvents, inactiveEvents, err := loadEventIds(tx, year)
 	persistedEvents := make(map[string]time.Time, len(activeEvents)+len(inactiveEvents))
 	for id, updateTime := range activeEvents {
@@ -514,9 +739,10 @@ func BulkUpdateEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent) error {
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Printf("Loaded %d Rows\n", len(persistedEvents))
+	activeCount := len(activeEvents)
 
 	var newEvents []*events.GenconEvent
 	var updatedEvents []*events.GenconEvent
@@ -535,9 +761,12 @@ func BulkUpdateEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent) error {
 	}
 
 	// Any remaining active events should be deleted
-	deletedEvents := make([]string, 0, len(activeEvents))
-	for event := range activeEvents {
-		deletedEvents = append(deletedEvents, event)
+	deletedEvents := eventsToDeactivate(activeEvents, rejected)
+
+	if limits != nil {
+		if err = limits.Check(activeCount, len(parsedEvents), len(deletedEvents)); err != nil {
+			return nil, err
+		}
 	}
 
 	log.Printf("Inserting %d events\n", len(newEvents))
@@ -546,14 +775,46 @@ func BulkUpdateEvents(tx *sql.Tx, parsedEvents []*events.GenconEvent) error {
 
 	err = bulkInsert(tx, newEvents)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Diff before overwriting, so we keep a history of how events moved
+	persisted, err := loadPersistedEvents(tx, year)
+	if err != nil {
+		return nil, err
 	}
 	err = bulkUpdate(tx, updatedEvents)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	err = bulkDelete(tx, deletedEvents)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	err = supersedeHeldImports(tx, year)
+	if err != nil {
+		return nil, err
+	}
+	report := newImportReport(year, activeCount, newEvents, updatedEvents, deletedEvents, persisted)
+
+	importTime := time.Now()
+	changes, snapshotEvents := importHistory(parsedEvents, updatedEvents, deletedEvents, persisted, importTime, live)
+	log.Printf("Recording %d changes\n", len(changes))
+	log.Printf("Recording %d ticket snapshots\n", len(snapshotEvents))
+
+	err = recordChanges(tx, year, changes)
+	if err != nil {
+		return nil, err
+	}
+	err = recordTicketSnapshots(tx, snapshotEvents, importTime)
+	if err != nil {
+		return nil, err
+	}
+	err = queueNotifications(tx, changes)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
 }
 
 func rangeSlice(min, max int) []interface{} {
@@ -600,6 +861,14 @@ func eventFields() []string {
 		"tickets_available",
 		"last_modified",
 		"short_category",
+		"feed_game_system",
+		"min_age",
+		"max_age",
+		"experience_level",
+		"registration",
+		"cost_cents",
+		"building",
+		"room",
 	}
 }
 
@@ -640,13 +909,22 @@ func eventToDbFields(event *events.GenconEvent) []interface{} {
 		event.TicketsAvailable,
 		event.LastModified,
 		event.ShortCategory,
+		event.FeedGameSystem,
+		event.MinAge,
+		event.MaxAge,
+		event.ExperienceLevel,
+		event.Registration,
+		event.CostCents,
+		event.Building,
+		event.Room,
 	}
 }
 
-func scanEvent(row *sql.Rows) (*events.GenconEvent, error) {
+// Extra columns selected after the event's are scanned into extra
+func scanEvent(row *sql.Rows, extra ...interface{}) (*events.GenconEvent, error) {
 	var event events.GenconEvent
 
-	err := row.Scan(
+	dest := []interface{}{
 		&event.EventId,
 		&event.Year,
 		&event.Active,
@@ -681,8 +959,18 @@ func scanEvent(row *sql.Rows) (*events.GenconEvent, error) {
 		&event.TicketsAvailable,
 		&event.LastModified,
 		&event.ShortCategory,
+		&event.FeedGameSystem,
+		&event.MinAge,
+		&event.MaxAge,
+		&event.ExperienceLevel,
+		&event.Registration,
+		&event.CostCents,
+		&event.Building,
+		&event.Room,
 		&event.IsStarred,
-		&event.OrgId)
+		&event.OrgId,
+	}
+	err := row.Scan(append(dest, extra...)...)
 
 	event.StartTime = event.StartTime.In(INDIANAPOLIS)
 	event.EndTime = event.EndTime.In(INDIANAPOLIS)
@@ -701,11 +989,11 @@ func bulkUpdate(tx *sql.Tx, updatedRows []*events.GenconEvent) error {
 				"($%d"+strings.Repeat(", $%d", numEventFields-1)+")",
 				rangeSlice(1, numEventFields)...))
 		updateStatement := fmt.Sprintf(
-			"UPDATE events SET %s WHERE event_id='%s'",
+			"UPDATE events SET %s WHERE event_id=$%d",
 			updatedFields,
-			row.EventId)
+			numEventFields+1)
 
-		valueArgs := eventToDbFields(row)
+		valueArgs := append(eventToDbFields(row), row.EventId)
 		_, err := tx.Exec(updateStatement, valueArgs...)
 
 		if err != nil {
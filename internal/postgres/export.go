@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// Every event in a year, active or not, as records for cmd/export. Clusters
+// are named by their lowest event id, the same as starred clusters.
+func LoadEventRecords(db *sql.DB, year int) ([]*events.EventRecord, error) {
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT %s, false,
+       COALESCE((SELECT MIN(o.id) FROM orgs o WHERE lower(o.alias) = lower(e.org_group)), 0)
+FROM events e
+WHERE e.year = $1
+ORDER BY e.event_id`, "e."+strings.Join(eventFields(), ", e.")), year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*events.EventRecord, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, events.NewEventRecord(event))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	clusters, err := db.Query(`
+SELECT event_id, MIN(event_id) OVER (PARTITION BY short_category, title, cluster_key)
+FROM events
+WHERE year = $1`, year)
+	if err != nil {
+		return nil, err
+	}
+	defer clusters.Close()
+
+	clusterIds := make(map[string]string, len(records))
+	for clusters.Next() {
+		var eventId, clusterId string
+		if err = clusters.Scan(&eventId, &clusterId); err != nil {
+			return nil, err
+		}
+		clusterIds[eventId] = clusterId
+	}
+	for _, record := range records {
+		record.ClusterId = clusterIds[record.EventId]
+	}
+	return records, clusters.Err()
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/lib/pq"
+)
+
+type GameSystemAlias struct {
+	Alias      string
+	GameSystem string
+	Updated    time.Time
+}
+
+// How many active events in a year use a game system
+type GameSystemCount struct {
+	GameSystem string
+	NumEvents  int
+}
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadSystemAliases(q querier) (events.SystemAliases, error) {
+	rows, err := q.Query("SELECT alias, game_system FROM game_system_aliases")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(events.SystemAliases)
+	for rows.Next() {
+		var alias, gameSystem string
+		if err = rows.Scan(&alias, &gameSystem); err != nil {
+			return nil, err
+		}
+		aliases[alias] = gameSystem
+	}
+	return aliases, rows.Err()
+}
+
+// The aliases for the import to apply
+func LoadSystemAliases(db *sql.DB) (events.SystemAliases, error) {
+	return loadSystemAliases(db)
+}
+
+func LoadGameSystemAliases(db *sql.DB) ([]*GameSystemAlias, error) {
+	rows, err := db.Query(`
+SELECT alias, game_system, updated
+FROM game_system_aliases
+ORDER BY lower(alias)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make([]*GameSystemAlias, 0)
+	for rows.Next() {
+		alias := &GameSystemAlias{}
+		if err = rows.Scan(&alias.Alias, &alias.GameSystem, &alias.Updated); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// Adds or changes an alias and renames the events it applies to, returning
+// how many changed.
+func SaveGameSystemAlias(db *sql.DB, alias, gameSystem string) (changed int, err error) {
+	alias = strings.TrimSpace(alias)
+	gameSystem = strings.TrimSpace(gameSystem)
+	if alias == "" || gameSystem == "" {
+		return 0, fmt.Errorf("an alias needs both names")
+	}
+	if alias == gameSystem {
+		return 0, fmt.Errorf("%q can't be an alias for itself", alias)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	// Aliases aren't followed in chains, so an alias of an alias goes
+	// straight to the end, and anything pointing at this alias is moved too
+	var target string
+	err = tx.QueryRow("SELECT game_system FROM game_system_aliases WHERE alias = $1", gameSystem).Scan(&target)
+	if err == nil {
+		if target == alias {
+			err = fmt.Errorf("%q is already an alias of %q", gameSystem, alias)
+			return 0, err
+		}
+		gameSystem = target
+	} else if err != sql.ErrNoRows {
+		return 0, err
+	}
+	feedSystems := []string{alias}
+	rows, err := tx.Query(`
+UPDATE game_system_aliases SET game_system = $2, updated = now()
+WHERE game_system = $1
+RETURNING alias`, alias, gameSystem)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var redirected string
+		if err = rows.Scan(&redirected); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		feedSystems = append(feedSystems, redirected)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO game_system_aliases (alias, game_system) VALUES ($1, $2)
+ON CONFLICT (alias) DO UPDATE SET game_system = excluded.game_system, updated = now()`,
+		alias, gameSystem)
+	if err != nil {
+		return 0, err
+	}
+	return reapplySystemAliases(tx, feedSystems)
+}
+
+// Removes an alias, putting its events back to the feed's name
+func DeleteGameSystemAlias(db *sql.DB, alias string) (changed int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	if _, err = tx.Exec("DELETE FROM game_system_aliases WHERE alias = $1", alias); err != nil {
+		return 0, err
+	}
+	return reapplySystemAliases(tx, []string{alias})
+}
+
+// Renames every event gencon lists under these game system names with the
+// current aliases. Events stored before the feed's name was kept are matched
+// on their game system.
+func reapplySystemAliases(tx *sql.Tx, feedSystems []string) (int, error) {
+	aliases, err := loadSystemAliases(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`
+SELECT %s, false, 0
+FROM events
+WHERE feed_game_system = ANY($1)
+   OR (feed_game_system = '' AND game_system = ANY($1))`, strings.Join(eventFields(), ", ")), pq.Array(feedSystems))
+	if err != nil {
+		return 0, err
+	}
+	affected := make([]*events.GenconEvent, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		affected = append(affected, event)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, event := range affected {
+		if !aliases.Apply(event) {
+			continue
+		}
+		_, err = tx.Exec(`
+UPDATE events SET game_system = $2, rules_edition = $3, feed_game_system = $4
+WHERE event_id = $1`, event.EventId, event.GameSystem, event.RulesEdition, event.FeedGameSystem)
+		if err != nil {
+			return 0, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// Game systems of the year's active events, most used first
+func LoadGameSystemCounts(db *sql.DB, year int) ([]*GameSystemCount, error) {
+	rows, err := db.Query(`
+SELECT game_system, count(1)
+FROM events
+WHERE year = $1 AND active AND game_system <> ''
+GROUP BY game_system
+ORDER BY count(1) DESC, game_system`, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]*GameSystemCount, 0)
+	for rows.Next() {
+		count := &GameSystemCount{}
+		if err = rows.Scan(&count.GameSystem, &count.NumEvents); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/lib/pq"
+)
+
+// Guards against a truncated feed cancelling everything that's missing from
+// it. Imports past either limit are held for someone to approve.
+type ImportLimits struct {
+	// Most of the active events one import may deactivate
+	MaxDeactivatedPercent float64
+	// Fewest events the feed may have, against what's active now
+	MinRowPercent float64
+}
+
+var DefaultImportLimits = ImportLimits{
+	MaxDeactivatedPercent: 10,
+	MinRowPercent:         90,
+}
+
+// Returned by BulkUpdateEvents when an import is past its limits, nothing
+// has been written.
+type LimitError struct {
+	Reason string
+}
+
+func (e *LimitError) Error() string {
+	return "import exceeds limits: " + e.Reason
+}
+
+// Nil when the import is within limits. Nothing is active before the first
+// import of a year, so there's nothing to compare against.
+func (l *ImportLimits) Check(active, feedRows, deactivated int) error {
+	if active == 0 {
+		return nil
+	}
+	deactivatedPercent := 100 * float64(deactivated) / float64(active)
+	if deactivatedPercent > l.MaxDeactivatedPercent {
+		return &LimitError{fmt.Sprintf(
+			"would deactivate %d of %d active events (%.1f%%, limit %.1f%%)",
+			deactivated, active, deactivatedPercent, l.MaxDeactivatedPercent)}
+	}
+	rowPercent := 100 * float64(feedRows) / float64(active)
+	if rowPercent < l.MinRowPercent {
+		return &LimitError{fmt.Sprintf(
+			"feed has %d events against %d active (%.1f%%, minimum %.1f%%)",
+			feedRows, active, rowPercent, l.MinRowPercent)}
+	}
+	return nil
+}
+
+const (
+	HeldStatus       = "held"
+	ApprovedStatus   = "approved"
+	RejectedStatus   = "rejected"
+	SupersededStatus = "superseded"
+)
+
+type HeldImport struct {
+	Id         int64
+	Year       int
+	Source     string
+	Reason     string
+	EventCount int
+	Status     string
+	Created    time.Time
+	Resolved   pq.NullTime
+}
+
+// Saves an import that went past its limits, returning its id. The rejected
+// rows are kept so approving it later doesn't deactivate their events. A feed
+// that's stuck keeps failing the same way every run, so when it's the same as
+// the newest import held for the year that one's id is returned instead.
+func HoldImport(db *sql.DB, year int, source string, reason string,
+	parsedEvents []*events.GenconEvent, rejected []*events.RowProblem) (int64, error) {
+	encoded, err := json.Marshal(parsedEvents)
+	if err != nil {
+		return 0, err
+	}
+	if rejected == nil {
+		rejected = []*events.RowProblem{}
+	}
+	encodedRejected, err := json.Marshal(rejected)
+	if err != nil {
+		return 0, err
+	}
+	sum := sha256.Sum256(append(encoded, encodedRejected...))
+	checksum := hex.EncodeToString(sum[:])
+
+	var id int64
+	var newestChecksum string
+	err = db.QueryRow(`
+SELECT id, checksum
+FROM held_imports
+WHERE year = $1 AND status = $2
+ORDER BY created DESC
+LIMIT 1`, year, HeldStatus).Scan(&id, &newestChecksum)
+	if err == nil && newestChecksum == checksum {
+		return id, nil
+	} else if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	err = db.QueryRow(`
+INSERT INTO held_imports (year, source, reason, event_count, events, rejected, checksum)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id`, year, source, reason, len(parsedEvents), encoded, encodedRejected, checksum).Scan(&id)
+	return id, err
+}
+
+// Held imports still waiting on a decision, oldest first
+func LoadHeldImports(db *sql.DB) ([]*HeldImport, error) {
+	rows, err := db.Query(`
+SELECT id, year, source, reason, event_count, status, created, resolved
+FROM held_imports
+WHERE status = $1
+ORDER BY created`, HeldStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	held := make([]*HeldImport, 0)
+	for rows.Next() {
+		var h HeldImport
+		if err = rows.Scan(&h.Id, &h.Year, &h.Source, &h.Reason, &h.EventCount, &h.Status, &h.Created, &h.Resolved); err != nil {
+			return nil, err
+		}
+		held = append(held, &h)
+	}
+	return held, rows.Err()
+}
+
+func resolveHeldImport(tx *sql.Tx, id int64, status string) error {
+	_, err := tx.Exec(`
+UPDATE held_imports
+SET status = $2, resolved = now()
+WHERE id = $1`, id, status)
+	return err
+}
+
+// Older holds for the year are stale once newer events have been written,
+// approving one would roll the events back.
+func supersedeHeldImports(tx *sql.Tx, year int) error {
+	_, err := tx.Exec(`
+UPDATE held_imports
+SET status = $2, resolved = now()
+WHERE year = $1 AND status = $3`, year, SupersededStatus, HeldStatus)
+	return err
+}
+
+// Locks a held import, failing if it's already been dealt with
+func loadHeldImportEvents(tx *sql.Tx, id int64) ([]*events.GenconEvent, []*events.RowProblem, error) {
+	var status string
+	var encoded, encodedRejected []byte
+	err := tx.QueryRow(`
+SELECT status, events, rejected
+FROM held_imports
+WHERE id = $1
+FOR UPDATE`, id).Scan(&status, &encoded, &encodedRejected)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("no held import %d", id)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if status != HeldStatus {
+		return nil, nil, fmt.Errorf("import %d was already %s", id, status)
+	}
+
+	var parsedEvents []*events.GenconEvent
+	if err = json.Unmarshal(encoded, &parsedEvents); err != nil {
+		return nil, nil, err
+	}
+	var rejected []*events.RowProblem
+	if err = json.Unmarshal(encodedRejected, &rejected); err != nil {
+		return nil, nil, err
+	}
+	return parsedEvents, rejected, nil
+}
+
+// Applies a held import without checking limits, someone's looked at it.
+func ApproveHeldImport(db *sql.DB, id int64) (report *ImportReport, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	parsedEvents, rejected, err := loadHeldImportEvents(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	report, err = BulkUpdateEvents(tx, parsedEvents, rejected, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = resolveHeldImport(tx, id, ApprovedStatus); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func RejectHeldImport(db *sql.DB, id int64) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	if _, _, err = loadHeldImportEvents(tx, id); err != nil {
+		return err
+	}
+	return resolveHeldImport(tx, id, RejectedStatus)
+}
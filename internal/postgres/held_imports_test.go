@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImportLimits(t *testing.T) {
+	limits := ImportLimits{MaxDeactivatedPercent: 10, MinRowPercent: 90}
+	for _, test := range []struct {
+		name                         string
+		active, feedRows, deactivate int
+		reason                       string
+	}{
+		{"first import of the year", 0, 12000, 0, ""},
+		{"normal churn", 12000, 12100, 150, ""},
+		{"right at the limits", 1000, 900, 100, ""},
+		{"truncated feed", 12000, 3000, 9000, "would deactivate 9000 of 12000"},
+		{"renumbered events", 1000, 1000, 500, "would deactivate 500 of 1000"},
+		// Few deactivated but the feed shrank, say most events moved years
+		{"short feed", 1000, 500, 0, "feed has 500 events against 1000 active"},
+	} {
+		err := limits.Check(test.active, test.feedRows, test.deactivate)
+		if test.reason == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Errorf("%s: expected a limit error, got %v", test.name, err)
+			continue
+		}
+		if !strings.Contains(limitErr.Reason, test.reason) {
+			t.Errorf("%s: expected %q in %q", test.name, test.reason, limitErr.Reason)
+		}
+	}
+}
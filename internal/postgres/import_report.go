@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// One column of an event that an import changed
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+type ReportedEvent struct {
+	EventId string        `json:"eventId"`
+	Title   string        `json:"title"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// What an import did, or would do in a dry run, to the events table
+type ImportReport struct {
+	Year   int  `json:"year"`
+	DryRun bool `json:"dryRun"`
+	// Active events before the import
+	Active      int              `json:"active"`
+	New         []*ReportedEvent `json:"new"`
+	Updated     []*ReportedEvent `json:"updated"`
+	Unchanged   int              `json:"unchanged"`
+	Deactivated []*ReportedEvent `json:"deactivated"`
+}
+
+func formatDbValue(value interface{}) string {
+	if t, isTime := value.(time.Time); isTime {
+		return t.In(INDIANAPOLIS).Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// Every stored column that differs, not just the ones tracked for history,
+// so a suspicious feed shows everything it would touch.
+func fieldChanges(previous, current *events.GenconEvent) []FieldChange {
+	fields := eventFields()
+	oldValues := eventToDbFields(previous)
+	newValues := eventToDbFields(current)
+
+	changes := make([]FieldChange, 0)
+	for i, field := range fields {
+		oldTime, oldIsTime := oldValues[i].(time.Time)
+		newTime, newIsTime := newValues[i].(time.Time)
+		if oldIsTime && newIsTime && oldTime.Equal(newTime) {
+			continue
+		}
+		oldValue := formatDbValue(oldValues[i])
+		newValue := formatDbValue(newValues[i])
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return changes
+}
+
+func sortReported(reported []*ReportedEvent) {
+	sort.Slice(reported, func(i, j int) bool {
+		return reported[i].EventId < reported[j].EventId
+	})
+}
+
+func newImportReport(year int, active int, newEvents, updatedEvents []*events.GenconEvent, deletedEvents []string, persisted map[string]*events.GenconEvent) *ImportReport {
+	report := &ImportReport{
+		Year:        year,
+		Active:      active,
+		New:         make([]*ReportedEvent, 0, len(newEvents)),
+		Updated:     make([]*ReportedEvent, 0),
+		Deactivated: make([]*ReportedEvent, 0, len(deletedEvents)),
+	}
+	for _, event := range newEvents {
+		report.New = append(report.New, &ReportedEvent{EventId: event.EventId, Title: event.Title})
+	}
+	for _, event := range updatedEvents {
+		previous, found := persisted[event.EventId]
+		if !found {
+			continue
+		}
+		changes := fieldChanges(previous, event)
+		if len(changes) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Updated = append(report.Updated, &ReportedEvent{EventId: event.EventId, Title: event.Title, Changes: changes})
+	}
+	for _, eventId := range deletedEvents {
+		reported := &ReportedEvent{EventId: eventId}
+		if previous, found := persisted[eventId]; found {
+			reported.Title = previous.Title
+		}
+		report.Deactivated = append(report.Deactivated, reported)
+	}
+
+	sortReported(report.New)
+	sortReported(report.Updated)
+	sortReported(report.Deactivated)
+	return report
+}
+
+// Long values like descriptions are cut down so the report stays readable
+func truncateValue(value string) string {
+	runes := []rune(strings.Join(strings.Fields(value), " "))
+	if len(runes) > 60 {
+		return string(runes[:57]) + "..."
+	}
+	return string(runes)
+}
+
+func (r *ImportReport) String() string {
+	var report strings.Builder
+	action := "Imported"
+	if r.DryRun {
+		action = "Dry run, would import"
+	}
+	fmt.Fprintf(&report, "%s %d: %d new, %d updated, %d unchanged, %d deactivated\n",
+		action, r.Year, len(r.New), len(r.Updated), r.Unchanged, len(r.Deactivated))
+
+	if len(r.New) > 0 {
+		report.WriteString("\nNew:\n")
+		for _, event := range r.New {
+			fmt.Fprintf(&report, "  %s %s\n", event.EventId, event.Title)
+		}
+	}
+	if len(r.Updated) > 0 {
+		report.WriteString("\nUpdated:\n")
+		for _, event := range r.Updated {
+			fmt.Fprintf(&report, "  %s %s\n", event.EventId, event.Title)
+			for _, change := range event.Changes {
+				fmt.Fprintf(&report, "    %s: %q -> %q\n", change.Field, truncateValue(change.OldValue), truncateValue(change.NewValue))
+			}
+		}
+	}
+	if len(r.Deactivated) > 0 {
+		report.WriteString("\nDeactivated:\n")
+		for _, event := range r.Deactivated {
+			fmt.Fprintf(&report, "  %s %s\n", event.EventId, event.Title)
+		}
+	}
+	return report.String()
+}
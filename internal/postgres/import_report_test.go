@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/notify"
+)
+
+func reportEvent(eventId, title string, tickets int) *events.GenconEvent {
+	start := time.Date(2024, 8, 1, 10, 0, 0, 0, INDIANAPOLIS)
+	return &events.GenconEvent{
+		EventId:          eventId,
+		Year:             2024,
+		Active:           true,
+		Title:            title,
+		StartTime:        start,
+		EndTime:          start.Add(2 * time.Hour),
+		TicketsAvailable: tickets,
+	}
+}
+
+func TestFieldChanges(t *testing.T) {
+	previous := reportEvent("RPG24ND1", "Dungeon Delve", 6)
+	current := reportEvent("RPG24ND1", "Dungeon Delve", 0)
+	// Same instant in another zone isn't a change
+	current.StartTime = current.StartTime.UTC()
+	current.LongDescription = "Now with dragons"
+
+	changes := fieldChanges(previous, current)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if changes[0] != (FieldChange{"long_description", "", "Now with dragons"}) {
+		t.Errorf("unexpected change: %v", changes[0])
+	}
+	if changes[1] != (FieldChange{"tickets_available", "6", "0"}) {
+		t.Errorf("unexpected change: %v", changes[1])
+	}
+}
+
+func TestNewImportReport(t *testing.T) {
+	persisted := map[string]*events.GenconEvent{
+		"RPG24ND1": reportEvent("RPG24ND1", "Dungeon Delve", 6),
+		"RPG24ND2": reportEvent("RPG24ND2", "Same Old", 4),
+		"RPG24ND3": reportEvent("RPG24ND3", "Cancelled", 4),
+	}
+	moved := reportEvent("RPG24ND1", "Dungeon Delve", 6)
+	moved.StartTime = moved.StartTime.Add(time.Hour)
+
+	report := newImportReport(2024, 3,
+		[]*events.GenconEvent{reportEvent("RPG24ND5", "Brand New", 8), reportEvent("RPG24ND4", "Also New", 8)},
+		[]*events.GenconEvent{moved, reportEvent("RPG24ND2", "Same Old", 4)},
+		[]string{"RPG24ND3"},
+		persisted)
+
+	if len(report.New) != 2 || report.New[0].EventId != "RPG24ND4" {
+		t.Errorf("expected new events sorted by id, got %v", report.New)
+	}
+	if len(report.Updated) != 1 || report.Unchanged != 1 {
+		t.Fatalf("expected 1 updated and 1 unchanged, got %v and %d", report.Updated, report.Unchanged)
+	}
+	if changes := report.Updated[0].Changes; len(changes) != 1 || changes[0].Field != "start_time" {
+		t.Errorf("expected only the start time to change, got %v", changes)
+	}
+	if len(report.Deactivated) != 1 || report.Deactivated[0].Title != "Cancelled" {
+		t.Errorf("expected the cancelled event with its title, got %v", report.Deactivated)
+	}
+
+	report.DryRun = true
+	text := report.String()
+	for _, want := range []string{
+		"Dry run, would import 2024: 2 new, 1 updated, 1 unchanged, 1 deactivated",
+		"RPG24ND5 Brand New",
+		`start_time: "2024-08-01T10:00:00-04:00" -> "2024-08-01T11:00:00-04:00"`,
+		"Deactivated:\n  RPG24ND3 Cancelled",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in report:\n%s", want, text)
+		}
+	}
+
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"dryRun":true`, `"field":"start_time"`, `"unchanged":1`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("expected %s in %s", want, encoded)
+		}
+	}
+}
+
+func TestTruncateValue(t *testing.T) {
+	long := strings.Repeat("é", 100)
+	if got := truncateValue(long); len([]rune(got)) != 60 || !strings.HasSuffix(got, "...") {
+		t.Errorf("expected 60 characters ending in ..., got %q", got)
+	}
+	if got := truncateValue("two\n  lines"); got != "two lines" {
+		t.Errorf("expected whitespace collapsed, got %q", got)
+	}
+}
+
+func TestBulkUpdateEventsRejectsMixedYears(t *testing.T) {
+	lastYear := reportEvent("RPG23ND1", "Old", 4)
+	lastYear.Year = 2023
+	// Caught before anything is read, so there's no need for a database
+	_, err := BulkUpdateEvents(nil, []*events.GenconEvent{reportEvent("RPG24ND1", "New", 4), lastYear}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "2024 and 2023") {
+		t.Errorf("expected a mixed year error, got %v", err)
+	}
+	if _, err = BackfillEvents(nil, nil, nil, nil); err == nil {
+		t.Error("expected an error for no events")
+	}
+}
+
+func TestReplayQueuesNoNotifications(t *testing.T) {
+	persisted := map[string]*events.GenconEvent{
+		"RPG24ND1": reportEvent("RPG24ND1", "Dungeon Delve", 6),
+		"RPG24ND2": reportEvent("RPG24ND2", "Cancelled", 4),
+	}
+	parsed := []*events.GenconEvent{reportEvent("RPG24ND1", "Dungeon Delve", 0)}
+	deactivated := []string{"RPG24ND2"}
+
+	changes, snapshots := importHistory(parsed, parsed, deactivated, persisted, time.Now(), true)
+	if len(changes) != 2 || len(snapshots) != 1 {
+		t.Fatalf("expected a live import to record the sell out and the cancellation, got %v and %v", changes, snapshots)
+	}
+
+	changes, snapshots = importHistory(parsed, parsed, deactivated, persisted, time.Now(), false)
+	if len(changes) != 0 || len(snapshots) != 0 {
+		t.Errorf("expected a replay to record nothing, got %v and %v", changes, snapshots)
+	}
+	// With nothing to queue the transaction isn't touched, there's no database
+	if err := queueNotifications(nil, changes); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRejectedRowsStayActive(t *testing.T) {
+	now := time.Now()
+	// Active events the feed didn't have. RPG24ND2 is still in it with a bad
+	// start time, RPG24ND3 is gone.
+	active := map[string]time.Time{"RPG24ND2": now, "RPG24ND3": now}
+	rejected := []*events.RowProblem{{Row: 3, EventId: "RPG24ND2", Column: "Start Date & Time", Reason: "isn't a time"}}
+
+	deactivated := eventsToDeactivate(active, rejected)
+	if len(deactivated) != 1 || deactivated[0] != "RPG24ND3" {
+		t.Fatalf("expected only RPG24ND3 deactivated, got %v", deactivated)
+	}
+
+	persisted := map[string]*events.GenconEvent{
+		"RPG24ND1": reportEvent("RPG24ND1", "Dungeon Delve", 6),
+		"RPG24ND2": reportEvent("RPG24ND2", "Dungeon Delve", 6),
+	}
+	changes := importChanges([]*events.GenconEvent{reportEvent("RPG24ND1", "Dungeon Delve", 6)}, deactivated, persisted, now)
+	for _, change := range changes {
+		if reason, notable := notify.Classify(change); notable && change.EventId == "RPG24ND2" {
+			t.Errorf("expected no notification for the rejected row, got %v", reason)
+		}
+	}
+	if len(changes) != 1 || changes[0].EventId != "RPG24ND3" {
+		t.Errorf("expected only RPG24ND3 cancelled, got %v", changes)
+	}
+}
+
+func TestRejectedRowWithoutIdDeactivatesNothing(t *testing.T) {
+	active := map[string]time.Time{"RPG24ND1": time.Now()}
+	rejected := []*events.RowProblem{{Row: 3, Column: "Game ID", Reason: "is missing"}}
+	if deactivated := eventsToDeactivate(active, rejected); len(deactivated) != 0 {
+		t.Errorf("expected nothing deactivated, got %v", deactivated)
+	}
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	RunningStatus   = "running"
+	SucceededStatus = "succeeded"
+	// The feed hadn't changed, the data is as fresh as it can be
+	UnchangedStatus = "unchanged"
+	DryRunStatus    = "dry_run"
+	FailedStatus    = "failed"
+	// HeldStatus is shared with held imports
+)
+
+// One run of the importer, kept so we can tell when the data was last
+// refreshed and whether the last run went wrong.
+type ImportRun struct {
+	Id     int64  `json:"id"`
+	Source string `json:"source"`
+	// The year of the events written, 0 if none were
+	Year         int        `json:"year"`
+	Started      time.Time  `json:"started"`
+	Finished     *time.Time `json:"finished"`
+	BytesFetched int64      `json:"bytesFetched"`
+	ParsedRows   int        `json:"parsedRows"`
+	RejectedRows int        `json:"rejectedRows"`
+	Inserted     int        `json:"inserted"`
+	Updated      int        `json:"updated"`
+	Deactivated  int        `json:"deactivated"`
+	Warnings     []string   `json:"warnings"`
+	Status       string     `json:"status"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Records that a run started, so one that never finishes still shows up
+func StartImportRun(db *sql.DB, source string) (*ImportRun, error) {
+	run := &ImportRun{Source: source, Status: RunningStatus, Warnings: make([]string, 0)}
+	row := db.QueryRow(`
+INSERT INTO import_runs (source, status) VALUES ($1, $2)
+RETURNING id, started`, source, run.Status)
+	if err := row.Scan(&run.Id, &run.Started); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func FinishImportRun(db *sql.DB, run *ImportRun) error {
+	finished := time.Now()
+	run.Finished = &finished
+	if run.Warnings == nil {
+		run.Warnings = make([]string, 0)
+	}
+	_, err := db.Exec(`
+UPDATE import_runs
+SET finished = $2, bytes_fetched = $3, parsed_rows = $4, rejected_rows = $5,
+    inserted = $6, updated = $7, deactivated = $8, warnings = $9, status = $10, error = $11,
+    year = NULLIF($12, 0)
+WHERE id = $1`,
+		run.Id, finished, run.BytesFetched, run.ParsedRows, run.RejectedRows,
+		run.Inserted, run.Updated, run.Deactivated, pq.Array(run.Warnings), run.Status, run.Error, run.Year)
+	return err
+}
+
+// The most recent runs, newest first
+func LoadImportRuns(db *sql.DB, limit int) ([]*ImportRun, error) {
+	rows, err := db.Query(`
+SELECT id, source, coalesce(year, 0), started, finished, bytes_fetched, parsed_rows, rejected_rows,
+       inserted, updated, deactivated, warnings, status, error
+FROM import_runs
+ORDER BY started DESC
+LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*ImportRun, 0)
+	for rows.Next() {
+		run := &ImportRun{}
+		var finished pq.NullTime
+		err = rows.Scan(&run.Id, &run.Source, &run.Year, &run.Started, &finished, &run.BytesFetched,
+			&run.ParsedRows, &run.RejectedRows, &run.Inserted, &run.Updated, &run.Deactivated,
+			pq.Array(&run.Warnings), &run.Status, &run.Error)
+		if err != nil {
+			return nil, err
+		}
+		if finished.Valid {
+			run.Finished = &finished.Time
+		}
+		if run.Warnings == nil {
+			run.Warnings = make([]string, 0)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// When the last run that wrote the feed, or found it unchanged, finished.
+// Nil if there hasn't been one.
+func LastRefreshed(db *sql.DB) (*time.Time, error) {
+	row := db.QueryRow(`
+SELECT max(finished)
+FROM import_runs
+WHERE status IN ($1, $2)`, SucceededStatus, UnchangedStatus)
+	var finished pq.NullTime
+	if err := row.Scan(&finished); err != nil {
+		return nil, err
+	}
+	if !finished.Valid {
+		return nil, nil
+	}
+	return &finished.Time, nil
+}
+
+// The newest year a successful import has written, 0 if the ledger doesn't
+// have one. Backfills aren't in the ledger, so this is the live year.
+func NewestLiveYear(db *sql.DB) (int, error) {
+	var year int
+	err := db.QueryRow(`
+SELECT coalesce(max(year), 0)
+FROM import_runs
+WHERE status = $1`, SucceededStatus).Scan(&year)
+	return year, err
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/Encinarus/genconplanner/internal/solver"
+)
+
+// Clusters the user hasn't ranked are treated as nice to have
+const DefaultClusterPriority = 1
+
+func LoadClusterPriorities(db *sql.DB, email string, year int) (map[string]int, error) {
+	rows, err := db.Query(`
+SELECT cluster_id, priority
+FROM cluster_priorities
+WHERE email = $1 AND year = $2`, email, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	priorities := make(map[string]int)
+	for rows.Next() {
+		var clusterId string
+		var priority int
+		if err = rows.Scan(&clusterId, &priority); err != nil {
+			return nil, err
+		}
+		priorities[clusterId] = priority
+	}
+	return priorities, nil
+}
+
+func SetClusterPriority(db *sql.DB, email string, year int, clusterId string, priority int) error {
+	_, err := db.Exec(`
+INSERT INTO cluster_priorities (email, year, cluster_id, priority)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (email, cluster_id)
+    DO UPDATE SET priority = $4, year = $2
+`, email, year, clusterId, priority)
+	return err
+}
+
+// Every starred cluster with all of its sessions, ready to hand to the
+// solver. Individually starred events count as a cluster too, the solver is
+// free to move them to a sibling session.
+func LoadItineraryClusters(db *sql.DB, email string, year int) ([]*solver.Cluster, error) {
+	starredInfo, err := LoadStarredInfo(db, email, year)
+	if err != nil {
+		return nil, err
+	}
+	priorities, err := LoadClusterPriorities(db, email, year)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIds := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, info := range starredInfo {
+		if !seen[info.ClusterId] {
+			seen[info.ClusterId] = true
+			clusterIds = append(clusterIds, info.ClusterId)
+		}
+	}
+	sort.Strings(clusterIds)
+
+	sessionsById, err := LoadClusterSessions(db, clusterIds, year, email)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]*solver.Cluster, 0, len(clusterIds))
+	for _, clusterId := range clusterIds {
+		sessions := sessionsById[clusterId]
+		if len(sessions) == 0 {
+			continue
+		}
+
+		priority, found := priorities[clusterId]
+		if !found {
+			priority = DefaultClusterPriority
+		}
+		clusters = append(clusters, &solver.Cluster{
+			Id:       clusterId,
+			Title:    sessions[0].Title,
+			Priority: priority,
+			Sessions: sessions,
+		})
+	}
+	return clusters, nil
+}
+
+// Replaces the stars on each picked session's cluster with an event level
+// star on just that session. Clusters without a pick are left alone.
+func AcceptItinerary(db *sql.DB, email string, pickedEventIds []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	for _, eventId := range pickedEventIds {
+		_, err = tx.Exec(`
+DELETE FROM starred_events s
+WHERE s.email = $1
+  AND s.event_id in (
+	  SELECT e2.event_id
+	  FROM events e1 join events e2 on e1.year = e2.year
+          AND e1.short_category = e2.short_category
+	      AND e1.title = e2.title
+          AND e1.cluster_key = e2.cluster_key
+	  WHERE e1.event_id = $2
+  )
+`, email, eventId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+INSERT INTO starred_events(email, event_id, level)
+VALUES ($1, $2, 'event')
+ON CONFLICT DO NOTHING
+`, email, eventId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
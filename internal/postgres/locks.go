@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Advisory lock key for importing events, "gencon" in ascii
+const importLockKey int64 = 0x67656e636f6e
+
+// Advisory lock key for delivering notifications, "notify" in ascii
+const notifyLockKey int64 = 0x6e6f74696679
+
+// Held while importing or delivering so only one process does it at a time.
+// Advisory locks belong to a session, so this keeps a connection out of the
+// pool until it's released, and postgres lets go of it if the process dies.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Nil, without an error, when another process holds the lock
+func TryImportLock(ctx context.Context, db *sql.DB) (*AdvisoryLock, error) {
+	return tryAdvisoryLock(ctx, db, importLockKey)
+}
+
+// Nil, without an error, when another process holds the lock
+func TryNotifyLock(ctx context.Context, db *sql.DB) (*AdvisoryLock, error) {
+	return tryAdvisoryLock(ctx, db, notifyLockKey)
+}
+
+func tryAdvisoryLock(ctx context.Context, db *sql.DB, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		return nil, err
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+func (l *AdvisoryLock) Release() error {
+	defer l.conn.Close()
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+	return err
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/notify"
+	"github.com/lib/pq"
+)
+
+// Queues a notification for everyone who starred an event with a notable
+// change, whether they starred that session or its whole cluster.
+func queueNotifications(tx *sql.Tx, changes []*events.EventChange) error {
+	eventIds := make([]string, 0)
+	reasons := make([]string, 0)
+	messages := make([]string, 0)
+	for _, change := range changes {
+		reason, notable := notify.Classify(change)
+		if !notable {
+			continue
+		}
+		eventIds = append(eventIds, change.EventId)
+		reasons = append(reasons, string(reason))
+		messages = append(messages, change.Describe())
+	}
+	if len(eventIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+INSERT INTO notification_queue (email, event_id, reason, message)
+SELECT DISTINCT s.email, c.event_id, c.reason, c.message
+FROM unnest($1::text[], $2::text[], $3::text[]) AS c(event_id, reason, message)
+    JOIN events e1 ON e1.event_id = c.event_id
+    JOIN events e2 ON e1.year = e2.year
+        AND e1.short_category = e2.short_category
+        AND e1.title = e2.title
+        AND e1.cluster_key = e2.cluster_key
+    JOIN starred_events s ON s.event_id = e2.event_id
+        AND (s.level = 'group' OR s.event_id = e1.event_id)
+`, pq.Array(eventIds), pq.Array(reasons), pq.Array(messages))
+	return err
+}
+
+// Returns the defaults if the user never saved any.
+func LoadNotificationPreferences(db *sql.DB, email string) (*notify.Preferences, error) {
+	prefs := notify.DefaultPreferences(email)
+	var lastDigest pq.NullTime
+	err := db.QueryRow(`
+SELECT send_email, webhook_url, digest, tickets, last_digest
+FROM notification_preferences
+WHERE email = $1`, email).Scan(&prefs.SendEmail, &prefs.WebhookUrl, &prefs.Digest, &prefs.Tickets, &lastDigest)
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	prefs.LastDigest = lastDigest.Time
+	return prefs, nil
+}
+
+func SaveNotificationPreferences(db *sql.DB, prefs *notify.Preferences) error {
+	_, err := db.Exec(`
+INSERT INTO notification_preferences (email, send_email, webhook_url, digest, tickets)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (email)
+    DO UPDATE SET send_email = $2, webhook_url = $3, digest = $4, tickets = $5
+`, prefs.Email, prefs.SendEmail, prefs.WebhookUrl, prefs.Digest, prefs.Tickets)
+	return err
+}
+
+// Everything not yet sent, keyed by the email of who it's for, oldest first.
+func LoadPendingNotifications(db *sql.DB) (map[string][]*notify.Notification, error) {
+	rows, err := db.Query(`
+SELECT q.notification_id, q.email, q.event_id, coalesce(e.title, ''), q.reason, q.message, q.created
+FROM notification_queue q LEFT JOIN events e ON e.event_id = q.event_id
+WHERE q.sent IS NULL
+ORDER BY q.created, q.notification_id
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := make(map[string][]*notify.Notification)
+	byId := make(map[int64]*notify.Notification)
+	for rows.Next() {
+		var n notify.Notification
+		var email, reason string
+		err = rows.Scan(&n.Id, &email, &n.EventId, &n.Title, &reason, &n.Message, &n.Created)
+		if err != nil {
+			return nil, err
+		}
+		n.Reason = notify.Reason(reason)
+		pending[email] = append(pending[email], &n)
+		byId[n.Id] = &n
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+SELECT d.notification_id, d.channel, d.attempts, d.last_attempt, d.sent
+FROM notification_deliveries d JOIN notification_queue q ON q.notification_id = d.notification_id
+WHERE q.sent IS NULL
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var channel string
+		var delivery notify.Delivery
+		var lastAttempt pq.NullTime
+		err = rows.Scan(&id, &channel, &delivery.Attempts, &lastAttempt, &delivery.Sent)
+		if err != nil {
+			return nil, err
+		}
+		delivery.LastAttempt = lastAttempt.Time
+		if n, found := byId[id]; found {
+			*n.Delivery(channel) = delivery
+		}
+	}
+	return pending, rows.Err()
+}
+
+// Records the notifications going out over the channel.
+func MarkDelivered(db *sql.DB, channel string, notificationIds []int64) error {
+	_, err := db.Exec(`
+INSERT INTO notification_deliveries (notification_id, channel, last_attempt, sent)
+SELECT id, $2, now(), true FROM unnest($1::integer[]) AS id
+ON CONFLICT (notification_id, channel)
+    DO UPDATE SET last_attempt = now(), sent = true
+`, pq.Array(notificationIds), channel)
+	return err
+}
+
+// Records a failed attempt to send the notifications over the channel.
+func MarkDeliveryFailed(db *sql.DB, channel string, notificationIds []int64) error {
+	_, err := db.Exec(`
+INSERT INTO notification_deliveries (notification_id, channel, attempts, last_attempt)
+SELECT id, $2, 1, now() FROM unnest($1::integer[]) AS id
+ON CONFLICT (notification_id, channel)
+    DO UPDATE SET attempts = notification_deliveries.attempts + 1, last_attempt = now()
+`, pq.Array(notificationIds), channel)
+	return err
+}
+
+// Marks the notifications handled, whether they went out on every channel,
+// we gave up on them, or the user didn't want them. Digest users also get their digest clock reset.
+func MarkNotificationsSent(db *sql.DB, prefs *notify.Preferences, notificationIds []int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	_, err = tx.Exec(`
+UPDATE notification_queue SET sent = now() WHERE notification_id = ANY($1)`, pq.Array(notificationIds))
+	if err != nil {
+		return err
+	}
+	if prefs.Digest {
+		_, err = tx.Exec(`
+INSERT INTO notification_preferences (email, send_email, webhook_url, digest, tickets, last_digest)
+VALUES ($1, $2, $3, $4, $5, now())
+ON CONFLICT (email)
+    DO UPDATE SET last_digest = now()
+`, prefs.Email, prefs.SendEmail, prefs.WebhookUrl, prefs.Digest, prefs.Tickets)
+	}
+	return err
+}
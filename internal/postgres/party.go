@@ -2,25 +2,53 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/lib/pq"
 )
 
+const (
+	PartyOwner  = "owner"
+	PartyMember = "member"
+)
+
 // A party is a group of users playing together in a given year.
 type Party struct {
 	Id      int64
 	Name    string
 	Year    int64
+	Owner   string
 	Members []*User
 }
 
+func (p *Party) IsOwner(email string) bool {
+	return p.Owner == email
+}
+
+func (p *Party) HasMember(email string) bool {
+	for _, member := range p.Members {
+		if member.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
 func LoadParties(db *sql.DB, currentUser *User) ([]*Party, error) {
 	// Load all partiesById the current user is in
 	rows, err := db.Query(
 		`
 SELECT p.party_id,
        p.name,
-       p.year
+       p.year,
+       coalesce((SELECT owner.email
+                 FROM party_members owner
+                 WHERE owner.party_id = p.party_id
+                   AND owner.role = 'owner'
+                 LIMIT 1), '')
 FROM parties p
     JOIN party_members pm ON p.party_id = pm.party_id
 WHERE pm.email = $1
@@ -34,7 +62,7 @@ WHERE pm.email = $1
 	partiesById := make(map[int64]*Party)
 	for rows.Next() {
 		var p Party
-		err = rows.Scan(&p.Id, &p.Name, &p.Year)
+		err = rows.Scan(&p.Id, &p.Name, &p.Year, &p.Owner)
 		if err != nil {
 			return nil, err
 		}
@@ -78,6 +106,20 @@ GROUP BY u.email, u.display_name
 	return parties, nil
 }
 
+// Returns nil if the party doesn't exist or the user isn't in it.
+func LoadParty(db *sql.DB, currentUser *User, partyId int64) (*Party, error) {
+	parties, err := LoadParties(db, currentUser)
+	if err != nil {
+		return nil, err
+	}
+	for _, party := range parties {
+		if party.Id == partyId {
+			return party, nil
+		}
+	}
+	return nil, nil
+}
+
 func NewParty(db *sql.DB, name string, year int64, founderEmail string) (*Party, error) {
 	founder, err := LoadOrCreateUser(db, founderEmail)
 
@@ -101,7 +143,7 @@ INSERT INTO parties(name, year) VALUES ($1, $2) RETURNING party_id`, name, year)
 	}
 
 	_, err = tx.Exec(`
-INSERT INTO party_members (party_id, email) VALUES ($1, $2)`, partyId, founder.Email)
+INSERT INTO party_members (party_id, email, role) VALUES ($1, $2, $3)`, partyId, founder.Email, PartyOwner)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +152,161 @@ INSERT INTO party_members (party_id, email) VALUES ($1, $2)`, partyId, founder.E
 		Id:      partyId,
 		Name:    name,
 		Year:    year,
+		Owner:   founder.Email,
 		Members: []*User{founder},
 	}, nil
 }
+
+func RemovePartyMember(db *sql.DB, partyId int64, email string) error {
+	_, err := db.Exec(`
+DELETE FROM party_members WHERE party_id = $1 AND email = $2`, partyId, email)
+	return err
+}
+
+// Takes the user out of the party. If they owned it, one of the remaining
+// members takes over, and a party with nobody left is deleted.
+func LeaveParty(db *sql.DB, party *Party, email string) error {
+	var newOwner string
+	for _, member := range party.Members {
+		if member.Email != email {
+			newOwner = member.Email
+			break
+		}
+	}
+	if newOwner == "" {
+		return DeleteParty(db, party.Id)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	_, err = tx.Exec(`
+DELETE FROM party_members WHERE party_id = $1 AND email = $2`, party.Id, email)
+	if err != nil {
+		return err
+	}
+	if party.IsOwner(email) {
+		_, err = tx.Exec(`
+UPDATE party_members SET role = $3 WHERE party_id = $1 AND email = $2`, party.Id, newOwner, PartyOwner)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DeleteParty(db *sql.DB, partyId int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	for _, table := range []string{"party_invites", "party_members", "parties"} {
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE party_id = $1", table), partyId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// An event on the party calendar, with who in the party starred it
+type PartyEvent struct {
+	Event   *events.GenconEvent
+	Members []*User
+	// Only starred as one session of a cluster starred as a group, so it's
+	// an option rather than somewhere anyone has to be
+	Optional bool
+}
+
+func loadEventsById(db *sql.DB, eventIds []string) ([]*events.GenconEvent, error) {
+	fields := "e1." + strings.Join(eventFields(), ", e1.")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT %s, false, o.id
+FROM events e1 LEFT JOIN orgs o ON (lower(o.alias) = lower(e1.org_group))
+WHERE e1.event_id = ANY($1)
+ORDER BY e1.start_time`, fields), pq.Array(eventIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loadedEvents := make([]*events.GenconEvent, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		loadedEvents = append(loadedEvents, event)
+	}
+	return loadedEvents, nil
+}
+
+// Everything the members of the party have starred for the party's year,
+// sorted by start time, with every session of clusters they starred as a
+// group like their own starred page. Members are the ones from party.Members.
+func LoadPartySchedule(db *sql.DB, party *Party) ([]*PartyEvent, error) {
+	rows, err := db.Query(`
+SELECT e2.event_id, pm.email, bool_and(coalesce(se.level = 'group', false))
+FROM party_members pm
+    JOIN starred_events se ON se.email = pm.email
+    JOIN events e1 ON e1.event_id = se.event_id
+    JOIN events e2 ON e2.year = e1.year
+        AND e2.active
+        AND (e2.event_id = e1.event_id OR se.level = 'group' AND e2.cluster_key = e1.cluster_key)
+WHERE pm.party_id = $1
+  AND e1.year = $2
+GROUP BY e2.event_id, pm.email
+`, party.Id, party.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	membersByEmail := make(map[string]*User)
+	for _, member := range party.Members {
+		membersByEmail[member.Email] = member
+	}
+
+	starredBy := make(map[string][]*User)
+	// Optional until someone stars that session itself
+	optional := make(map[string]bool)
+	eventIds := make([]string, 0)
+	for rows.Next() {
+		var eventId, email string
+		var groupStarred bool
+		if err = rows.Scan(&eventId, &email, &groupStarred); err != nil {
+			return nil, err
+		}
+		if isOptional, found := optional[eventId]; !found || isOptional {
+			optional[eventId] = groupStarred
+		}
+		member, found := membersByEmail[email]
+		if !found {
+			member = &User{Email: email, DisplayName: strings.Split(email, "@")[0]}
+		}
+		if _, found := starredBy[eventId]; !found {
+			eventIds = append(eventIds, eventId)
+		}
+		starredBy[eventId] = append(starredBy[eventId], member)
+	}
+
+	loadedEvents, err := loadEventsById(db, eventIds)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := make([]*PartyEvent, 0, len(loadedEvents))
+	for _, event := range loadedEvents {
+		members := starredBy[event.EventId]
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].DisplayName < members[j].DisplayName
+		})
+		schedule = append(schedule, &PartyEvent{Event: event, Members: members, Optional: optional[event.EventId]})
+	}
+	return schedule, nil
+}
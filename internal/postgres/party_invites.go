@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// An invite into a party. Invites addressed to an email can only be used by
+// that user, and go away once accepted or declined. Join links have no
+// email, and keep working for anyone who has them until the party is gone.
+type PartyInvite struct {
+	Token     string
+	PartyId   int64
+	PartyName string
+	Year      int64
+	InvitedBy string
+	Email     string
+	Created   time.Time
+}
+
+func (i *PartyInvite) IsLink() bool {
+	return i.Email == ""
+}
+
+// Whether the invite lets this user in. Invites are stored lowercased, but
+// sign in emails can have capitals.
+func (i *PartyInvite) CanUse(email string) bool {
+	return i.IsLink() || strings.EqualFold(i.Email, email)
+}
+
+// Where to send someone to accept the invite, baseUrl is the site's scheme
+// and host
+func (i *PartyInvite) Url(baseUrl string) string {
+	return fmt.Sprintf("%s/invite/%s", baseUrl, i.Token)
+}
+
+func CreatePartyInvite(db *sql.DB, partyId int64, invitedBy string, email string) (*PartyInvite, error) {
+	// Same shape of secret as calendar tokens: knowing it is enough to join.
+	token, err := newCalendarToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := PartyInvite{
+		Token:     token,
+		PartyId:   partyId,
+		InvitedBy: invitedBy,
+		Email:     email,
+	}
+	err = db.QueryRow(`
+WITH new_invite AS (
+    INSERT INTO party_invites (token, party_id, invited_by, email)
+    VALUES ($1, $2, $3, nullif($4, ''))
+    RETURNING party_id, created
+)
+SELECT p.name, p.year, i.created
+FROM new_invite i JOIN parties p ON p.party_id = i.party_id
+`, token, partyId, invitedBy, email).Scan(&invite.PartyName, &invite.Year, &invite.Created)
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+const inviteFields = `
+SELECT i.token, i.party_id, p.name, p.year, i.invited_by, coalesce(i.email, ''), i.created
+FROM party_invites i JOIN parties p ON p.party_id = i.party_id
+`
+
+func loadInvites(db *sql.DB, query string, args ...interface{}) ([]*PartyInvite, error) {
+	rows, err := db.Query(inviteFields+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := make([]*PartyInvite, 0)
+	for rows.Next() {
+		var i PartyInvite
+		err = rows.Scan(&i.Token, &i.PartyId, &i.PartyName, &i.Year, &i.InvitedBy, &i.Email, &i.Created)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, &i)
+	}
+	return invites, nil
+}
+
+// Returns nil if there's no such invite.
+func LoadPartyInvite(db *sql.DB, token string) (*PartyInvite, error) {
+	invites, err := loadInvites(db, "WHERE i.token = $1", token)
+	if err != nil || len(invites) == 0 {
+		return nil, err
+	}
+	return invites[0], nil
+}
+
+// Invites addressed to this user which they haven't answered yet
+func LoadPendingInvites(db *sql.DB, email string) ([]*PartyInvite, error) {
+	return loadInvites(db, `
+WHERE i.email = $1
+  AND NOT EXISTS (SELECT 1 FROM party_members pm WHERE pm.party_id = i.party_id AND pm.email = $1)
+ORDER BY i.created`, email)
+}
+
+// Every outstanding invite and join link for the party
+func LoadPartyInvites(db *sql.DB, partyId int64) ([]*PartyInvite, error) {
+	return loadInvites(db, "WHERE i.party_id = $1 ORDER BY i.created", partyId)
+}
+
+func AcceptPartyInvite(db *sql.DB, invite *PartyInvite, email string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	_, err = tx.Exec(`
+INSERT INTO party_members (party_id, email, role)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING`, invite.PartyId, email, PartyMember)
+	if err != nil {
+		return err
+	}
+
+	if !invite.IsLink() {
+		_, err = tx.Exec(`DELETE FROM party_invites WHERE token = $1`, invite.Token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Declining, or revoking, an invite just removes it.
+func DeletePartyInvite(db *sql.DB, token string) error {
+	_, err := db.Exec(`DELETE FROM party_invites WHERE token = $1`, token)
+	return err
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import "testing"
+
+func TestPartyInviteCanUse(t *testing.T) {
+	invite := &PartyInvite{Token: "abc", Email: "player@example.com"}
+	if !invite.CanUse("Player@Example.com") {
+		t.Error("expected the invite to ignore case in the email")
+	}
+	if invite.CanUse("someone@example.com") {
+		t.Error("expected the invite to only work for its email")
+	}
+	if link := (&PartyInvite{Token: "abc"}); !link.CanUse("someone@example.com") {
+		t.Error("expected a join link to work for anyone")
+	}
+	if url := invite.Url("https://example.com"); url != "https://example.com/invite/abc" {
+		t.Errorf("unexpected invite url %v", url)
+	}
+}
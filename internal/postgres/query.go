@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Encinarus/genconplanner/internal/search"
+)
+
+// Builds up a query's arguments alongside its SQL, so values from users only
+// ever reach postgres as numbered placeholders. One builder can back several
+// where clauses in the same statement, the placeholders keep counting up.
+type queryBuilder struct {
+	args []interface{}
+}
+
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// Replaces each ? in the fragment with a placeholder for the next value.
+func (b *queryBuilder) bind(fragment string, values ...interface{}) string {
+	parts := strings.Split(fragment, "?")
+	if len(parts) != len(values)+1 {
+		panic(fmt.Sprintf("%d values for %d placeholders in %q", len(values), len(parts)-1, fragment))
+	}
+	var bound strings.Builder
+	for i, part := range parts {
+		bound.WriteString(part)
+		if i < len(values) {
+			bound.WriteString(b.arg(values[i]))
+		}
+	}
+	return bound.String()
+}
+
+func (b *queryBuilder) where() *whereClause {
+	return &whereClause{builder: b}
+}
+
+type whereClause struct {
+	builder    *queryBuilder
+	conditions []string
+}
+
+// Adds a condition, with ? standing in for each value.
+func (w *whereClause) and(condition string, values ...interface{}) *whereClause {
+	w.conditions = append(w.conditions, "("+w.builder.bind(condition, values...)+")")
+	return w
+}
+
+// Adds conditions where any one matching is enough. Adds nothing when
+// there are no conditions.
+func (w *whereClause) anyOf(conditions ...string) *whereClause {
+	if len(conditions) > 0 {
+		w.conditions = append(w.conditions, "("+strings.Join(conditions, " OR ")+")")
+	}
+	return w
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return "true"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
+
+// Escapes LIKE wildcards so user input only ever matches literally.
+func likeLiteral(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+var filterColumns = map[search.Field]string{
+	search.Category:     "short_category",
+	search.System:       "game_system",
+	search.Title:        "title",
+	search.Org:          "org_group",
+	search.GM:           "gm_names",
+	search.Location:     "location || ' ' || room_name",
+	search.Cost:         "cost_cents",
+	search.Tickets:      "tickets_available",
+	search.DayOfWeek:    "day_of_week",
+	search.Start:        "EXTRACT(EPOCH FROM (start_time AT TIME ZONE 'EDT')::time) / 3600",
+	search.End:          "EXTRACT(EPOCH FROM (end_time AT TIME ZONE 'EDT')::time) / 3600",
+	search.Age:          "min_age",
+	search.AgeLabel:     "age_required",
+	search.Experience:   "experience_level",
+	search.Registration: "registration",
+	search.Tournament:   "tournament",
+}
+
+// Events someone of the given age can go to. Events whose labels we
+// couldn't read have a min_age of -1 and never match, a parent asking for
+// ages 8+ wants events that say so.
+func ageCondition(b *queryBuilder, age int) string {
+	return b.bind("min_age BETWEEN 0 AND ? AND (max_age = 0 OR max_age >= ?)", age, age)
+}
+
+// Only these make it into the SQL text, never the op from the query.
+var comparisons = map[search.Op]string{
+	search.Contains:     "=",
+	search.Equals:       "=",
+	search.Less:         "<",
+	search.LessEqual:    "<=",
+	search.Greater:      ">",
+	search.GreaterEqual: ">=",
+}
+
+// Turns a parsed filter into a condition on the events table.
+func compileFilter(filter search.Filter, b *queryBuilder) string {
+	var condition string
+	column := filterColumns[filter.Field]
+	comparison := comparisons[filter.Op]
+
+	switch {
+	case filter.Field == search.Players:
+		// Lower bounds are about how big the table can get, upper bounds
+		// about how few it needs, and an exact count has to fit.
+		switch filter.Op {
+		case search.Greater, search.GreaterEqual:
+			condition = b.bind("max_players "+comparison+" ?", filter.Number)
+		case search.Less, search.LessEqual:
+			condition = b.bind("min_players "+comparison+" ?", filter.Number)
+		case search.Between:
+			condition = b.bind("max_players >= ? AND min_players <= ?", filter.Number, filter.High)
+		default:
+			condition = b.bind("min_players <= ? AND max_players >= ?", filter.Number, filter.Number)
+		}
+	case column == "":
+		// Not a field we know how to search, match nothing rather than guess
+		condition = "false"
+	case filter.Field.Kind == search.Ages:
+		condition = ageCondition(b, int(filter.Number))
+	case filter.Field.Kind == search.Level:
+		// Unknown levels are 0, which would otherwise pass for easier than none
+		condition = "experience_level > 0 AND "
+		if filter.Op == search.Between {
+			condition += b.bind("experience_level BETWEEN ? AND ?", filter.Number, filter.High)
+		} else {
+			condition += b.bind("experience_level "+comparison+" ?", filter.Number)
+		}
+	case filter.Op == search.Between:
+		condition = b.bind(column+" BETWEEN ? AND ?", filter.Number, filter.High)
+	case filter.Field.Kind == search.Number, filter.Field.Kind == search.Dollars,
+		filter.Field.Kind == search.Hour, filter.Field.Kind == search.Day:
+		condition = b.bind(column+" "+comparison+" ?", filter.Number)
+	case filter.Field.Kind == search.Bool:
+		condition = b.bind(column+" = ?", filter.Number == 1)
+	case filter.Field.Kind == search.Exact, filter.Op == search.Equals:
+		condition = b.bind("lower("+column+") = lower(?)", filter.Value)
+	case filter.Field.Kind == search.Prefix:
+		condition = b.bind(column+" ILIKE ?", likeLiteral(filter.Value)+"%")
+	default:
+		condition = b.bind(column+" ILIKE ?", "%"+likeLiteral(filter.Value)+"%")
+	}
+
+	if filter.Negate {
+		return fmt.Sprintf("NOT coalesce((%s), false)", condition)
+	}
+	return condition
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Encinarus/genconplanner/internal/search"
+)
+
+const hostile = `'); DROP TABLE events; --`
+
+func TestBindNumbersPlaceholders(t *testing.T) {
+	var b queryBuilder
+	first := b.bind("year = ? AND title = ?", 2024, "x")
+	second := b.bind("org_group = ?", "y")
+
+	if first != "year = $1 AND title = $2" {
+		t.Errorf("first fragment: %q", first)
+	}
+	if second != "org_group = $3" {
+		t.Errorf("second fragment: %q", second)
+	}
+	if len(b.args) != 3 || b.args[0] != 2024 || b.args[2] != "y" {
+		t.Errorf("args: %v", b.args)
+	}
+}
+
+func TestBindPanicsOnMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a missing value")
+		}
+	}()
+	var b queryBuilder
+	b.bind("year = ? AND title = ?", 2024)
+}
+
+func TestWhereClause(t *testing.T) {
+	var b queryBuilder
+	if where := b.where(); where.String() != "true" {
+		t.Errorf("empty where: %q", where)
+	}
+
+	where := b.where().and("active").and("year = ?", 2024).anyOf()
+	if where.String() != "(active) AND (year = $1)" {
+		t.Errorf("where: %q", where)
+	}
+	where.anyOf("a", "b")
+	if where.String() != "(active) AND (year = $1) AND (a OR b)" {
+		t.Errorf("where with anyOf: %q", where)
+	}
+}
+
+func TestCompileFilterKeepsValuesOutOfSql(t *testing.T) {
+	for _, field := range []search.Field{
+		search.Category, search.System, search.Title, search.Org,
+		search.GM, search.Location, search.AgeLabel, search.Registration,
+	} {
+		for _, op := range []search.Op{search.Contains, search.Equals} {
+			var b queryBuilder
+			sql := compileFilter(search.Filter{Field: field, Op: op, Value: hostile}, &b)
+			if strings.Contains(sql, "DROP") {
+				t.Errorf("%s%s leaked its value into %q", field.Name, op, sql)
+			}
+			if len(b.args) != 1 || !strings.Contains(b.args[0].(string), hostile) {
+				t.Errorf("%s%s args: %v", field.Name, op, b.args)
+			}
+		}
+	}
+}
+
+func TestCompileFilterEscapesWildcards(t *testing.T) {
+	var b queryBuilder
+	compileFilter(search.Filter{Field: search.Title, Op: search.Contains, Value: `100%_\`}, &b)
+	if b.args[0] != `%100\%\_\\%` {
+		t.Errorf("contains arg: %q", b.args[0])
+	}
+
+	b = queryBuilder{}
+	compileFilter(search.Filter{Field: search.AgeLabel, Op: search.Contains, Value: "kids_"}, &b)
+	if b.args[0] != `kids\_%` {
+		t.Errorf("prefix arg: %q", b.args[0])
+	}
+}
+
+func TestCompileFilterIgnoresUnknownOps(t *testing.T) {
+	var b queryBuilder
+	sql := compileFilter(search.Filter{Field: search.Cost, Op: search.Op("; DROP TABLE events"), Number: 4}, &b)
+	if strings.Contains(sql, "DROP") {
+		t.Errorf("op leaked into %q", sql)
+	}
+
+	b = queryBuilder{}
+	sql = compileFilter(search.Filter{Field: search.Field{Name: hostile, Kind: search.Text}, Op: search.Contains, Value: "x"}, &b)
+	if sql != "false" {
+		t.Errorf("unknown field compiled to %q", sql)
+	}
+}
+
+func TestCompileFilterNegates(t *testing.T) {
+	var b queryBuilder
+	sql := compileFilter(search.Filter{Field: search.Tournament, Op: search.Contains, Number: 1, Negate: true}, &b)
+	if sql != "NOT coalesce((tournament = $1), false)" {
+		t.Errorf("negated filter: %q", sql)
+	}
+	if b.args[0] != true {
+		t.Errorf("args: %v", b.args)
+	}
+}
+
+func TestCompileAgeAndExperience(t *testing.T) {
+	var b queryBuilder
+	sql := compileFilter(search.Filter{Field: search.Age, Op: search.Contains, Value: "8+", Number: 8}, &b)
+	if sql != "min_age BETWEEN 0 AND $1 AND (max_age = 0 OR max_age >= $2)" {
+		t.Errorf("age filter: %q", sql)
+	}
+	if len(b.args) != 2 || b.args[0] != 8 || b.args[1] != 8 {
+		t.Errorf("age args: %v", b.args)
+	}
+
+	b = queryBuilder{}
+	sql = compileFilter(search.Filter{Field: search.Experience, Op: search.LessEqual, Value: "some", Number: 2}, &b)
+	if sql != "experience_level > 0 AND experience_level <= $1" {
+		t.Errorf("experience filter: %q", sql)
+	}
+}
+
+func TestBuildFindEventsQuery(t *testing.T) {
+	query := &ParsedQuery{
+		TextQueries: []string{hostile, `"dragon's hoard"`},
+		Filters: []search.Filter{
+			{Field: search.Org, Op: search.Contains, Value: hostile},
+			{Field: search.Cost, Op: search.LessEqual, Number: 450},
+		},
+		Year: 2024,
+		DaysOfWeek: map[string]bool{
+			"sat":    true,
+			hostile:  true,
+			"wed OR": true,
+		},
+		StartBeforeHour: -1,
+		StartAfterHour:  18,
+		EndBeforeHour:   -1,
+		EndAfterHour:    -1,
+		OrgId:           7,
+	}
+
+	sql, args := buildFindEventsQuery(query)
+	if strings.Contains(sql, "DROP") || strings.Contains(sql, "dragon") || strings.Contains(sql, "wed OR") {
+		t.Errorf("user input leaked into the query:\n%s", sql)
+	}
+	if !strings.Contains(sql, "(c.sat_tickets > 0)") {
+		t.Errorf("missing day filter:\n%s", sql)
+	}
+	if strings.Count(sql, "$") != len(args) {
+		t.Errorf("%d placeholders for %d args:\n%s", strings.Count(sql, "$"), len(args), sql)
+	}
+
+	found := map[interface{}]bool{}
+	for _, arg := range args {
+		found[arg] = true
+	}
+	for _, want := range []interface{}{2024, 18, "%" + hostile + "%", 450.0, hostile + ` "dragon's hoard"`, 7} {
+		if !found[want] {
+			t.Errorf("missing arg %v in %v", want, args)
+		}
+	}
+}
@@ -24,6 +24,7 @@ CREATE TABLE public.party_members
 (
     party_id integer NOT NULL,
     email text COLLATE pg_catalog."default" NOT NULL,
+    role text COLLATE pg_catalog."default" NOT NULL DEFAULT 'member',
     CONSTRAINT party_members_pkey PRIMARY KEY (party_id, email)
 )
     WITH (
@@ -34,6 +35,35 @@ CREATE TABLE public.party_members
 ALTER TABLE public.party_members
     OWNER to postgres;
 
+-- Databases from before party owners need the role column, and each party's
+-- earliest member, the one who made it, promoted to owner. Nothing records
+-- when members joined, so insertion order is the best guess:
+-- ALTER TABLE public.party_members ADD COLUMN role text COLLATE pg_catalog."default" NOT NULL DEFAULT 'member';
+-- UPDATE public.party_members pm SET role = 'owner'
+--     WHERE pm.ctid = (SELECT min(first.ctid) FROM public.party_members first WHERE first.party_id = pm.party_id);
+
+-- Table: public.party_invites
+
+-- DROP TABLE public.party_invites;
+
+CREATE TABLE public.party_invites
+(
+    token text COLLATE pg_catalog."default" NOT NULL,
+    party_id integer NOT NULL,
+    invited_by text COLLATE pg_catalog."default" NOT NULL,
+    -- Null for join links, which anyone holding them can use
+    email text COLLATE pg_catalog."default",
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT party_invites_pkey PRIMARY KEY (token)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.party_invites
+    OWNER to postgres;
+
 -- Table: public.boardgame
 
 -- DROP TABLE public.boardgame;
@@ -122,6 +152,7 @@ CREATE TABLE public.users
 (
   email text COLLATE pg_catalog."default" NOT NULL,
   display_name text COLLATE pg_catalog."default",
+  calendar_token text COLLATE pg_catalog."default",
   CONSTRAINT users_pkey PRIMARY KEY (email)
 )
   WITH (
@@ -132,6 +163,19 @@ CREATE TABLE public.users
 ALTER TABLE public.users
   OWNER to postgres;
 
+-- Databases from before calendar feeds need the token column, it's filled in
+-- the first time each user opens their starred page:
+-- ALTER TABLE public.users ADD COLUMN calendar_token text COLLATE pg_catalog."default";
+
+-- Index: calendar_token_idx
+
+-- DROP INDEX public.calendar_token_idx;
+
+CREATE UNIQUE INDEX calendar_token_idx
+  ON public.users USING btree
+    (calendar_token COLLATE pg_catalog."default")
+  TABLESPACE pg_default;
+
 -- Table: public.events
 
 -- DROP TABLE public.events;
@@ -177,6 +221,18 @@ CREATE TABLE public.events
     desc_tsv tsvector,
     day_of_week integer,
     search_key tsvector,
+    feed_game_system text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    -- Parsed from age_required, experience_required and
+    -- attendee_registration at import, see events.ParseDetails
+    min_age integer NOT NULL DEFAULT -1,
+    max_age integer NOT NULL DEFAULT 0,
+    experience_level integer NOT NULL DEFAULT 0,
+    registration text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    cost_cents integer NOT NULL DEFAULT 0,
+    -- Worked out from location and room_name with the venue tables, see
+    -- events.Venue.Locate
+    building text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    room text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
     CONSTRAINT event_pkey PRIMARY KEY (event_id)
 )
   WITH (
@@ -187,6 +243,29 @@ CREATE TABLE public.events
 ALTER TABLE public.events
   OWNER to postgres;
 
+-- Databases from before the parsed fields need them added, then the next
+-- import fills them in for the current year, and a backfill for past ones:
+-- ALTER TABLE public.events ADD COLUMN min_age integer NOT NULL DEFAULT -1;
+-- ALTER TABLE public.events ADD COLUMN max_age integer NOT NULL DEFAULT 0;
+-- ALTER TABLE public.events ADD COLUMN experience_level integer NOT NULL DEFAULT 0;
+-- ALTER TABLE public.events ADD COLUMN registration text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+-- ALTER TABLE public.events ADD COLUMN cost_cents integer NOT NULL DEFAULT 0;
+-- UPDATE public.events SET cost_cents = cost * 100 WHERE cost IS NOT NULL;
+
+-- Databases from before the venue tables need these, then saving anything
+-- on /admin/venue/ places every event:
+-- ALTER TABLE public.events ADD COLUMN building text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+-- ALTER TABLE public.events ADD COLUMN room text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+-- Index: min_age_index
+
+-- DROP INDEX public.min_age_index;
+
+CREATE INDEX min_age_index
+  ON public.events USING btree
+    (min_age)
+  TABLESPACE pg_default;
+
 -- Index: dow_index
 
 -- DROP INDEX public.dow_index;
@@ -417,3 +496,601 @@ EXECUTE PROCEDURE public.update_org();
 -- update orgs o
 -- set id = (select min(o2.id) from orgs o2
 -- 		  where translate(lower(o2.alias), '''.",!:; ', '') = translate(lower(o.alias), '''.",!:; ', '') )
+
+-- Table: public.cluster_priorities
+
+-- DROP TABLE public.cluster_priorities;
+
+CREATE TABLE public.cluster_priorities
+(
+    email text COLLATE pg_catalog."default" NOT NULL,
+    year integer NOT NULL,
+    cluster_id character varying(13) COLLATE pg_catalog."default" NOT NULL,
+    priority integer NOT NULL,
+    CONSTRAINT cluster_priorities_pkey PRIMARY KEY (email, cluster_id)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.cluster_priorities
+    OWNER to postgres;
+
+-- Table: public.event_changes
+
+-- DROP TABLE public.event_changes;
+
+CREATE TABLE public.event_changes
+(
+    change_id SERIAL PRIMARY KEY,
+    event_id text COLLATE pg_catalog."default" NOT NULL,
+    year integer NOT NULL,
+    changed timestamp with time zone NOT NULL DEFAULT now(),
+    field text COLLATE pg_catalog."default" NOT NULL,
+    old_value text COLLATE pg_catalog."default",
+    new_value text COLLATE pg_catalog."default"
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.event_changes
+    OWNER to postgres;
+
+-- Index: event_changes_event_idx
+
+-- DROP INDEX public.event_changes_event_idx;
+
+CREATE INDEX event_changes_event_idx
+    ON public.event_changes USING btree
+    (event_id COLLATE pg_catalog."default", changed)
+    TABLESPACE pg_default;
+
+-- Table: public.user_visits
+
+-- DROP TABLE public.user_visits;
+
+CREATE TABLE public.user_visits
+(
+    email text COLLATE pg_catalog."default" NOT NULL,
+    page text COLLATE pg_catalog."default" NOT NULL,
+    visited timestamp with time zone NOT NULL,
+    CONSTRAINT user_visits_pkey PRIMARY KEY (email, page)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.user_visits
+    OWNER to postgres;
+
+-- Table: public.notification_preferences
+
+-- DROP TABLE public.notification_preferences;
+
+CREATE TABLE public.notification_preferences
+(
+    email text COLLATE pg_catalog."default" NOT NULL,
+    send_email boolean NOT NULL DEFAULT false,
+    webhook_url text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    digest boolean NOT NULL DEFAULT false,
+    tickets boolean NOT NULL DEFAULT true,
+    last_digest timestamp with time zone,
+    CONSTRAINT notification_preferences_pkey PRIMARY KEY (email)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.notification_preferences
+    OWNER to postgres;
+
+-- Databases from before email was opt in need the default changed. Rows
+-- already there were saved by their users, so they stay as they are:
+-- ALTER TABLE public.notification_preferences ALTER COLUMN send_email SET DEFAULT false;
+
+-- Table: public.notification_queue
+
+-- DROP TABLE public.notification_queue;
+
+CREATE TABLE public.notification_queue
+(
+    notification_id SERIAL PRIMARY KEY,
+    email text COLLATE pg_catalog."default" NOT NULL,
+    event_id text COLLATE pg_catalog."default" NOT NULL,
+    reason text COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default" NOT NULL,
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    sent timestamp with time zone
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.notification_queue
+    OWNER to postgres;
+
+-- Index: notification_queue_pending_idx
+
+-- DROP INDEX public.notification_queue_pending_idx;
+
+CREATE INDEX notification_queue_pending_idx
+    ON public.notification_queue USING btree
+    (email COLLATE pg_catalog."default")
+    TABLESPACE pg_default
+    WHERE sent IS NULL;
+
+-- Table: public.notification_deliveries
+
+-- DROP TABLE public.notification_deliveries;
+
+CREATE TABLE public.notification_deliveries
+(
+    notification_id integer NOT NULL,
+    channel text COLLATE pg_catalog."default" NOT NULL,
+    -- Failed attempts so far
+    attempts integer NOT NULL DEFAULT 0,
+    last_attempt timestamp with time zone,
+    sent boolean NOT NULL DEFAULT false,
+    CONSTRAINT notification_deliveries_pkey PRIMARY KEY (notification_id, channel)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.notification_deliveries
+    OWNER to postgres;
+
+-- Table: public.ticket_snapshots
+
+-- DROP TABLE public.ticket_snapshots;
+
+CREATE TABLE public.ticket_snapshots
+(
+    event_id text COLLATE pg_catalog."default" NOT NULL,
+    taken timestamp with time zone NOT NULL,
+    tickets_available integer NOT NULL,
+    CONSTRAINT ticket_snapshots_pkey PRIMARY KEY (event_id, taken)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.ticket_snapshots
+    OWNER to postgres;
+
+-- Table: public.held_imports
+
+-- DROP TABLE public.held_imports;
+
+CREATE TABLE public.held_imports
+(
+    id bigserial NOT NULL,
+    year integer NOT NULL,
+    source text COLLATE pg_catalog."default" NOT NULL,
+    reason text COLLATE pg_catalog."default" NOT NULL,
+    event_count integer NOT NULL,
+    events jsonb NOT NULL,
+    -- Rows the parser couldn't read, their events aren't deactivated
+    rejected jsonb NOT NULL DEFAULT '[]',
+    -- Of events and rejected, so the same feed isn't held over and over
+    checksum text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    status text COLLATE pg_catalog."default" NOT NULL DEFAULT 'held',
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    resolved timestamp with time zone,
+    CONSTRAINT held_imports_pkey PRIMARY KEY (id)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.held_imports
+    OWNER to postgres;
+
+-- Databases from before rejected rows were kept need:
+-- ALTER TABLE public.held_imports ADD COLUMN rejected jsonb NOT NULL DEFAULT '[]';
+
+-- Databases from before holds were deduplicated need:
+-- ALTER TABLE public.held_imports ADD COLUMN checksum text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+-- Table: public.import_runs
+
+-- DROP TABLE public.import_runs;
+
+CREATE TABLE public.import_runs
+(
+    id bigserial NOT NULL,
+    source text COLLATE pg_catalog."default" NOT NULL,
+    year integer,
+    started timestamp with time zone NOT NULL DEFAULT now(),
+    finished timestamp with time zone,
+    bytes_fetched bigint NOT NULL DEFAULT 0,
+    parsed_rows integer NOT NULL DEFAULT 0,
+    rejected_rows integer NOT NULL DEFAULT 0,
+    inserted integer NOT NULL DEFAULT 0,
+    updated integer NOT NULL DEFAULT 0,
+    deactivated integer NOT NULL DEFAULT 0,
+    warnings text[] COLLATE pg_catalog."default" NOT NULL DEFAULT '{}',
+    status text COLLATE pg_catalog."default" NOT NULL DEFAULT 'running',
+    error text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    CONSTRAINT import_runs_pkey PRIMARY KEY (id)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.import_runs
+    OWNER to postgres;
+
+-- Databases from before runs recorded their year need the year column.
+-- Backfills are refused without -force until the next import fills it in:
+-- ALTER TABLE public.import_runs ADD COLUMN year integer;
+
+-- Index: import_runs_status_finished
+
+-- DROP INDEX public.import_runs_status_finished;
+
+CREATE INDEX import_runs_status_finished
+    ON public.import_runs USING btree
+        (status, finished DESC)
+    TABLESPACE pg_default;
+
+-- Table: public.game_system_aliases
+
+-- DROP TABLE public.game_system_aliases;
+
+CREATE TABLE public.game_system_aliases
+(
+    alias text COLLATE pg_catalog."default" NOT NULL,
+    game_system text COLLATE pg_catalog."default" NOT NULL,
+    updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT game_system_aliases_pkey PRIMARY KEY (alias)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.game_system_aliases
+    OWNER to postgres;
+
+-- Databases from before feed_game_system need it added to events:
+-- ALTER TABLE public.events ADD COLUMN feed_game_system text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+-- Aliases that used to be hardcoded in events.NormalizeEvent
+INSERT INTO public.game_system_aliases (alias, game_system) VALUES
+    ('5 Minute Dungeon', '5-Minute Dungeon'),
+    ('5 Minute Mystery', '5-Minute Mystery'),
+    ('5 Year Mission', 'Star Trek: Five-Year Mission'),
+    ('51st State Ultimate Edtion', '51st State: Ultimate Edition'),
+    ('7 wonders', '7 Wonders'),
+    ('7th Sea City of Five Sails', '7th Sea: City of Five Sails'),
+    ('A Sonf of Ice and Fire - Miniatures Game', 'A Song of Ice and Fire - Miniatures Game'),
+    ('A Touch of Evil, The Supernatural Game', 'A Touch of Evil: The Supernatural Game'),
+    ('AEGIS Combining Robots', 'A.E.G.I.S. Combining Robots: Season 2'),
+    ('Affliction', 'AFFLICTION: Salem 1692'),
+    ('Agatha Christie: Death in the Cards', 'Agatha Christie: Death on the Cards'),
+    ('Age of Mythology', 'Age of Mythology: The Boardgame'),
+    ('Ahau: Rulers of Yucatan', 'Ahau: Rulers of Yucatán'),
+    ('Alien - Fate of the Nostromo', 'ALIEN: Fate of the Nostromo'),
+    ('Angry Town', 'Angry Town: The Fighting Card Game'),
+    ('Anna''s Roundtable', 'Anna''s Roundtable: The Fan Made Fire Emblem Board Game'),
+    ('Ascension Tactics', 'Ascension Tactics: Miniatures Deckbuilding Game'),
+    ('Ascension', 'Ascension: Deckbuilding Game'),
+    ('Ascension: Tactics', 'Ascension Tactics: Miniatures Deckbuilding Game'),
+    ('Ascension; Tactics', 'Ascension Tactics: Miniatures Deckbuilding Game'),
+    ('Ashes Reborn', 'Ashes Reborn: Rise of the Phoenixborn'),
+    ('Axis & Allies 1942', 'Axis & Allies: 1942'),
+    ('B-17 Queen of the Skies', 'B-17: Queen of the Skies'),
+    ('Battle for Greyport', 'The Red Dragon Inn: Battle for Greyport'),
+    ('Battletech Alpha Strike', 'BattleTech: Alpha Strike'),
+    ('Betrayal at the House on Haunted Hill', 'Betrayal at House on the Hill'),
+    ('Betrayal at the House on the Hill', 'Betrayal at House on the Hill'),
+    ('Bigfoot Roll & Smash', 'BIGFOOT: Roll & Smash'),
+    ('Boss Monster', 'Boss Monster: The Dungeon Building Card Game'),
+    ('Bouba Kiki', 'Bouba/Kiki'),
+    ('Bridgerton High Society Game', 'Bridgerton: The High Society Game'),
+    ('Broadsides and Boarding Parties', 'Broadsides & Boarding Parties'),
+    ('Broken and Beautiful', 'Broken and Beautiful: A Game About Kintsugi'),
+    ('Cache Me If You Can!', 'Cache Me If You Can!: The Geocaching Board Game'),
+    ('Captain is Dead', 'The Captain Is Dead'),
+    ('Captain is Dead: Lockdown', 'The Captain Is Dead: Lockdown'),
+    ('Carcassone', 'Carcassonne'),
+    ('cartagena', 'Cartagena'),
+    ('Cartographers: A Roll Player Tale', 'Cartographers'),
+    ('Cartographers: Heroes', 'Cartographers Heroes'),
+    ('Castle Ravenloft', 'Dungeons & Dragons: Castle Ravenloft Board Game'),
+    ('Caverna w Forgotten Folks', 'Caverna: The Cave Farmers'),
+    ('Caverna', 'Caverna: The Cave Farmers'),
+    ('Channel A: The Anime Pitch Game', 'Channel A'),
+    ('Clank! Catacombs', 'Clank!: Catacombs'),
+    ('Clank!', 'Clank!: A Deck-Building Adventure'),
+    ('Codenames Duet', 'Codenames: Duet'),
+    ('Commisioned', 'Commissioned'),
+    ('Conan by Monolith', 'Conan'),
+    ('Concordia Salsa', 'Concordia: Salsa'),
+    ('Conquest Princess', 'Conquest Princess: Fashion Is Power'),
+    ('Conspiracy Theory Trivia', 'Conspiracy Theory Trivia Board Game'),
+    ('Corps of Discovery', 'Corps of Discovery: A Game Set in the World of Manifest Destiny'),
+    ('Darwin Awards', 'Darwin Awards Party Card Game'),
+    ('Dead Cells', 'Dead Cells: The Rogue-Lite Board Game'),
+    ('Dead of Winter', 'Dead of Winter: A Crossroads Game'),
+    ('Decorum', 'Décorum'),
+    ('Deep Rock Galactic', 'Deep Rock Galactic: The Board Game'),
+    ('Destination Neptune', 'Destination: Neptune'),
+    ('Disney Sorcerer''s Arena: Epic Alliances', 'Disney Sorcerer''s Arena: Epic Alliances Core Set'),
+    ('Disney''s Big Thunder Mountain', 'Disney Big Thunder Mountain Railroad'),
+    ('Disney''s The Haunted Mansion', 'Disney: The Haunted Mansion – Call of the Spirits Game'),
+    ('Disney''s Villainous', 'Disney Villainous: The Worst Takes it All'),
+    ('Disney''s Villianous', 'Disney Villainous: The Worst Takes it All'),
+    ('Disney: The Haunted Mansion - Call of the Spirits Game', 'Disney: The Haunted Mansion – Call of the Spirits Game'),
+    ('Doctor Who Time of the Daleks', 'Doctor Who: Time of the Daleks'),
+    ('Downfall of Pompeii', 'The Downfall of Pompeii'),
+    ('Dr. Who: Blink', 'Blink!'),
+    ('Dragon Age', 'Dragon AGE'),
+    ('Dragon Prince: Battlecharged', 'The Dragon Prince: Battlecharged'),
+    ('Dune Imperium', 'Dune: Imperium'),
+    ('Dune Imperium: Uprising', 'Dune: Imperium – Uprising'),
+    ('Dungeon Fun', 'Dungeon Party'),
+    ('Dungeon', 'Dungeon!'),
+    ('Dungeons & Dragons: The Yawning Portal Board Game', 'Dungeons & Dragons: The Yawning Portal'),
+    ('E.T.I. Estimated Time to Invasion', 'E.T.I.: Estimated Time to Invasion'),
+    ('Elemental Stones', 'Pathfinder: Elemental Stones'),
+    ('Empyreal', 'Empyreal: Spells & Steam'),
+    ('Epic Spell Wars Annihilageddon', 'Epic Spell Wars of the Battle Wizards: Annihilageddon Deck-Building Game'),
+    ('Escape the Dark', 'The Last of Us: Escape the Dark'),
+    ('Extraordinary Adventures: Pirates!', 'Extraordinary Adventures: Pirates'),
+    ('Faeries and Magical Creatures', 'Faeries & Magical Creatures'),
+    ('Fangs: Werewolves vs. Vampires vs. Humans', 'Fangs: Werewolves vs Vampires vs Humans'),
+    ('Farshore', 'Everdell Farshore'),
+    ('Fateforge', 'Fateforge: Chronicles of Kaan'),
+    ('Fates of Madness: An Adventure Card Game', 'Fates of Madness'),
+    ('Favor of the Pharoah', 'Favor of the Pharaoh'),
+    ('Firefly', 'Firefly: The Game'),
+    ('Firefly: The Boardgame', 'Firefly: The Game'),
+    ('Fish ''n'' Katz', 'Fish & Katz'),
+    ('Five Tribes', 'Five Tribes: The Djinns of Naqala'),
+    ('Formula de Mini', 'Formula Dé Mini'),
+    ('Formula De Mini', 'Formula Dé Mini'),
+    ('Foundations of Rome; Roads of Fortune', 'Foundations of Rome: Roads of Fortune'),
+    ('Funkoverse', 'Funkoverse Strategy Game'),
+    ('Genshin Tarot', 'Genshin Tarot: The Fan Made Genshin Impact Board Game'),
+    ('Great British Baking show', 'The Great British Baking Show Game'),
+    ('Headless Horseman', 'Headless Horseman Board Game'),
+    ('Hellboy The Board Game', 'Hellboy: The Board Game'),
+    ('Hulk Smash', 'The Incredible Hulk Smash'),
+    ('Ierusalem', 'Ierusalem: Anno Domini'),
+    ('Imperial Assault', 'Star Wars: Imperial Assault'),
+    ('Inca Empire TCG', 'Inca Empire: The Card Game'),
+    ('Kinfire Chronicles', 'Kinfire Chronicles: Night''s Fall'),
+    ('Kelp - Shark vs. Octopus', 'Kelp: Shark vs Octopus'),
+    ('Kung-Fu Zoo', 'Kung Fu Zoo'),
+    ('Kutna Hora', 'Kutná Hora: The City of Silver'),
+    ('Ladies and Gentlmen', 'Ladies & Gentlemen'),
+    ('Last Night on Earth', 'Last Night on Earth: The Zombie Game'),
+    ('Legacy''s Allure', 'Legacy''s Allure: Season 1'),
+    ('Life of the Amazoia', 'Life of the Amazonia'),
+    ('Magic: the Gathering', 'Magic: The Gathering'),
+    ('Manhattan Project: Energy Empire', 'The Manhattan Project: Energy Empire'),
+    ('Marvel Champions', 'Marvel Champions: The Card Game'),
+    ('Marvel Villainous', 'Marvel Villainous: Infinite Power'),
+    ('Masters of Orion', 'Master of Orion: The Board Game'),
+    ('My Little Pony Adventures in Equestria Deck-Building Game', 'My Little Pony: Adventures in Equestria Deck-Building Game'),
+    ('Oath', 'Oath: Chronicles of Empire and Exile'),
+    ('Oltree', 'Oltréé'),
+    ('Orleans', 'Orléans'),
+    ('Oranges and Lemons', 'Oranges & Lemons'),
+    ('Overboss', 'Overboss: A Boss Monster Adventure'),
+    ('Pasaraya', 'Pasaraya: Supermarket Manager'),
+    ('Prestige: The City-Building Game', 'Prestige: A City Building Game'),
+    ('Persona 5 Royal', 'Trick Gear: Persona 5 The Royal'),
+    ('Pick-a-Pepper (Sauscharf)', 'Pick-a-Pepper'),
+    ('Planted', 'Planted: A Game of Nature & Nurture'),
+    ('Red Dragon Inn', 'The Red Dragon Inn'),
+    ('Roll Camera', 'Roll Camera!: The Filmmaking Board Game'),
+    ('Roll to the Top', 'Roll to the Top!'),
+    ('Roar and Write', 'Roar and Write!'),
+    ('Robinson Crusoe', 'Robinson Crusoe: Adventures on the Cursed Island'),
+    ('Sentinels of the Mutliverse', 'Sentinels of the Multiverse'),
+    ('Settlers of America', 'Catan Histories: Settlers of America – Trails to Rails'),
+    ('Settlers of Catan', 'The Settlers of Catan'),
+    ('Shadowgate the Living Castle', 'Shadowgate: The Living Castle'),
+    ('Shadows of ESteren', 'Shadows of Esteren'),
+    ('Shadres of Infinity', 'Shards of Infinity'),
+    ('SHOBU', 'SHŌBU'),
+    ('Smash Up: Disney Style!', 'Smash Up: Disney Edition'),
+    ('Sorcerer Endbringer', 'Sorcerer: Endbringer'),
+    ('Snow White Gemstone Mining', 'Snow White and the Seven Dwarfs: A Gemstone Mining Game'),
+    ('SpaceCorp: 2025-2300 AD', 'SpaceCorp: 2025-2300AD'),
+    ('SpaceCorp 2025-2300AD', 'SpaceCorp: 2025-2300AD'),
+    ('Space Lion', 'Space Lion: Divide and Conquer'),
+    ('Spector Ops', 'Specter Ops'),
+    ('Star Trek 5 Year Mission', 'Star Trek: Five-Year Mission'),
+    ('Star Trek Ascendancy', 'Star Trek: Ascendancy'),
+    ('Star Trek Ascendency', 'Star Trek: Ascendancy'),
+    ('Strat-O-matic', 'Strat-O-Matic Baseball'),
+    ('Stupid Death', 'Stupid Deaths'),
+    ('Supershow', 'The Supershow'),
+    ('Sushi Go Party', 'Sushi Go Party!'),
+    ('Suspects: Adele & Neville, Investigative Reporters', 'Suspects: Adele and Neville, Investigative Reporters'),
+    ('Swords and Sorcery', 'Sword & Sorcery'),
+    ('Tesla vs Edison', 'Tesla vs. Edison'),
+    ('The Binding Of Isaac Four Souls', 'The Binding of Isaac: Four Souls'),
+    ('The Lord of the Rings LCG', 'The Lord of the Rings: The Card Game'),
+    ('The Lost Ruins of Arnak', 'Lost Ruins of Arnak'),
+    ('Town of Salem', 'Town of Salem: The Card Game'),
+    ('Trekking', 'Trekking the World'),
+    ('Trogdor!!', 'Trogdor!!: The Board Game'),
+    ('Tzolk''in', 'Tzolk''in: The Mayan Calendar'),
+    ('Unmatched', 'Unmatched Game System'),
+    ('Uproot Arboreal Battleship', 'Uproot: Arboreal Battleship'),
+    ('Vampire: The Masquerade Rivals', 'Vampire: The Masquerade – Rivals Expandable Card Game'),
+    ('Villainous', 'Disney Villainous'),
+    ('Viticulture World', 'Viticulture World: Cooperative Expansion'),
+    ('Way Too Many Cats', 'Way Too Many Cats!'),
+    ('World of Ulos', 'Dawn of Ulos'),
+    ('Wrath of Ashardalon', 'Dungeons & Dragons: Wrath of Ashardalon Board Game'),
+    ('Wrath of the Lich King', 'World of Warcraft: Wrath of the Lich King'),
+    ('You Gotta be Kitten Me! Learn to Play', 'You Gotta be Kitten Me!'),
+    ('Zombie Survival', 'Zombie Survival: The Board Game')
+ON CONFLICT (alias) DO NOTHING;
+
+-- Table: public.venue_buildings
+
+-- DROP TABLE public.venue_buildings;
+
+CREATE TABLE public.venue_buildings
+(
+    code text COLLATE pg_catalog."default" NOT NULL,
+    name text COLLATE pg_catalog."default" NOT NULL,
+    -- Between rooms inside the building
+    inside_walk_minutes integer NOT NULL DEFAULT 0,
+    updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT venue_buildings_pkey PRIMARY KEY (code)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.venue_buildings
+    OWNER to postgres;
+
+-- Table: public.venue_building_aliases
+
+-- DROP TABLE public.venue_building_aliases;
+
+CREATE TABLE public.venue_building_aliases
+(
+    alias text COLLATE pg_catalog."default" NOT NULL,
+    code text COLLATE pg_catalog."default" NOT NULL,
+    updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT venue_building_aliases_pkey PRIMARY KEY (alias)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.venue_building_aliases
+    OWNER to postgres;
+
+-- Table: public.venue_room_aliases
+
+-- DROP TABLE public.venue_room_aliases;
+
+CREATE TABLE public.venue_room_aliases
+(
+    alias text COLLATE pg_catalog."default" NOT NULL,
+    -- Empty when the alias applies in any building
+    building text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    room text COLLATE pg_catalog."default" NOT NULL,
+    updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT venue_room_aliases_pkey PRIMARY KEY (alias)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.venue_room_aliases
+    OWNER to postgres;
+
+-- Table: public.venue_walks
+
+-- DROP TABLE public.venue_walks;
+
+-- Stored once per pair of buildings, with from_code sorting first
+CREATE TABLE public.venue_walks
+(
+    from_code text COLLATE pg_catalog."default" NOT NULL,
+    to_code text COLLATE pg_catalog."default" NOT NULL,
+    minutes integer NOT NULL,
+    updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT venue_walks_pkey PRIMARY KEY (from_code, to_code),
+    CONSTRAINT venue_walks_order CHECK (from_code < to_code)
+)
+    WITH (
+        OIDS = FALSE
+    )
+    TABLESPACE pg_default;
+
+ALTER TABLE public.venue_walks
+    OWNER to postgres;
+
+-- Gen Con's buildings, walking times are rough and through the skywalks
+-- where there are any
+INSERT INTO public.venue_buildings (code, name, inside_walk_minutes) VALUES
+    ('CP', 'Crowne Plaza', 2),
+    ('DTM', 'Marriott Downtown', 2),
+    ('EMB', 'Embassy Suites', 2),
+    ('HYATT', 'Hyatt Regency', 2),
+    ('ICC', 'Indiana Convention Center', 5),
+    ('JW', 'JW Marriott', 3),
+    ('LOS', 'Lucas Oil Stadium', 5),
+    ('OMNI', 'Omni Severin', 1),
+    ('WES', 'Westin', 2)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO public.venue_building_aliases (alias, code) VALUES
+    ('convention center', 'ICC'),
+    ('crowne', 'CP'),
+    ('downtown marriott', 'DTM'),
+    ('embassy', 'EMB'),
+    ('hyatt', 'HYATT'),
+    ('indianapolis marriott downtown', 'DTM'),
+    ('lucas oil', 'LOS'),
+    ('marriott', 'DTM'),
+    ('omni', 'OMNI'),
+    ('stadium', 'LOS'),
+    ('union station', 'CP'),
+    ('westin', 'WES')
+ON CONFLICT (alias) DO NOTHING;
+
+INSERT INTO public.venue_walks (from_code, to_code, minutes) VALUES
+    ('CP', 'DTM', 8),
+    ('CP', 'EMB', 12),
+    ('CP', 'HYATT', 10),
+    ('CP', 'ICC', 5),
+    ('CP', 'JW', 8),
+    ('CP', 'LOS', 10),
+    ('CP', 'OMNI', 8),
+    ('CP', 'WES', 8),
+    ('DTM', 'EMB', 8),
+    ('DTM', 'HYATT', 8),
+    ('DTM', 'ICC', 6),
+    ('DTM', 'JW', 6),
+    ('DTM', 'LOS', 12),
+    ('DTM', 'OMNI', 10),
+    ('DTM', 'WES', 5),
+    ('EMB', 'HYATT', 4),
+    ('EMB', 'ICC', 8),
+    ('EMB', 'JW', 12),
+    ('EMB', 'LOS', 14),
+    ('EMB', 'OMNI', 6),
+    ('EMB', 'WES', 6),
+    ('HYATT', 'ICC', 7),
+    ('HYATT', 'JW', 12),
+    ('HYATT', 'LOS', 14),
+    ('HYATT', 'OMNI', 6),
+    ('HYATT', 'WES', 5),
+    ('ICC', 'JW', 6),
+    ('ICC', 'LOS', 8),
+    ('ICC', 'OMNI', 10),
+    ('ICC', 'WES', 5),
+    ('JW', 'LOS', 10),
+    ('JW', 'OMNI', 15),
+    ('JW', 'WES', 8),
+    ('LOS', 'OMNI', 15),
+    ('LOS', 'WES', 12),
+    ('OMNI', 'WES', 8)
+ON CONFLICT (from_code, to_code) DO NOTHING;
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/tickets"
+	"github.com/lib/pq"
+)
+
+func recordTicketSnapshots(tx *sql.Tx, snapshotEvents []*events.GenconEvent, taken time.Time) error {
+	if len(snapshotEvents) == 0 {
+		return nil
+	}
+	eventIds := make([]string, 0, len(snapshotEvents))
+	counts := make([]int64, 0, len(snapshotEvents))
+	for _, event := range snapshotEvents {
+		eventIds = append(eventIds, event.EventId)
+		counts = append(counts, int64(event.TicketsAvailable))
+	}
+
+	_, err := tx.Exec(`
+INSERT INTO ticket_snapshots (event_id, taken, tickets_available)
+SELECT s.event_id, $1, s.tickets_available
+FROM unnest($2::text[], $3::integer[]) AS s(event_id, tickets_available)
+ON CONFLICT DO NOTHING
+`, taken, pq.Array(eventIds), pq.Array(counts))
+	return err
+}
+
+// Snapshots for each event, oldest first, keyed by event id.
+func LoadTicketHistory(db *sql.DB, eventIds []string) (map[string][]tickets.Snapshot, error) {
+	rows, err := db.Query(`
+SELECT event_id, taken, tickets_available
+FROM ticket_snapshots
+WHERE event_id = ANY($1)
+ORDER BY taken
+`, pq.Array(eventIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]tickets.Snapshot)
+	for rows.Next() {
+		var eventId string
+		var snapshot tickets.Snapshot
+		if err = rows.Scan(&eventId, &snapshot.Taken, &snapshot.Tickets); err != nil {
+			return nil, err
+		}
+		history[eventId] = append(history[eventId], snapshot)
+	}
+	return history, nil
+}
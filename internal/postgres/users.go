@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/schedule"
 	"github.com/lib/pq"
 )
 
@@ -117,25 +118,47 @@ GROUP BY e.cluster_key, day_of_week
 }
 
 func LoadStarredEvents(db *sql.DB, userEmail string, year int) ([]*events.GenconEvent, error) {
-	fields := "e1." + strings.Join(eventFields(), ", e1.")
-	rows, err := db.Query(fmt.Sprintf(`
-SELECT %s, true, o.id
-FROM events e1 LEFT JOIN orgs o ON (lower(o.alias) = lower(e1.org_group))
-WHERE
-  e1.year = $2
-  AND e1.active
-  AND ( 
-    e1.event_id IN (SELECT event_id FROM starred_events WHERE email = $1)
+	return loadStarredEvents(db, userEmail, year, false)
+}
+
+// Starred events for calendar feeds. These also have the events the user
+// starred that were since cancelled, so calendars can mark them cancelled
+// instead of quietly dropping them. Cancelled sessions of a starred cluster
+// are left out, the user only wanted one of the sessions anyway.
+func LoadCalendarEvents(db *sql.DB, userEmail string, year int) ([]*events.GenconEvent, error) {
+	return loadStarredEvents(db, userEmail, year, true)
+}
+
+// SQL matching the events e1 that the user with the given email starred,
+// along with every session of the clusters they starred as a group. email is
+// SQL too, like a parameter or a column.
+func starredByCondition(email string) string {
+	return fmt.Sprintf(`(
+    e1.event_id IN (SELECT event_id FROM starred_events WHERE email = %[1]s)
     OR
     e1.cluster_key IN (
       SELECT e.cluster_key
       FROM 
         events e
-        JOIN (SELECT event_id FROM starred_events WHERE email = $1 AND level = 'group') s
+        JOIN (SELECT event_id FROM starred_events WHERE email = %[1]s AND level = 'group') s
         ON e.event_id = s.event_id
     )
+  )`, email)
+}
+
+func loadStarredEvents(db *sql.DB, userEmail string, year int, withCancelled bool) ([]*events.GenconEvent, error) {
+	fields := "e1." + strings.Join(eventFields(), ", e1.")
+	rows, err := db.Query(fmt.Sprintf(`
+SELECT %s, true, o.id
+FROM events e1 LEFT JOIN orgs o ON (lower(o.alias) = lower(e1.org_group))
+WHERE
+  e1.year = $2
+  AND (
+    e1.active
+    OR $3 AND e1.event_id IN (SELECT event_id FROM starred_events WHERE email = $1)
   )
-ORDER BY e1.start_time`, fields), userEmail, year)
+  AND %s
+ORDER BY e1.start_time`, fields, starredByCondition("$1")), userEmail, year, withCancelled)
 
 	if err != nil {
 		return nil, err
@@ -153,6 +176,79 @@ ORDER BY e1.start_time`, fields), userEmail, year)
 	return loadedEvents, nil
 }
 
+// How an event ended up on a user's starred list, keyed by event id. Events
+// starred as part of a cluster share a ClusterId with their siblings, which
+// lets callers tell alternative sessions apart from separate events.
+type StarredInfo struct {
+	Level     string // "group" or "event"
+	ClusterId string // lowest event id in the cluster
+}
+
+// Covers the same events as LoadStarredEvents, including sessions added to a
+// starred cluster after it was starred.
+func LoadStarredInfo(db *sql.DB, userEmail string, year int) (map[string]*StarredInfo, error) {
+	rows, err := db.Query(`
+WITH starred AS (
+    SELECT e.event_id, e.cluster_key, se.level
+    FROM starred_events se JOIN events e ON e.event_id = se.event_id
+    WHERE se.email = $1 AND e.year = $2
+)
+SELECT
+    e.event_id,
+    COALESCE((SELECT s.level FROM starred s WHERE s.event_id = e.event_id), 'group'),
+    MIN(e.event_id) OVER (PARTITION BY e.short_category, e.title, e.cluster_key)
+FROM events e
+WHERE e.year = $2
+  AND e.active
+  AND (
+    e.event_id IN (SELECT event_id FROM starred)
+    OR e.cluster_key IN (SELECT cluster_key FROM starred WHERE level = 'group')
+  )
+`, userEmail, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infoById := make(map[string]*StarredInfo)
+	for rows.Next() {
+		var eventId string
+		var info StarredInfo
+		if err = rows.Scan(&eventId, &info.Level, &info.ClusterId); err != nil {
+			return nil, err
+		}
+		infoById[eventId] = &info
+	}
+	return infoById, nil
+}
+
+// Starred events along with how they were starred, ready for the schedule
+// analyzer.
+func LoadStarredSchedule(db *sql.DB, userEmail string, year int) ([]*schedule.Entry, error) {
+	starredEvents, err := LoadStarredEvents(db, userEmail, year)
+	if err != nil {
+		return nil, err
+	}
+	infoById, err := LoadStarredInfo(db, userEmail, year)
+	if err != nil {
+		return nil, err
+	}
+	return StarredEntries(starredEvents, infoById), nil
+}
+
+func StarredEntries(starredEvents []*events.GenconEvent, infoById map[string]*StarredInfo) []*schedule.Entry {
+	entries := make([]*schedule.Entry, 0, len(starredEvents))
+	for _, event := range starredEvents {
+		entry := &schedule.Entry{Event: event, ClusterId: event.EventId}
+		if info, found := infoById[event.EventId]; found {
+			entry.GroupStarred = info.Level == "group"
+			entry.ClusterId = info.ClusterId
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 func UpdateStarredEvent(db *sql.DB, email string, eventId string, starGroup bool, add bool) (*UserStarredEvents, error) {
 	tx, err := db.Begin()
 	if err != nil {
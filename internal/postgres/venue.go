@@ -0,0 +1,414 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func loadBuildings(q querier) ([]*events.Building, error) {
+	rows, err := q.Query(`
+SELECT code, name, inside_walk_minutes
+FROM venue_buildings
+ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buildings := make([]*events.Building, 0)
+	for rows.Next() {
+		building := &events.Building{}
+		var insideMinutes int
+		if err = rows.Scan(&building.Code, &building.Name, &insideMinutes); err != nil {
+			return nil, err
+		}
+		building.InsideWalk = time.Duration(insideMinutes) * time.Minute
+		buildings = append(buildings, building)
+	}
+	return buildings, rows.Err()
+}
+
+func loadBuildingAliases(q querier) (map[string]string, error) {
+	rows, err := q.Query("SELECT alias, code FROM venue_building_aliases")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, code string
+		if err = rows.Scan(&alias, &code); err != nil {
+			return nil, err
+		}
+		aliases[alias] = code
+	}
+	return aliases, rows.Err()
+}
+
+func loadRoomAliases(q querier) ([]*events.RoomAlias, error) {
+	rows, err := q.Query(`
+SELECT alias, building, room
+FROM venue_room_aliases
+ORDER BY building, lower(alias)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make([]*events.RoomAlias, 0)
+	for rows.Next() {
+		alias := &events.RoomAlias{}
+		if err = rows.Scan(&alias.Alias, &alias.Building, &alias.Room); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+func loadWalks(q querier) ([]*events.Walk, error) {
+	rows, err := q.Query("SELECT from_code, to_code, minutes FROM venue_walks")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	walks := make([]*events.Walk, 0)
+	for rows.Next() {
+		walk := &events.Walk{}
+		var minutes int
+		if err = rows.Scan(&walk.From, &walk.To, &minutes); err != nil {
+			return nil, err
+		}
+		walk.Time = time.Duration(minutes) * time.Minute
+		walks = append(walks, walk)
+	}
+	return walks, rows.Err()
+}
+
+func loadVenue(q querier) (*events.Venue, error) {
+	buildings, err := loadBuildings(q)
+	if err != nil {
+		return nil, err
+	}
+	buildingAliases, err := loadBuildingAliases(q)
+	if err != nil {
+		return nil, err
+	}
+	roomAliases, err := loadRoomAliases(q)
+	if err != nil {
+		return nil, err
+	}
+	walks, err := loadWalks(q)
+	if err != nil {
+		return nil, err
+	}
+	return events.NewVenue(buildings, buildingAliases, roomAliases, walks), nil
+}
+
+// The venue for the import to place events with, and schedules to work out
+// walking times from
+func LoadVenue(db *sql.DB) (*events.Venue, error) {
+	return loadVenue(db)
+}
+
+// Everything on the venue admin page
+type VenueTables struct {
+	Buildings       []*events.Building
+	BuildingAliases []*BuildingAlias
+	RoomAliases     []*events.RoomAlias
+	Walks           []*events.Walk
+	// How many events each building has in the year asked for
+	EventCounts map[string]int
+	// Locations with events in the year that aren't in any building
+	Unplaced []*UnplacedLocation
+}
+
+type BuildingAlias struct {
+	Alias string
+	Code  string
+}
+
+type UnplacedLocation struct {
+	Location  string
+	NumEvents int
+}
+
+func LoadVenueTables(db *sql.DB, year int) (*VenueTables, error) {
+	tables := &VenueTables{EventCounts: make(map[string]int)}
+	var err error
+	if tables.Buildings, err = loadBuildings(db); err != nil {
+		return nil, err
+	}
+	if tables.RoomAliases, err = loadRoomAliases(db); err != nil {
+		return nil, err
+	}
+	if tables.Walks, err = loadWalks(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT alias, code FROM venue_building_aliases ORDER BY code, alias")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tables.BuildingAliases = make([]*BuildingAlias, 0)
+	for rows.Next() {
+		alias := &BuildingAlias{}
+		if err = rows.Scan(&alias.Alias, &alias.Code); err != nil {
+			return nil, err
+		}
+		tables.BuildingAliases = append(tables.BuildingAliases, alias)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts, err := db.Query(`
+SELECT building, coalesce(location, ''), count(1)
+FROM events
+WHERE year = $1 AND active
+GROUP BY building, coalesce(location, '')
+ORDER BY count(1) DESC, 2`, year)
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close()
+	tables.Unplaced = make([]*UnplacedLocation, 0)
+	for counts.Next() {
+		var building, location string
+		var numEvents int
+		if err = counts.Scan(&building, &location, &numEvents); err != nil {
+			return nil, err
+		}
+		if building == "" {
+			tables.Unplaced = append(tables.Unplaced, &UnplacedLocation{Location: location, NumEvents: numEvents})
+		} else {
+			tables.EventCounts[building] += numEvents
+		}
+	}
+	return tables, counts.Err()
+}
+
+// Adds or changes a building, returning how many events moved
+func SaveBuilding(db *sql.DB, code, name string, insideMinutes int) (changed int, err error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	name = strings.TrimSpace(name)
+	if code == "" || name == "" {
+		return 0, fmt.Errorf("a building needs a code and a name")
+	}
+	if insideMinutes < 0 {
+		return 0, fmt.Errorf("walking inside %v can't take %d minutes", name, insideMinutes)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	_, err = tx.Exec(`
+INSERT INTO venue_buildings (code, name, inside_walk_minutes) VALUES ($1, $2, $3)
+ON CONFLICT (code) DO UPDATE
+SET name = excluded.name, inside_walk_minutes = excluded.inside_walk_minutes, updated = now()`,
+		code, name, insideMinutes)
+	if err != nil {
+		return 0, err
+	}
+	return relocateEvents(tx)
+}
+
+// Removes a building with its aliases, room aliases and walking times,
+// returning how many events were in it
+func DeleteBuilding(db *sql.DB, code string) (changed int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	for _, statement := range []string{
+		"DELETE FROM venue_walks WHERE from_code = $1 OR to_code = $1",
+		"DELETE FROM venue_building_aliases WHERE code = $1",
+		"DELETE FROM venue_room_aliases WHERE building = $1",
+		"DELETE FROM venue_buildings WHERE code = $1",
+	} {
+		if _, err = tx.Exec(statement, code); err != nil {
+			return 0, err
+		}
+	}
+	return relocateEvents(tx)
+}
+
+// Adds or changes another name for a building, returning how many events
+// moved
+func SaveBuildingAlias(db *sql.DB, alias, code string) (changed int, err error) {
+	alias = events.NormalizePlace(alias)
+	if alias == "" || code == "" {
+		return 0, fmt.Errorf("an alias needs a name and a building")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	var found bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM venue_buildings WHERE code = $1)", code).Scan(&found)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		err = fmt.Errorf("there's no building %q", code)
+		return 0, err
+	}
+	_, err = tx.Exec(`
+INSERT INTO venue_building_aliases (alias, code) VALUES ($1, $2)
+ON CONFLICT (alias) DO UPDATE SET code = excluded.code, updated = now()`, alias, code)
+	if err != nil {
+		return 0, err
+	}
+	return relocateEvents(tx)
+}
+
+func DeleteBuildingAlias(db *sql.DB, alias string) (changed int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	if _, err = tx.Exec("DELETE FROM venue_building_aliases WHERE alias = $1", alias); err != nil {
+		return 0, err
+	}
+	return relocateEvents(tx)
+}
+
+// Adds or changes a room alias, building can be empty for an alias that
+// applies anywhere. Returns how many events moved.
+func SaveRoomAlias(db *sql.DB, alias, building, room string) (changed int, err error) {
+	alias = strings.TrimSpace(alias)
+	room = strings.TrimSpace(room)
+	if alias == "" || room == "" {
+		return 0, fmt.Errorf("a room alias needs both names")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	if building != "" {
+		var found bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM venue_buildings WHERE code = $1)", building).Scan(&found)
+		if err != nil {
+			return 0, err
+		}
+		if !found {
+			err = fmt.Errorf("there's no building %q", building)
+			return 0, err
+		}
+	}
+	_, err = tx.Exec(`
+INSERT INTO venue_room_aliases (alias, building, room) VALUES ($1, $2, $3)
+ON CONFLICT (alias) DO UPDATE SET building = excluded.building, room = excluded.room, updated = now()`,
+		alias, building, room)
+	if err != nil {
+		return 0, err
+	}
+	return relocateEvents(tx)
+}
+
+func DeleteRoomAlias(db *sql.DB, alias string) (changed int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	if _, err = tx.Exec("DELETE FROM venue_room_aliases WHERE alias = $1", alias); err != nil {
+		return 0, err
+	}
+	return relocateEvents(tx)
+}
+
+// Sets the walking times between buildings, in minutes. Negative minutes
+// clear a walking time, so schedules go back to guessing.
+func SaveWalks(db *sql.DB, walks []*events.Walk) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { CleanupTransaction(err, tx) }()
+
+	for _, walk := range walks {
+		from, to := walk.From, walk.To
+		if to < from {
+			from, to = to, from
+		}
+		if from == to {
+			err = fmt.Errorf("walks inside %v are set on the building", from)
+			return err
+		}
+		if walk.Time < 0 {
+			_, err = tx.Exec("DELETE FROM venue_walks WHERE from_code = $1 AND to_code = $2", from, to)
+		} else {
+			_, err = tx.Exec(`
+INSERT INTO venue_walks (from_code, to_code, minutes) VALUES ($1, $2, $3)
+ON CONFLICT (from_code, to_code) DO UPDATE SET minutes = excluded.minutes, updated = now()`,
+				from, to, int(walk.Time/time.Minute))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Places every event again with the venue as it is in the transaction.
+// Only the building and room are touched, the feed's location stays.
+func relocateEvents(tx *sql.Tx) (int, error) {
+	venue, err := loadVenue(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query("SELECT event_id, location, room_name, building, room FROM events")
+	if err != nil {
+		return 0, err
+	}
+	moved := make([]*events.GenconEvent, 0)
+	for rows.Next() {
+		event := &events.GenconEvent{}
+		var location, roomName sql.NullString
+		err = rows.Scan(&event.EventId, &location, &roomName, &event.Building, &event.Room)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		event.Location, event.RoomName = location.String, roomName.String
+		if venue.Locate(event) {
+			moved = append(moved, event)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, event := range moved {
+		_, err = tx.Exec("UPDATE events SET building = $2, room = $3 WHERE event_id = $1",
+			event.EventId, event.Building, event.Room)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(moved), nil
+}
@@ -0,0 +1,243 @@
+package schedule
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+// One event on a user's starred list. Events starred as a cluster are
+// alternatives to each other, the user only needs to make one of them.
+type Entry struct {
+	Event        *events.GenconEvent
+	GroupStarred bool
+	ClusterId    string
+}
+
+type ConflictKind string
+
+const (
+	// The two events run at the same time
+	Overlap ConflictKind = "overlap"
+	// There's a gap between them, but not enough to walk from one to the next
+	TravelBuffer ConflictKind = "travel"
+)
+
+type Conflict struct {
+	Kind ConflictKind
+	// First starts no later than Second
+	First  *events.GenconEvent
+	Second *events.GenconEvent
+	// Time between First ending and Second starting, negative when they overlap
+	Gap time.Duration
+	// How long it takes to get from First's location to Second's
+	TravelTime time.Duration
+	// Other sessions from a group starred cluster which avoid this clash.
+	// They clash with something else, or the conflict wouldn't be reported.
+	Alternatives []*events.GenconEvent
+}
+
+// Estimates how long it takes to get from one event to the next
+type TravelTimeFunc func(from, to *events.GenconEvent) time.Duration
+
+// Without knowing the layout of the venue, assume anything in a different
+// building takes a short walk, and everything in the same building is next
+// door.
+const DefaultWalkingTime = 10 * time.Minute
+
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
+
+func DefaultTravelTime(from, to *events.GenconEvent) time.Duration {
+	fromLocation := normalizeLocation(from.Location)
+	toLocation := normalizeLocation(to.Location)
+	if fromLocation == "" || toLocation == "" || fromLocation == toLocation {
+		return 0
+	}
+	return DefaultWalkingTime
+}
+
+// Walking times from the venue, falling back on DefaultTravelTime for
+// events it can't place. A nil venue is the same as DefaultTravelTime.
+func VenueTravelTime(venue *events.Venue) TravelTimeFunc {
+	if venue == nil {
+		return DefaultTravelTime
+	}
+	return func(from, to *events.GenconEvent) time.Duration {
+		if walk, known := venue.WalkingTime(from, to); known {
+			return walk
+		}
+		return DefaultTravelTime(from, to)
+	}
+}
+
+type Analyzer struct {
+	TravelTime TravelTimeFunc
+}
+
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{TravelTime: DefaultTravelTime}
+}
+
+func NewVenueAnalyzer(venue *events.Venue) *Analyzer {
+	return &Analyzer{TravelTime: VenueTravelTime(venue)}
+}
+
+// Returns the conflict between the two events, or nil if they fit. The
+// events can be passed in either order.
+func (a *Analyzer) Check(x, y *events.GenconEvent) *Conflict {
+	first, second := x, y
+	if second.StartTime.Before(first.StartTime) {
+		first, second = second, first
+	}
+
+	gap := second.StartTime.Sub(first.EndTime)
+	if gap < 0 {
+		return &Conflict{Kind: Overlap, First: first, Second: second, Gap: gap}
+	}
+
+	travel := a.TravelTime(first, second)
+	if gap < travel {
+		return &Conflict{Kind: TravelBuffer, First: first, Second: second, Gap: gap, TravelTime: travel}
+	}
+	return nil
+}
+
+func (a *Analyzer) clashesWithAny(event *events.GenconEvent, others []*events.GenconEvent) bool {
+	for _, other := range others {
+		if a.Check(event, other) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Sessions in the cluster with tickets left that don't clash with any of the
+// individually starred events.
+func (a *Analyzer) alternatives(cluster []*Entry, fixed []*events.GenconEvent) []*events.GenconEvent {
+	alternatives := make([]*events.GenconEvent, 0)
+	for _, sibling := range cluster {
+		if sibling.Event.TicketsAvailable <= 0 {
+			continue
+		}
+		if a.clashesWithAny(sibling.Event, fixed) {
+			continue
+		}
+		alternatives = append(alternatives, sibling.Event)
+	}
+	return alternatives
+}
+
+// Finds every pair of starred events the user can't make both of.
+//
+// Individually starred events always have to be attended, so any clash
+// between two of them is reported. A group starred cluster only conflicts
+// with an individually starred event when none of its sessions with tickets
+// left fit around every individually starred event. That's reported once per
+// event and cluster, with the sessions that would avoid that one event. Two
+// group starred clusters only conflict if no pair of their sessions fit
+// together. Otherwise there's nothing for the user to fix.
+func (a *Analyzer) FindConflicts(entries []*Entry) []*Conflict {
+	sorted := make([]*Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Event.StartTime.Before(sorted[j].Event.StartTime)
+	})
+
+	clusters := make(map[string][]*Entry)
+	fixed := make([]*events.GenconEvent, 0)
+	for _, entry := range sorted {
+		if entry.GroupStarred {
+			clusters[entry.ClusterId] = append(clusters[entry.ClusterId], entry)
+		} else {
+			fixed = append(fixed, entry.Event)
+		}
+	}
+
+	// Whether each cluster has a session that fits around every individually
+	// starred event, in which case it doesn't conflict with any of them
+	clusterFits := make(map[string]bool)
+	for clusterId, cluster := range clusters {
+		clusterFits[clusterId] = len(a.alternatives(cluster, fixed)) > 0
+	}
+
+	conflicts := make([]*Conflict, 0)
+	// Group vs group conflicts are per cluster pair, and group vs individual
+	// per cluster and event, remember which we've already decided on.
+	checkedClusterPairs := make(map[[2]string]bool)
+	checkedClusterEvents := make(map[[2]string]bool)
+
+	for i, first := range sorted {
+		for _, second := range sorted[i+1:] {
+			if len(first.ClusterId) > 0 && first.ClusterId == second.ClusterId {
+				// Sessions of the same cluster are alternatives
+				continue
+			}
+
+			switch {
+			case !first.GroupStarred && !second.GroupStarred:
+				if conflict := a.Check(first.Event, second.Event); conflict != nil {
+					conflicts = append(conflicts, conflict)
+				}
+			case first.GroupStarred && second.GroupStarred:
+				key := [2]string{first.ClusterId, second.ClusterId}
+				if key[1] < key[0] {
+					key[0], key[1] = key[1], key[0]
+				}
+				if checkedClusterPairs[key] {
+					continue
+				}
+				checkedClusterPairs[key] = true
+				if conflict := a.clusterConflict(clusters[key[0]], clusters[key[1]]); conflict != nil {
+					conflicts = append(conflicts, conflict)
+				}
+			default:
+				group, single := first, second
+				if second.GroupStarred {
+					group, single = second, first
+				}
+				if clusterFits[group.ClusterId] {
+					continue
+				}
+				key := [2]string{group.ClusterId, single.Event.EventId}
+				if checkedClusterEvents[key] {
+					continue
+				}
+				conflict := a.Check(first.Event, second.Event)
+				if conflict == nil {
+					continue
+				}
+				checkedClusterEvents[key] = true
+				conflict.Alternatives = a.alternatives(clusters[group.ClusterId], []*events.GenconEvent{single.Event})
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// Reports a conflict between the earliest pair of sessions when no pair of
+// sessions with tickets left fit together. Sold out sessions can't get the
+// user out of a clash, just like with alternatives.
+func (a *Analyzer) clusterConflict(firstCluster, secondCluster []*Entry) *Conflict {
+	var earliest *Conflict
+	for _, x := range firstCluster {
+		for _, y := range secondCluster {
+			conflict := a.Check(x.Event, y.Event)
+			if conflict == nil {
+				if x.Event.TicketsAvailable > 0 && y.Event.TicketsAvailable > 0 {
+					return nil
+				}
+				continue
+			}
+			if earliest == nil {
+				earliest = conflict
+			}
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+var baseTime = time.Date(2025, time.July, 31, 8, 0, 0, 0, time.UTC)
+
+func testEvent(id string, startHour, hours float64, location string) *events.GenconEvent {
+	start := baseTime.Add(time.Duration(startHour * float64(time.Hour)))
+	return &events.GenconEvent{
+		EventId:          id,
+		StartTime:        start,
+		EndTime:          start.Add(time.Duration(hours * float64(time.Hour))),
+		Location:         location,
+		TicketsAvailable: 4,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	first := testEvent("A", 0, 2, "ICC")
+	if conflict := analyzer.Check(first, testEvent("B", 1, 2, "ICC")); conflict == nil || conflict.Kind != Overlap {
+		t.Errorf("Expected an overlap, got %+v", conflict)
+	}
+	if conflict := analyzer.Check(first, testEvent("C", 2, 1, "ICC")); conflict != nil {
+		t.Errorf("Back to back in the same building should be fine, got %+v", conflict)
+	}
+	conflict := analyzer.Check(testEvent("D", 2, 1, "JW Marriott"), first)
+	if conflict == nil || conflict.Kind != TravelBuffer {
+		t.Fatalf("Expected a travel conflict, got %+v", conflict)
+	}
+	if conflict.First.EventId != "A" {
+		t.Errorf("Expected the earlier event first, got %v", conflict.First.EventId)
+	}
+}
+
+func TestFindConflictsIgnoresClustersWithASessionThatFits(t *testing.T) {
+	entries := []*Entry{
+		{Event: testEvent("FIXED", 0, 2, "ICC")},
+		{Event: testEvent("G1", 1, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+		{Event: testEvent("G2", 4, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+		{Event: testEvent("G3", 1.5, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+	}
+	if conflicts := NewAnalyzer().FindConflicts(entries); len(conflicts) != 0 {
+		t.Errorf("G2 fits, expected no conflicts, got %+v", conflicts)
+	}
+
+	// Sold out sessions don't count as fitting
+	entries[2].Event.TicketsAvailable = 0
+	if conflicts := NewAnalyzer().FindConflicts(entries); len(conflicts) != 1 {
+		t.Errorf("Expected one conflict with the cluster, got %+v", conflicts)
+	}
+}
+
+func TestFindConflictsSuggestsSiblings(t *testing.T) {
+	entries := []*Entry{
+		{Event: testEvent("MORNING", 0, 2, "ICC")},
+		{Event: testEvent("AFTERNOON", 4, 1, "ICC")},
+		{Event: testEvent("G1", 1, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+		{Event: testEvent("G2", 4, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+		{Event: testEvent("G3", 1.5, 1, "ICC"), GroupStarred: true, ClusterId: "G1"},
+	}
+	conflicts := NewAnalyzer().FindConflicts(entries)
+
+	// Once per starred event, not once per clashing session
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %d: %+v", len(conflicts), conflicts)
+	}
+	want := map[string][]string{"MORNING": {"G2"}, "AFTERNOON": {"G1", "G3"}}
+	for _, conflict := range conflicts {
+		single := conflict.First.EventId
+		if _, found := want[single]; !found {
+			single = conflict.Second.EventId
+		}
+		got := make([]string, 0)
+		for _, alternative := range conflict.Alternatives {
+			got = append(got, alternative.EventId)
+		}
+		if strings.Join(got, ",") != strings.Join(want[single], ",") {
+			t.Errorf("Expected %v as alternatives to %v, got %v", want[single], single, got)
+		}
+	}
+}
+
+func TestFindConflictsBetweenClusters(t *testing.T) {
+	entries := []*Entry{
+		{Event: testEvent("A1", 0, 2, "ICC"), GroupStarred: true, ClusterId: "A1"},
+		{Event: testEvent("A2", 4, 2, "ICC"), GroupStarred: true, ClusterId: "A1"},
+		{Event: testEvent("B1", 1, 2, "ICC"), GroupStarred: true, ClusterId: "B1"},
+		{Event: testEvent("C1", 0, 6, "ICC"), GroupStarred: true, ClusterId: "C1"},
+	}
+	conflicts := NewAnalyzer().FindConflicts(entries)
+
+	// A and B can be played by taking A2, but C covers both A sessions and B
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %d: %+v", len(conflicts), conflicts)
+	}
+	for _, conflict := range conflicts {
+		if conflict.First.EventId != "C1" && conflict.Second.EventId != "C1" {
+			t.Errorf("Expected every conflict to involve C1, got %v and %v",
+				conflict.First.EventId, conflict.Second.EventId)
+		}
+	}
+}
+
+func TestFindConflictsBetweenClustersIgnoresSoldOutSessions(t *testing.T) {
+	entries := []*Entry{
+		{Event: testEvent("A1", 0, 2, "ICC"), GroupStarred: true, ClusterId: "A1"},
+		{Event: testEvent("A2", 4, 2, "ICC"), GroupStarred: true, ClusterId: "A1"},
+		{Event: testEvent("B1", 1, 2, "ICC"), GroupStarred: true, ClusterId: "B1"},
+	}
+	if conflicts := NewAnalyzer().FindConflicts(entries); len(conflicts) != 0 {
+		t.Fatalf("A2 fits around B1, expected no conflicts, got %+v", conflicts)
+	}
+
+	// A2 is the only way out, without tickets the clusters clash
+	entries[1].Event.TicketsAvailable = 0
+	conflicts := NewAnalyzer().FindConflicts(entries)
+	if len(conflicts) != 1 || conflicts[0].First.EventId != "A1" || conflicts[0].Second.EventId != "B1" {
+		t.Errorf("Expected A1 and B1 to conflict, got %+v", conflicts)
+	}
+}
+
+func TestVenueTravelTime(t *testing.T) {
+	venue := events.NewVenue(
+		[]*events.Building{
+			{Code: "ICC", Name: "Indiana Convention Center", InsideWalk: 5 * time.Minute},
+			{Code: "LOS", Name: "Lucas Oil Stadium"},
+			{Code: "JW", Name: "JW Marriott"},
+		},
+		map[string]string{"Lucas Oil": "LOS"},
+		nil,
+		[]*events.Walk{{From: "LOS", To: "ICC", Time: 12 * time.Minute}},
+	)
+	analyzer := NewVenueAnalyzer(venue)
+	located := func(id string, startHour, hours float64, location, room string) *events.GenconEvent {
+		event := testEvent(id, startHour, hours, location)
+		event.RoomName = room
+		venue.Locate(event)
+		return event
+	}
+
+	first := located("A", 0, 2, "ICC", "Room 101")
+	conflict := analyzer.Check(first, located("B", 2.1, 1, "Lucas Oil", "Field"))
+	if conflict == nil || conflict.TravelTime != 12*time.Minute || conflict.Gap != 6*time.Minute {
+		t.Errorf("Expected a 12 minute walk with a 6 minute gap, got %+v", conflict)
+	}
+	if conflict := analyzer.Check(first, located("C", 2.25, 1, "Lucas Oil", "Field")); conflict != nil {
+		t.Errorf("15 minutes is enough for a 12 minute walk, got %+v", conflict)
+	}
+	if conflict := analyzer.Check(first, located("D", 2, 1, "icc", "Hall A")); conflict == nil || conflict.TravelTime != 5*time.Minute {
+		t.Errorf("Expected the walk across the ICC, got %+v", conflict)
+	}
+	if conflict := analyzer.Check(first, located("E", 2, 1, "ICC", "room  101")); conflict != nil {
+		t.Errorf("Same room should be fine back to back, got %+v", conflict)
+	}
+	// No walking time between the ICC and the JW, so it's a guess
+	if conflict := analyzer.Check(first, located("F", 2, 1, "J.W. Marriott", "")); conflict == nil || conflict.TravelTime != DefaultWalkingTime {
+		t.Errorf("Expected the default walk, got %+v", conflict)
+	}
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+type Block struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (b Block) Duration() time.Duration {
+	return b.End.Sub(b.Start)
+}
+
+// Midnight at the start of each day from first to last, inclusive, in the
+// location first is in.
+func Days(first, last time.Time) []time.Time {
+	days := make([]time.Time, 0)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+	for !day.After(last) {
+		days = append(days, day)
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+// FreeBlocks finds the stretches of each day where none of the busy events
+// are running. Only time between dayStart and dayEnd, measured from each
+// day's midnight, counts, and blocks shorter than minLength are dropped.
+func FreeBlocks(busy []*events.GenconEvent, days []time.Time, dayStart, dayEnd, minLength time.Duration) []Block {
+	sorted := make([]*events.GenconEvent, len(busy))
+	copy(sorted, busy)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	blocks := make([]Block, 0)
+	addBlock := func(start, end time.Time) {
+		if end.Sub(start) >= minLength {
+			blocks = append(blocks, Block{Start: start, End: end})
+		}
+	}
+
+	for _, day := range days {
+		windowStart := day.Add(dayStart)
+		windowEnd := day.Add(dayEnd)
+
+		free := windowStart
+		for _, event := range sorted {
+			if !event.EndTime.After(free) {
+				continue
+			}
+			if !event.StartTime.Before(windowEnd) {
+				break
+			}
+			if event.StartTime.After(free) {
+				addBlock(free, event.StartTime)
+			}
+			free = event.EndTime
+		}
+		if free.Before(windowEnd) {
+			addBlock(free, windowEnd)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func TestFreeBlocks(t *testing.T) {
+	day := time.Date(2025, time.July, 31, 0, 0, 0, 0, time.UTC)
+	busy := []*events.GenconEvent{
+		testEvent("B", 4, 2, "ICC"),
+		testEvent("A", 0, 2, "ICC"),
+		// Overlaps A, so doesn't open up any extra time
+		testEvent("C", 1, 1.5, "ICC"),
+	}
+
+	blocks := FreeBlocks(busy, []time.Time{day}, 8*time.Hour, 20*time.Hour, time.Hour)
+	expected := []Block{
+		{Start: day.Add(10*time.Hour + 30*time.Minute), End: day.Add(12 * time.Hour)},
+		{Start: day.Add(14 * time.Hour), End: day.Add(20 * time.Hour)},
+	}
+	if len(blocks) != len(expected) {
+		t.Fatalf("Expected %v blocks, got %+v", len(expected), blocks)
+	}
+	for i := range expected {
+		if !blocks[i].Start.Equal(expected[i].Start) || !blocks[i].End.Equal(expected[i].End) {
+			t.Errorf("Block %v: expected %+v, got %+v", i, expected[i], blocks[i])
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	first := time.Date(2025, time.July, 31, 15, 0, 0, 0, time.UTC)
+	last := time.Date(2025, time.August, 3, 9, 0, 0, 0, time.UTC)
+	days := Days(first, last)
+	if len(days) != 4 {
+		t.Fatalf("Expected 4 days, got %v", days)
+	}
+	if days[0].Hour() != 0 || days[3].Day() != 3 {
+		t.Errorf("Unexpected days %v", days)
+	}
+}
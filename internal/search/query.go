@@ -0,0 +1,352 @@
+// Package search parses the query language used in the search box, like
+// `pathfinder cat:RPG cost<=4 day:sat start>18`, into text terms and field
+// filters.
+package search
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+type Op string
+
+const (
+	Contains     Op = ":"
+	Equals       Op = "="
+	Less         Op = "<"
+	LessEqual    Op = "<="
+	Greater      Op = ">"
+	GreaterEqual Op = ">="
+	Between      Op = ".."
+)
+
+type Kind int
+
+const (
+	// Matches anywhere in the field, or all of it with =
+	Text Kind = iota
+	// Matches the start of the field, for Gen Con's long age labels
+	Prefix
+	// Exact, case insensitive
+	Exact
+	Number
+	// Dollars and cents, held as cents
+	Dollars
+	// Hours of the day, fractions allowed
+	Hour
+	Day
+	Bool
+	// An attendee's age, events they're old enough and young enough for.
+	// Anything that isn't a number is matched against the age labels.
+	Ages
+	// Experience levels, none, some or expert, in that order
+	Level
+)
+
+type Field struct {
+	Name string
+	Kind Kind
+}
+
+var (
+	Category     = Field{"cat", Exact}
+	System       = Field{"system", Text}
+	Title        = Field{"title", Text}
+	Org          = Field{"org", Text}
+	GM           = Field{"gm", Text}
+	Location     = Field{"location", Text}
+	Cost         = Field{"cost", Dollars}
+	Tickets      = Field{"tickets", Number}
+	Players      = Field{"players", Number}
+	DayOfWeek    = Field{"day", Day}
+	Start        = Field{"start", Hour}
+	End          = Field{"end", Hour}
+	Age          = Field{"age", Ages}
+	Experience   = Field{"exp", Level}
+	Registration = Field{"reg", Exact}
+	Tournament   = Field{"tournament", Bool}
+
+	// What age filters that aren't numbers become, like age:teen
+	AgeLabel = Field{"age", Prefix}
+)
+
+// Exact fields that only take certain values
+var choices = map[Field][]string{
+	Registration: {"ticket", "free", "closed"},
+}
+
+// Every name a field goes by in a query
+var fieldsByName = map[string]Field{
+	"cat":          Category,
+	"category":     Category,
+	"system":       System,
+	"game":         System,
+	"title":        Title,
+	"org":          Org,
+	"group":        Org,
+	"gm":           GM,
+	"location":     Location,
+	"loc":          Location,
+	"cost":         Cost,
+	"price":        Cost,
+	"tickets":      Tickets,
+	"players":      Players,
+	"day":          DayOfWeek,
+	"start":        Start,
+	"end":          End,
+	"age":          Age,
+	"exp":          Experience,
+	"experience":   Experience,
+	"reg":          Registration,
+	"registration": Registration,
+	"tournament":   Tournament,
+}
+
+var days = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thur": 4, "thurs": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
+
+// One field condition. Number holds the parsed value of numeric, hour, day,
+// age and level fields, cents for dollars and 1 for true, High the top of a
+// Between range.
+type Filter struct {
+	Field  Field
+	Op     Op
+	Value  string
+	Number float64
+	High   float64
+	Negate bool
+}
+
+type Query struct {
+	// Words and quoted phrases for the full text search, negated ones
+	// start with -
+	Text    []string
+	Filters []Filter
+	// Problems with individual terms, which are left out of the search
+	Errors []string
+}
+
+// Splits on spaces, except inside double quotes, which are dropped.
+// Returns the terms and whether each contained quotes.
+func tokenize(raw string) ([]string, []bool) {
+	terms := make([]string, 0)
+	quotedTerms := make([]bool, 0)
+	var current strings.Builder
+	inQuotes, quoted := false, false
+	flush := func() {
+		if current.Len() > 0 || quoted {
+			terms = append(terms, current.String())
+			quotedTerms = append(quotedTerms, quoted)
+		}
+		current.Reset()
+		quoted = false
+	}
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return terms, quotedTerms
+}
+
+// Finds key<op>value, the longest operator wins so <= isn't read as <
+func splitFilter(term string) (key string, op Op, value string, found bool) {
+	for i, r := range term {
+		if !unicode.IsLetter(r) {
+			if i == 0 {
+				return "", "", "", false
+			}
+			rest := term[i:]
+			for _, candidate := range []Op{LessEqual, GreaterEqual, Contains, Equals, Less, Greater} {
+				if strings.HasPrefix(rest, string(candidate)) {
+					return strings.ToLower(term[:i]), candidate, rest[len(candidate):], true
+				}
+			}
+			return "", "", "", false
+		}
+	}
+	return "", "", "", false
+}
+
+func parseNumber(field Field, value string) (float64, error) {
+	switch field.Kind {
+	case Number:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s needs a whole number, not %q", field.Name, value)
+		}
+		return float64(n), nil
+	case Dollars:
+		d, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%s needs an amount in dollars, like 4 or 2.50, not %q", field.Name, value)
+		}
+		return math.Round(d * 100), nil
+	case Ages:
+		a, err := strconv.Atoi(strings.TrimSuffix(value, "+"))
+		if err != nil || a < 0 {
+			return 0, fmt.Errorf("%s needs an age in years, not %q", field.Name, value)
+		}
+		return float64(a), nil
+	case Level:
+		level, found := events.LookupExperienceLevel(value)
+		if !found {
+			return 0, fmt.Errorf("%s needs none, some or expert, not %q", field.Name, value)
+		}
+		return float64(level), nil
+	case Hour:
+		h, err := strconv.ParseFloat(value, 64)
+		if err != nil || h < 0 || h > 24 {
+			return 0, fmt.Errorf("%s needs an hour from 0 to 24, not %q", field.Name, value)
+		}
+		return h, nil
+	case Day:
+		d, found := days[strings.ToLower(value)]
+		if !found {
+			return 0, fmt.Errorf("%q isn't a day, try wed, thu, fri, sat or sun", value)
+		}
+		return float64(d), nil
+	case Bool:
+		switch strings.ToLower(value) {
+		case "yes", "y", "true":
+			return 1, nil
+		case "no", "n", "false":
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%s needs yes or no, not %q", field.Name, value)
+	}
+	return 0, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFilter(field Field, op Op, value string, negate bool) (Filter, error) {
+	filter := Filter{Field: field, Op: op, Value: value, Negate: negate}
+	if value == "" {
+		return filter, fmt.Errorf("%s%s needs a value", field.Name, op)
+	}
+
+	ordered := field.Kind == Number || field.Kind == Dollars || field.Kind == Hour || field.Kind == Level
+	if op != Contains && op != Equals && !ordered {
+		return filter, fmt.Errorf("%s can't be compared with %s", field.Name, op)
+	}
+
+	switch field.Kind {
+	case Text, Prefix:
+		return filter, nil
+	case Exact:
+		if allowed, limited := choices[field]; limited && !containsFold(allowed, value) {
+			last := len(allowed) - 1
+			return filter, fmt.Errorf("%s needs %s or %s, not %q",
+				field.Name, strings.Join(allowed[:last], ", "), allowed[last], value)
+		}
+		return filter, nil
+	case Ages:
+		if _, err := strconv.Atoi(strings.TrimSuffix(value, "+")); err != nil {
+			filter.Field = AgeLabel
+			return filter, nil
+		}
+	}
+
+	if low, high, isRange := strings.Cut(value, string(Between)); isRange && op == Contains {
+		if !ordered {
+			return filter, fmt.Errorf("%s can't be a range", field.Name)
+		}
+		var err error
+		filter.Op = Between
+		if filter.Number, err = parseNumber(field, low); err != nil {
+			return filter, err
+		}
+		if filter.High, err = parseNumber(field, high); err != nil {
+			return filter, err
+		}
+		if filter.High < filter.Number {
+			return filter, fmt.Errorf("%s range %s is backwards", field.Name, value)
+		}
+		return filter, nil
+	}
+
+	var err error
+	filter.Number, err = parseNumber(field, value)
+	return filter, err
+}
+
+// Parse never fails outright, terms it can't make sense of are reported in
+// Errors and left out.
+func Parse(raw string) *Query {
+	query := &Query{
+		Text:    make([]string, 0),
+		Filters: make([]Filter, 0),
+		Errors:  make([]string, 0),
+	}
+
+	terms, quoted := tokenize(raw)
+	for i, term := range terms {
+		negate := false
+		if strings.HasPrefix(term, "-") {
+			term = strings.TrimPrefix(term, "-")
+			negate = true
+		}
+
+		if key, op, value, found := splitFilter(term); found {
+			field, known := fieldsByName[key]
+			if !known {
+				query.Errors = append(query.Errors, fmt.Sprintf("Unknown field %q", key))
+				continue
+			}
+			filter, err := parseFilter(field, op, value, negate)
+			if err != nil {
+				query.Errors = append(query.Errors, err.Error())
+				continue
+			}
+			query.Filters = append(query.Filters, filter)
+			continue
+		}
+
+		// Anything else is for the text search, with the punctuation the
+		// search syntax cares about dropped.
+		text := strings.TrimSpace(strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`!&|()<>=~:'"`, r) {
+				return -1
+			}
+			return r
+		}, term))
+		if len(text) == 0 {
+			continue
+		}
+		if quoted[i] {
+			text = `"` + text + `"`
+		}
+		if negate {
+			text = "-" + text
+		}
+		query.Text = append(query.Text, text)
+	}
+	return query
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	query := Parse(`dragon -goblin "lost mine" Sci-Fi`)
+	expected := []string{"dragon", "-goblin", `"lost mine"`, "Sci-Fi"}
+	if !reflect.DeepEqual(query.Text, expected) {
+		t.Errorf("Expected %q, got %q", expected, query.Text)
+	}
+	if len(query.Filters) != 0 || len(query.Errors) != 0 {
+		t.Errorf("Expected only text, got %+v", query)
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	query := Parse(`cat:RPG system:"Pathfinder Society" cost<=4 tickets>0 players>=6 day:sat start>18 ` +
+		`org:"Paizo" age:teen exp:none tournament:yes -title~x players:3..5 age:8+ exp<=some reg:free cost:$2.50`)
+	if len(query.Errors) != 0 {
+		t.Fatalf("Unexpected errors %v", query.Errors)
+	}
+
+	expected := []Filter{
+		{Field: Category, Op: Contains, Value: "RPG"},
+		{Field: System, Op: Contains, Value: "Pathfinder Society"},
+		{Field: Cost, Op: LessEqual, Value: "4", Number: 400},
+		{Field: Tickets, Op: Greater, Value: "0", Number: 0},
+		{Field: Players, Op: GreaterEqual, Value: "6", Number: 6},
+		{Field: DayOfWeek, Op: Contains, Value: "sat", Number: 6},
+		{Field: Start, Op: Greater, Value: "18", Number: 18},
+		{Field: Org, Op: Contains, Value: "Paizo"},
+		{Field: AgeLabel, Op: Contains, Value: "teen"},
+		{Field: Experience, Op: Contains, Value: "none", Number: 1},
+		{Field: Tournament, Op: Contains, Value: "yes", Number: 1},
+		{Field: Players, Op: Between, Value: "3..5", Number: 3, High: 5},
+		{Field: Age, Op: Contains, Value: "8+", Number: 8},
+		{Field: Experience, Op: LessEqual, Value: "some", Number: 2},
+		{Field: Registration, Op: Contains, Value: "free"},
+		{Field: Cost, Op: Contains, Value: "$2.50", Number: 250},
+	}
+	if !reflect.DeepEqual(query.Filters, expected) {
+		t.Errorf("Expected\n%+v\ngot\n%+v", expected, query.Filters)
+	}
+	// title~x isn't an operator we know, so it's just text
+	if !reflect.DeepEqual(query.Text, []string{"-titlex"}) {
+		t.Errorf("Unexpected text %q", query.Text)
+	}
+}
+
+func TestParseNegatedFilter(t *testing.T) {
+	query := Parse("-cat:LRP")
+	if len(query.Filters) != 1 || !query.Filters[0].Negate {
+		t.Errorf("Expected a negated filter, got %+v", query.Filters)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"color:red":     `Unknown field "color"`,
+		"cost<=cheap":   `cost needs an amount in dollars, like 4 or 2.50, not "cheap"`,
+		"age>8":         "age can't be compared with >",
+		"age:-3":        `age needs an age in years, not "-3"`,
+		"exp:guru":      `exp needs none, some or expert, not "guru"`,
+		"reg:maybe":     `reg needs ticket, free or closed, not "maybe"`,
+		"day:someday":   `"someday" isn't a day, try wed, thu, fri, sat or sun`,
+		"start>25":      `start needs an hour from 0 to 24, not "25"`,
+		"cat>RPG":       "cat can't be compared with >",
+		"tournament:eh": `tournament needs yes or no, not "eh"`,
+		"players:6..3":  "players range 6..3 is backwards",
+		"system:":       "system: needs a value",
+		"day:wed..fri":  "day can't be a range",
+	}
+	for raw, expected := range cases {
+		query := Parse(raw + " dragon")
+		if len(query.Errors) != 1 || query.Errors[0] != expected {
+			t.Errorf("%v: expected error %q, got %q", raw, expected, query.Errors)
+		}
+		if len(query.Filters) != 0 || len(query.Text) != 1 {
+			t.Errorf("%v: bad terms should be dropped, got %+v", raw, query)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Package siteurl builds absolute links back to the site, for pages and api
+// responses that hand out urls to be opened somewhere else.
+package siteurl
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The scheme and host this request came in on. Heroku terminates TLS before
+// it reaches us, so trust the forwarded header when it's there.
+func Base(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); len(forwarded) > 0 {
+		scheme = forwarded
+	}
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
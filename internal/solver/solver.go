@@ -0,0 +1,259 @@
+package solver
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/schedule"
+)
+
+// Something the user wants to play, with every session they could play it in.
+type Cluster struct {
+	Id       string
+	Title    string
+	Priority int
+	Sessions []*events.GenconEvent
+}
+
+// A window during the day where the user needs some free time to eat.
+// Windows are measured from midnight in Indianapolis.
+type MealBreak struct {
+	Name        string
+	WindowStart time.Duration
+	WindowEnd   time.Duration
+	Length      time.Duration
+}
+
+type Constraints struct {
+	// No sessions starting before this, measured from midnight
+	DayStart time.Duration
+	// No sessions ending after this, measured from midnight. 24 hours means
+	// sessions can run right up to midnight.
+	DayEnd     time.Duration
+	Meals      []MealBreak
+	TravelTime schedule.TravelTimeFunc
+}
+
+var Lunch = MealBreak{Name: "Lunch", WindowStart: 11 * time.Hour, WindowEnd: 14 * time.Hour, Length: 45 * time.Minute}
+var Dinner = MealBreak{Name: "Dinner", WindowStart: 17 * time.Hour, WindowEnd: 20 * time.Hour, Length: time.Hour}
+
+func DefaultConstraints() Constraints {
+	return Constraints{
+		DayStart:   8 * time.Hour,
+		DayEnd:     24 * time.Hour,
+		Meals:      []MealBreak{Lunch, Dinner},
+		TravelTime: schedule.DefaultTravelTime,
+	}
+}
+
+type Pick struct {
+	Cluster *Cluster
+	Session *events.GenconEvent
+}
+
+type Itinerary struct {
+	// Sorted by start time
+	Picks []*Pick
+	// Clusters that couldn't fit, highest priority first
+	Unscheduled   []*Cluster
+	TotalPriority int
+	// Set when the search gave up before proving this is the best itinerary
+	Truncated bool
+}
+
+// Upper bound on how many partial itineraries we'll look at. Big wishlists
+// with lots of sessions can blow up, past this we return the best we found.
+const maxNodes = 500000
+
+type search struct {
+	clusters    []*Cluster
+	candidates  [][]*events.GenconEvent
+	remaining   []int // priority still available from clusters[i:]
+	constraints Constraints
+	analyzer    *schedule.Analyzer
+
+	chosen    []*events.GenconEvent
+	choices   []int
+	score     int
+	best      []int
+	bestScore int
+	nodes     int
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Whether the session fits inside the user's day, ignoring everything else.
+func (c *Constraints) allows(session *events.GenconEvent) bool {
+	if !session.Active || session.TicketsAvailable <= 0 {
+		return false
+	}
+	start := session.StartTime.In(events.Indianapolis)
+	end := session.EndTime.In(events.Indianapolis)
+	dayStart := midnight(start)
+	return !start.Before(dayStart.Add(c.DayStart)) && !end.After(dayStart.Add(c.DayEnd))
+}
+
+// Checks every meal window on the session's day still has room to eat,
+// given everything already chosen plus the session.
+func (s *search) mealsFit(session *events.GenconEvent) bool {
+	if len(s.constraints.Meals) == 0 {
+		return true
+	}
+	day := midnight(session.StartTime.In(events.Indianapolis))
+
+	busy := []*events.GenconEvent{session}
+	for _, chosen := range s.chosen {
+		if midnight(chosen.StartTime.In(events.Indianapolis)).Equal(day) {
+			busy = append(busy, chosen)
+		}
+	}
+	sort.Slice(busy, func(i, j int) bool {
+		return busy[i].StartTime.Before(busy[j].StartTime)
+	})
+
+	for _, meal := range s.constraints.Meals {
+		windowStart := day.Add(meal.WindowStart)
+		windowEnd := day.Add(meal.WindowEnd)
+
+		free := windowStart
+		fits := false
+		for _, event := range busy {
+			if !event.StartTime.After(free) {
+				if event.EndTime.After(free) {
+					free = event.EndTime
+				}
+				continue
+			}
+			gapEnd := event.StartTime
+			if gapEnd.After(windowEnd) {
+				gapEnd = windowEnd
+			}
+			if gapEnd.Sub(free) >= meal.Length {
+				fits = true
+				break
+			}
+			if event.EndTime.After(free) {
+				free = event.EndTime
+			}
+		}
+		if !fits && windowEnd.Sub(free) >= meal.Length {
+			fits = true
+		}
+		if !fits {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *search) fits(session *events.GenconEvent) bool {
+	for _, chosen := range s.chosen {
+		if s.analyzer.Check(chosen, session) != nil {
+			return false
+		}
+	}
+	return s.mealsFit(session)
+}
+
+func (s *search) explore(i int) {
+	s.nodes++
+	if s.score > s.bestScore || s.best == nil {
+		s.bestScore = s.score
+		s.best = append(s.best[:0], s.choices...)
+	}
+	if i == len(s.clusters) || s.nodes > maxNodes {
+		return
+	}
+	// Even if everything left fits, we can't beat what we have
+	if s.score+s.remaining[i] <= s.bestScore {
+		return
+	}
+
+	for j, session := range s.candidates[i] {
+		if !s.fits(session) {
+			continue
+		}
+		s.chosen = append(s.chosen, session)
+		s.choices[i] = j
+		s.score += s.clusters[i].Priority
+
+		s.explore(i + 1)
+
+		s.score -= s.clusters[i].Priority
+		s.choices[i] = -1
+		s.chosen = s.chosen[:len(s.chosen)-1]
+	}
+
+	// And try leaving this cluster out entirely
+	s.explore(i + 1)
+}
+
+// Solve picks at most one session from each cluster so nothing overlaps,
+// every day stays inside the user's limits with time for meals, and the
+// priority of the clusters that make it in is as high as possible.
+//
+// Clusters with a priority of zero or less are skipped.
+func Solve(clusters []*Cluster, constraints Constraints) *Itinerary {
+	if constraints.TravelTime == nil {
+		constraints.TravelTime = schedule.DefaultTravelTime
+	}
+
+	s := &search{
+		constraints: constraints,
+		analyzer:    &schedule.Analyzer{TravelTime: constraints.TravelTime},
+	}
+	itinerary := &Itinerary{Picks: make([]*Pick, 0), Unscheduled: make([]*Cluster, 0)}
+
+	for _, cluster := range clusters {
+		if cluster.Priority <= 0 {
+			continue
+		}
+		s.clusters = append(s.clusters, cluster)
+	}
+	// Deciding on the most important clusters first gets a good itinerary
+	// early, which lets the bound cut off more of the search.
+	sort.SliceStable(s.clusters, func(i, j int) bool {
+		return s.clusters[i].Priority > s.clusters[j].Priority
+	})
+
+	s.candidates = make([][]*events.GenconEvent, len(s.clusters))
+	for i, cluster := range s.clusters {
+		for _, session := range cluster.Sessions {
+			if constraints.allows(session) {
+				s.candidates[i] = append(s.candidates[i], session)
+			}
+		}
+	}
+
+	s.remaining = make([]int, len(s.clusters)+1)
+	for i := len(s.clusters) - 1; i >= 0; i-- {
+		s.remaining[i] = s.remaining[i+1] + s.clusters[i].Priority
+	}
+	s.choices = make([]int, len(s.clusters))
+	for i := range s.choices {
+		s.choices[i] = -1
+	}
+
+	s.explore(0)
+	itinerary.Truncated = s.nodes > maxNodes
+	itinerary.TotalPriority = s.bestScore
+
+	for i, choice := range s.best {
+		if choice < 0 {
+			itinerary.Unscheduled = append(itinerary.Unscheduled, s.clusters[i])
+			continue
+		}
+		itinerary.Picks = append(itinerary.Picks, &Pick{
+			Cluster: s.clusters[i],
+			Session: s.candidates[i][choice],
+		})
+	}
+	sort.Slice(itinerary.Picks, func(i, j int) bool {
+		return itinerary.Picks[i].Session.StartTime.Before(itinerary.Picks[j].Session.StartTime)
+	})
+
+	return itinerary
+}
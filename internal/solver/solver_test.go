@@ -0,0 +1,80 @@
+package solver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+)
+
+func session(id string, day int, startHour, hours float64) *events.GenconEvent {
+	start := time.Date(2025, time.July, day, 0, 0, 0, 0, events.Indianapolis).
+		Add(time.Duration(startHour * float64(time.Hour)))
+	return &events.GenconEvent{
+		EventId:          id,
+		Active:           true,
+		StartTime:        start,
+		EndTime:          start.Add(time.Duration(hours * float64(time.Hour))),
+		Location:         "ICC",
+		TicketsAvailable: 2,
+	}
+}
+
+func pickedIds(itinerary *Itinerary) map[string]bool {
+	ids := make(map[string]bool)
+	for _, pick := range itinerary.Picks {
+		ids[pick.Session.EventId] = true
+	}
+	return ids
+}
+
+func TestSolvePrefersHigherPriority(t *testing.T) {
+	clusters := []*Cluster{
+		{Id: "low", Priority: 1, Sessions: []*events.GenconEvent{session("low", 31, 9, 2)}},
+		{Id: "high", Priority: 5, Sessions: []*events.GenconEvent{session("high", 31, 10, 1)}},
+	}
+	constraints := DefaultConstraints()
+	constraints.Meals = nil
+	itinerary := Solve(clusters, constraints)
+
+	picks := pickedIds(itinerary)
+	if !picks["high"] || picks["low"] {
+		t.Errorf("Expected only the high priority session, got %v", picks)
+	}
+	if itinerary.TotalPriority != 5 {
+		t.Errorf("Expected total priority 5, got %v", itinerary.TotalPriority)
+	}
+}
+
+func TestSolveUsesSiblingSessions(t *testing.T) {
+	soldOut := session("a-sold-out", 31, 13, 2)
+	soldOut.TicketsAvailable = 0
+	clusters := []*Cluster{
+		{Id: "a", Priority: 3, Sessions: []*events.GenconEvent{
+			session("a-morning", 31, 9, 2), soldOut, session("a-evening", 31, 20, 2),
+		}},
+		{Id: "b", Priority: 3, Sessions: []*events.GenconEvent{session("b", 31, 9, 3)}},
+		{Id: "c", Priority: 2, Sessions: []*events.GenconEvent{session("c-early", 31, 6, 2)}},
+	}
+	itinerary := Solve(clusters, DefaultConstraints())
+
+	picks := pickedIds(itinerary)
+	if !picks["a-evening"] || !picks["b"] {
+		t.Errorf("Expected a-evening and b, got %v", picks)
+	}
+	if len(itinerary.Unscheduled) != 1 || itinerary.Unscheduled[0].Id != "c" {
+		t.Errorf("Expected c to start too early, got %v", itinerary.Unscheduled)
+	}
+}
+
+func TestSolveLeavesTimeForMeals(t *testing.T) {
+	clusters := []*Cluster{
+		{Id: "morning", Priority: 1, Sessions: []*events.GenconEvent{session("morning", 31, 10, 2)}},
+		{Id: "afternoon", Priority: 1, Sessions: []*events.GenconEvent{session("afternoon", 31, 12, 2.5)}},
+	}
+	itinerary := Solve(clusters, DefaultConstraints())
+
+	if len(itinerary.Picks) != 1 {
+		t.Errorf("Expected one session to be dropped for lunch, got %v", pickedIds(itinerary))
+	}
+}
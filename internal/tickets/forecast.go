@@ -0,0 +1,113 @@
+// Package tickets works with the history of how many tickets an event had
+// left over time.
+package tickets
+
+import (
+	"sort"
+	"time"
+)
+
+// How many tickets were left as of an import. Imports only record a new
+// snapshot when the count moves, so the count holds until the next one.
+type Snapshot struct {
+	Taken   time.Time `json:"taken"`
+	Tickets int       `json:"tickets"`
+}
+
+// Only recent sales say much about how fast an event is going now.
+const ForecastWindow = 48 * time.Hour
+
+// Combine adds up the histories of several sessions into one, as of every
+// time any of them changed.
+func Combine(series [][]Snapshot) []Snapshot {
+	times := make([]time.Time, 0)
+	for _, snapshots := range series {
+		for _, snapshot := range snapshots {
+			times = append(times, snapshot.Taken)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	combined := make([]Snapshot, 0, len(times))
+	positions := make([]int, len(series))
+	for i, at := range times {
+		if i > 0 && at.Equal(times[i-1]) {
+			continue
+		}
+		total := 0
+		for s, snapshots := range series {
+			for positions[s] < len(snapshots) && !snapshots[positions[s]].Taken.After(at) {
+				positions[s]++
+			}
+			if positions[s] > 0 {
+				total += snapshots[positions[s]-1].Tickets
+			}
+		}
+		combined = append(combined, Snapshot{Taken: at, Tickets: total})
+	}
+	return combined
+}
+
+type Forecast struct {
+	// Tickets lost per hour over the window, positive when selling
+	RatePerHour float64
+	// When we expect none to be left, nil if they aren't going down
+	SellOutBy *time.Time
+}
+
+// Estimate fits a line through the snapshots in the window before now and
+// projects when it reaches zero. Snapshots must be sorted oldest first.
+func Estimate(snapshots []Snapshot, now time.Time) Forecast {
+	if len(snapshots) == 0 {
+		return Forecast{}
+	}
+	current := snapshots[len(snapshots)-1]
+	if current.Tickets <= 0 {
+		soldOut := current.Taken
+		return Forecast{SellOutBy: &soldOut}
+	}
+
+	// The count held since the last snapshot, which is a point on the line
+	// too. Without it a burst of sales long ago would look current.
+	points := make([]Snapshot, 0, len(snapshots)+1)
+	windowStart := now.Add(-ForecastWindow)
+	for i, snapshot := range snapshots {
+		if snapshot.Taken.Before(windowStart) {
+			if i+1 < len(snapshots) && !snapshots[i+1].Taken.After(windowStart) {
+				continue
+			}
+			// Whatever was left as the window opened
+			snapshot.Taken = windowStart
+		}
+		points = append(points, snapshot)
+	}
+	points = append(points, Snapshot{Taken: now, Tickets: current.Tickets})
+	if len(points) < 2 {
+		return Forecast{}
+	}
+
+	// Least squares slope of tickets over hours
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.Taken.Sub(windowStart).Hours()
+		y := float64(p.Tickets)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return Forecast{}
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+
+	forecast := Forecast{RatePerHour: -slope}
+	if slope < 0 {
+		hoursLeft := float64(current.Tickets) / -slope
+		sellOut := now.Add(time.Duration(hoursLeft * float64(time.Hour)))
+		forecast.SellOutBy = &sellOut
+	}
+	return forecast
+}
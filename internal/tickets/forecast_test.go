@@ -0,0 +1,60 @@
+package tickets
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, time.May, 18, 12, 0, 0, 0, time.UTC)
+
+func at(hours float64, tickets int) Snapshot {
+	return Snapshot{Taken: start.Add(time.Duration(hours * float64(time.Hour))), Tickets: tickets}
+}
+
+func TestCombine(t *testing.T) {
+	combined := Combine([][]Snapshot{
+		{at(0, 10), at(2, 6)},
+		{at(1, 4), at(2, 3)},
+	})
+	expected := []Snapshot{at(0, 10), at(1, 14), at(2, 9)}
+	if len(combined) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, combined)
+	}
+	for i := range expected {
+		if !combined[i].Taken.Equal(expected[i].Taken) || combined[i].Tickets != expected[i].Tickets {
+			t.Errorf("Snapshot %v: expected %v, got %v", i, expected[i], combined[i])
+		}
+	}
+}
+
+func TestEstimateSelling(t *testing.T) {
+	// Two tickets an hour, 10 left at hour 4
+	snapshots := []Snapshot{at(0, 18), at(1, 16), at(2, 14), at(3, 12), at(4, 10)}
+	forecast := Estimate(snapshots, start.Add(4*time.Hour))
+	if forecast.RatePerHour < 1.9 || forecast.RatePerHour > 2.1 {
+		t.Errorf("Expected about 2 an hour, got %v", forecast.RatePerHour)
+	}
+	if forecast.SellOutBy == nil {
+		t.Fatalf("Expected a sell out time")
+	}
+	expected := start.Add(9 * time.Hour)
+	if diff := forecast.SellOutBy.Sub(expected); diff > 10*time.Minute || diff < -10*time.Minute {
+		t.Errorf("Expected to sell out around %v, got %v", expected, forecast.SellOutBy)
+	}
+}
+
+func TestEstimateIdle(t *testing.T) {
+	// Sold fast long ago, nothing in the last few days
+	snapshots := []Snapshot{at(0, 20), at(1, 10)}
+	forecast := Estimate(snapshots, start.Add(100*time.Hour))
+	if forecast.SellOutBy != nil {
+		t.Errorf("Expected no forecast for a quiet event, got %v", forecast.SellOutBy)
+	}
+}
+
+func TestEstimateSoldOut(t *testing.T) {
+	forecast := Estimate([]Snapshot{at(0, 4), at(1, 0)}, start.Add(5*time.Hour))
+	if forecast.SellOutBy == nil || !forecast.SellOutBy.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected sold out at hour 1, got %v", forecast.SellOutBy)
+	}
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/ical"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/siteurl"
+	"github.com/gin-gonic/gin"
+)
+
+// Subscribe links use webcal so phones offer to add the feed instead of
+// downloading it once.
+func calendarFeedUrl(c *gin.Context, token string, year int) string {
+	feedUrl := fmt.Sprintf("%s/calendar/%s/%d.ics", siteurl.Base(c), token, year)
+	return "webcal" + strings.TrimPrefix(strings.TrimPrefix(feedUrl, "https"), "http")
+}
+
+func renderCalendar(c *gin.Context, db *sql.DB, user *postgres.User, year int, filename string) {
+	starredEvents, err := postgres.LoadCalendarEvents(db, user.Email, year)
+	if err != nil {
+		log.Printf("Error loading starred events")
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	cal := ical.Calendar{
+		Name:    fmt.Sprintf("Gen Con %d - %s", year, user.DisplayName),
+		BaseUrl: siteurl.Base(c),
+		Events:  starredEvents,
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	if len(filename) > 0 {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err = cal.Write(c.Writer); err != nil {
+		log.Printf("Error writing calendar: %v", err)
+	}
+}
+
+// Downloads the signed in user's starred events as an .ics file
+func StarredCalendar(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.User == nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		year, err := strconv.Atoi(c.Param("year"))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		renderCalendar(c, db, appContext.User, year, fmt.Sprintf("gencon-%d.ics", year))
+	}
+}
+
+// Serves the private feed url calendar apps subscribe to. There's no signin
+// cookie on these requests, the token is the only credential.
+func CalendarFeed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("token")
+		year, err := strconv.Atoi(strings.TrimSuffix(c.Param("year"), ".ics"))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := postgres.LoadUserByCalendarToken(db, token)
+		if err != nil {
+			log.Printf("Error loading calendar token: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if user == nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		renderCalendar(c, db, user, year, "")
+	}
+}
+
+// Issues a new feed token, for when a subscription url was shared by mistake
+func ResetCalendarFeed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.User == nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		year, err := strconv.Atoi(c.PostForm("year"))
+		if err != nil {
+			year = time.Now().Year()
+		}
+
+		_, err = postgres.ResetCalendarToken(db, appContext.User.Email)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, fmt.Sprintf("/starred/%d", year))
+	}
+}
@@ -56,9 +56,10 @@ func CategoryList(db *sql.DB) func(c *gin.Context) {
 		}
 
 		c.HTML(http.StatusOK, "categories.html", gin.H{
-			"title":      "Main website",
-			"categories": categories,
-			"context":    context,
+			"title":         "Main website",
+			"categories":    categories,
+			"context":       context,
+			"lastRefreshed": loadLastRefreshed(db),
 		})
 	}
 }
@@ -102,6 +103,7 @@ func ViewCategory(db *sql.DB) func(c *gin.Context) {
 			"breakdown":     "Category",
 			"pageHeader":    "Search",
 			"subHeader":     params.Category,
+			"lastRefreshed": loadLastRefreshed(db),
 		})
 	}
 }
@@ -50,7 +50,31 @@ func allStarred(events []*events.GenconEvent) bool {
 	return true
 }
 
-func renderHtml(c *gin.Context, result *LookupResult, appContext *Context) {
+// What's happened to the event since the user last looked at it. Anyone who
+// hasn't been here before, or isn't signed in, gets the whole history.
+func loadChangesSinceVisit(db *sql.DB, eventId string, appContext *Context) ([]*events.EventChange, time.Time) {
+	var lastVisit time.Time
+	if appContext.Email != "" {
+		page := "event:" + eventId
+		var err error
+		if lastVisit, err = postgres.LastVisit(db, appContext.Email, page); err != nil {
+			log.Printf("Error loading last visit: %v", err)
+		}
+		if err = postgres.RecordVisit(db, appContext.Email, page); err != nil {
+			log.Printf("Error recording visit: %v", err)
+		}
+	}
+
+	changes, err := postgres.LoadEventChanges(db, []string{eventId}, lastVisit)
+	if err != nil {
+		// The event is still worth showing without its history
+		log.Printf("Error loading event changes: %v", err)
+		return nil, lastVisit
+	}
+	return events.CollapseChanges(changes), lastVisit
+}
+
+func renderHtml(c *gin.Context, result *LookupResult, appContext *Context, changes []*events.EventChange, lastVisit time.Time, lastRefreshed *time.Time) {
 	starred := true
 	for _, loadedEvents := range result.EventsPerDay {
 		starred = starred && allStarred(loadedEvents)
@@ -59,10 +83,13 @@ func renderHtml(c *gin.Context, result *LookupResult, appContext *Context) {
 	c.Header("Cache-Control", fmt.Sprintf("max-age=%d", nextUpdateTime.Sub(time.Now())/time.Second))
 
 	c.HTML(http.StatusOK, "event.html", gin.H{
-		"result":       result,
-		"eventsPerDay": result.EventsPerDay,
-		"context":      appContext,
-		"allStarred":   starred,
+		"result":        result,
+		"eventsPerDay":  result.EventsPerDay,
+		"context":       appContext,
+		"allStarred":    starred,
+		"changes":       changes,
+		"lastVisit":     lastVisit,
+		"lastRefreshed": lastRefreshed,
 	})
 }
 
@@ -103,7 +130,8 @@ func ViewEvent(db *sql.DB) gin.HandlerFunc {
 		if json {
 			renderJson(c, result, appContext)
 		} else {
-			renderHtml(c, result, appContext)
+			changes, lastVisit := loadChangesSinceVisit(db, eventId, appContext)
+			renderHtml(c, result, appContext, changes, lastVisit, loadLastRefreshed(db))
 		}
 	}
 }
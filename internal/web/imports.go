@@ -0,0 +1,53 @@
+package web
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// When the event data was last refreshed, in Indianapolis time. Pages still
+// render without it, so errors are only logged.
+func loadLastRefreshed(db *sql.DB) *time.Time {
+	lastRefreshed, err := postgres.LastRefreshed(db)
+	if err != nil {
+		log.Printf("Error loading last refresh time: %v", err)
+		return nil
+	}
+	if lastRefreshed != nil {
+		local := lastRefreshed.In(postgres.INDIANAPOLIS)
+		lastRefreshed = &local
+	}
+	return lastRefreshed
+}
+
+func ViewImports(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		appContext.Year = time.Now().Year()
+
+		runs, err := postgres.LoadImportRuns(db, 100)
+		if err != nil {
+			log.Printf("Error loading import runs: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		for _, run := range runs {
+			run.Started = run.Started.In(postgres.INDIANAPOLIS)
+			if run.Finished != nil {
+				finished := run.Finished.In(postgres.INDIANAPOLIS)
+				run.Finished = &finished
+			}
+		}
+
+		c.HTML(http.StatusOK, "imports.html", gin.H{
+			"context":       appContext,
+			"runs":          runs,
+			"lastRefreshed": loadLastRefreshed(db),
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/schedule"
+	"github.com/Encinarus/genconplanner/internal/solver"
+	"github.com/gin-gonic/gin"
+)
+
+// Meal checkboxes are only sent when checked, so once the limits form has
+// been submitted a missing box means the user doesn't want that meal.
+func mealRequested(c *gin.Context, meal string) bool {
+	if _, submitted := c.GetQuery("limits"); !submitted {
+		return true
+	}
+	_, found := c.GetQuery(meal)
+	return found
+}
+
+func itineraryConstraints(c *gin.Context) solver.Constraints {
+	constraints := solver.DefaultConstraints()
+	dayStart := parseHour(c, "day_start", -1)
+	if dayStart >= 0 {
+		constraints.DayStart = time.Duration(dayStart) * time.Hour
+	}
+	dayEnd := parseHour(c, "day_end", -1)
+	if dayEnd > 0 {
+		constraints.DayEnd = time.Duration(dayEnd) * time.Hour
+	}
+
+	constraints.Meals = nil
+	if mealRequested(c, "lunch") {
+		constraints.Meals = append(constraints.Meals, solver.Lunch)
+	}
+	if mealRequested(c, "dinner") {
+		constraints.Meals = append(constraints.Meals, solver.Dinner)
+	}
+	return constraints
+}
+
+// Shows the starred clusters for ranking, and the itinerary the solver
+// proposes from those rankings.
+func ItineraryPage(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		year, err := strconv.Atoi(c.Param("year"))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		appContext.Year = year
+
+		if appContext.Email == "" {
+			c.HTML(http.StatusUnauthorized, "signin.html", gin.H{
+				"context":  appContext,
+				"redirect": c.Request.URL,
+			})
+			return
+		}
+
+		clusters, err := postgres.LoadItineraryClusters(db, appContext.Email, year)
+		if err != nil {
+			log.Printf("Error loading clusters: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		constraints := itineraryConstraints(c)
+		venue, err := postgres.LoadVenue(db)
+		if err != nil {
+			log.Printf("Error loading the venue: %v", err)
+		}
+		constraints.TravelTime = schedule.VenueTravelTime(venue)
+		itinerary := solver.Solve(clusters, constraints)
+
+		c.Header("Cache-Control", "no-cache")
+		c.HTML(http.StatusOK, "itinerary.html", gin.H{
+			"context":   appContext,
+			"clusters":  clusters,
+			"itinerary": itinerary,
+			"dayStart":  int(constraints.DayStart / time.Hour),
+			"dayEnd":    int(constraints.DayEnd / time.Hour),
+			"lunch":     mealRequested(c, "lunch"),
+			"dinner":    mealRequested(c, "dinner"),
+			// The user's limits carry over when saving priorities
+			"priorityAction": template.URL(fmt.Sprintf("/itinerary/%d/priorities?%s", year, c.Request.URL.RawQuery)),
+			"levels":         []int{0, 1, 2, 3, 4, 5},
+		})
+	}
+}
+
+func SetItineraryPriorities(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		year, err := strconv.Atoi(c.Param("year"))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		for clusterId, rawPriority := range c.PostFormMap("priority") {
+			priority, err := strconv.Atoi(rawPriority)
+			if err != nil {
+				log.Printf("Couldn't parse priority %v for %v", rawPriority, clusterId)
+				continue
+			}
+			err = postgres.SetClusterPriority(db, appContext.Email, year, clusterId, priority)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		c.Redirect(http.StatusSeeOther, fmt.Sprintf("/itinerary/%d?%s", year, c.Request.URL.RawQuery))
+	}
+}
+
+// Turns the proposed itinerary into event level stars
+func AcceptItinerary(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		year, err := strconv.Atoi(c.Param("year"))
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		eventIds := c.PostFormArray("eventId")
+		log.Printf("Accepting itinerary of %v events for %v", len(eventIds), appContext.Email)
+		if err = postgres.AcceptItinerary(db, appContext.Email, eventIds); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		c.Redirect(http.StatusSeeOther, fmt.Sprintf("/starred/%d", year))
+	}
+}
@@ -11,7 +11,6 @@ import (
 
 func MergeOrgs(db *sql.DB) gin.HandlerFunc {
 	return func (c *gin.Context) {
-		// TODO(alek): add acl check here. I guess I need a concept of admin users
 		stringOrgIds, ok := c.GetPostFormArray("id")
 		if !ok {
 			log.Printf("Unable to get array")
@@ -42,7 +41,6 @@ func MergeOrgs(db *sql.DB) gin.HandlerFunc {
 
 func ViewOrgs(db *sql.DB) gin.HandlerFunc {
 	return func (c *gin.Context) {
-		// TODO(alek): add acl check here. I guess I need a concept of admin users
 		orgs, err := postgres.LoadAllOrgs(db)
 		if err != nil {
 			c.Error(err)
@@ -2,7 +2,11 @@ package web
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/schedule"
+	"github.com/Encinarus/genconplanner/internal/siteurl"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -11,40 +15,104 @@ import (
 	"time"
 )
 
+// Free time for the party only counts during reasonable gaming hours
+const partyDayStart = 8 * time.Hour
+const partyDayEnd = 24 * time.Hour
+
+// Loads the party named in the url, making sure the signed in user is in
+// it. Aborts the request and returns nil otherwise.
+func loadMemberParty(c *gin.Context, db *sql.DB) *postgres.Party {
+	appContext := c.MustGet("context").(*Context)
+	if appContext.Email == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil
+	}
+
+	partyId, err := strconv.ParseInt(strings.TrimSpace(c.Param("party_id")), 10, 64)
+	if err != nil {
+		log.Printf("Error parsing party_id")
+		c.AbortWithError(http.StatusBadRequest, err)
+		return nil
+	}
+
+	party, err := postgres.LoadParty(db, appContext.User, partyId)
+	if err != nil {
+		log.Printf("Error loading parties: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return nil
+	}
+	if party == nil {
+		// Either it doesn't exist, or they aren't in it. Don't leak which.
+		c.AbortWithStatus(http.StatusNotFound)
+		return nil
+	}
+	return party
+}
+
+func partyUrl(party *postgres.Party) string {
+	return fmt.Sprintf("/party/%d", party.Id)
+}
+
 func Party(db *sql.DB) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		appContext := c.MustGet("context").(*Context)
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+		appContext.Year = int(party.Year)
 
-		if appContext.Email == "" {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		partyEvents, err := postgres.LoadPartySchedule(db, party)
+		if err != nil {
+			log.Printf("Error loading party schedule: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
 			return
 		}
 
-		var partyId int64
-		var err error
-		if len(strings.TrimSpace(c.Param("party_id"))) > 0 {
-			partyId, err = strconv.ParseInt(c.Param("party_id"), 10, 64)
-			if err != nil {
-				log.Printf("Error parsing party_id")
-				c.AbortWithError(http.StatusBadRequest, err)
-				return
+		sharedEvents := make([]*postgres.PartyEvent, 0)
+		busy := make([]*events.GenconEvent, 0, len(partyEvents))
+		for _, partyEvent := range partyEvents {
+			// Sessions of group starred clusters are alternatives, they
+			// only say someone would like to play one of them
+			if !partyEvent.Optional {
+				busy = append(busy, partyEvent.Event)
+			}
+			if len(partyEvent.Members) > 1 {
+				sharedEvents = append(sharedEvents, partyEvent)
 			}
-		} else {
-			log.Printf("No party id provided")
 		}
 
-		var party *postgres.Party
-		parties, err := postgres.LoadParties(db, appContext.User)
-		for _, p := range parties {
-			if p.Id == partyId {
-				party = p
-				break
-			}
+		startDate := GenconStartDate(appContext.Year)
+		endDate := GenconEndDate(appContext.Year)
+		firstDay, _ := time.ParseInLocation("2006-01-02", startDate, postgres.INDIANAPOLIS)
+		lastDay, _ := time.ParseInLocation("2006-01-02", endDate, postgres.INDIANAPOLIS)
+		// Only bother with blocks long enough to actually play something
+		freeBlocks := schedule.FreeBlocks(busy, schedule.Days(firstDay, lastDay),
+			partyDayStart, partyDayEnd, time.Hour)
+
+		invites, err := postgres.LoadPartyInvites(db, party.Id)
+		if err != nil {
+			log.Printf("Error loading party invites: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		inviteUrls := make(map[string]string)
+		for _, invite := range invites {
+			inviteUrls[invite.Token] = invite.Url(siteurl.Base(c))
 		}
 
+		c.Header("Cache-Control", "no-cache")
 		c.HTML(http.StatusOK, "party.html", gin.H{
-			"party":       party,
+			"party":        party,
+			"isOwner":      party.IsOwner(appContext.Email),
+			"invites":      invites,
+			"inviteUrls":   inviteUrls,
 			"context":      appContext,
+			"partyEvents":  partyEvents,
+			"sharedEvents": sharedEvents,
+			"freeBlocks":   freeBlocks,
+			"startDate":    startDate,
+			"endDate":      endDate,
 		})
 	}
 }
@@ -64,7 +132,7 @@ func NewParty(db *sql.DB) func(c *gin.Context) {
 		partyName := c.PostForm("partyName")
 		year, err := strconv.ParseInt(c.PostForm("year"), 10, 64)
 		if err != nil {
-			log.Printf("Couldn't parse %v, defaulting to this year")
+			log.Printf("Couldn't parse %v, defaulting to this year", c.PostForm("year"))
 			year = int64(time.Now().Year())
 		}
 		log.Printf("Creating a new party: %v, %v, with %v as a member\n", partyName, year, appContext.Email)
@@ -83,3 +151,199 @@ func NewParty(db *sql.DB) func(c *gin.Context) {
 		})
 	}
 }
+
+// Any member can invite more people. Without an email this makes a join
+// link that works for whoever it's shared with.
+func InviteToParty(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+
+		email := strings.ToLower(strings.TrimSpace(c.PostForm("email")))
+		_, err := postgres.CreatePartyInvite(db, party.Id, appContext.Email, email)
+		if err != nil {
+			log.Printf("Couldn't create invite: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, partyUrl(party))
+	}
+}
+
+func RevokePartyInvite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+
+		invite, err := postgres.LoadPartyInvite(db, c.PostForm("token"))
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if invite == nil || invite.PartyId != party.Id {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		if err = postgres.DeletePartyInvite(db, invite.Token); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, partyUrl(party))
+	}
+}
+
+// Loads the invite named in the url, if the signed in user is allowed to
+// use it. Aborts the request and returns nil otherwise.
+func loadUsableInvite(c *gin.Context, db *sql.DB) *postgres.PartyInvite {
+	appContext := c.MustGet("context").(*Context)
+	invite, err := postgres.LoadPartyInvite(db, c.Param("token"))
+	if err != nil {
+		log.Printf("Error loading invite: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return nil
+	}
+	if invite == nil || !invite.CanUse(appContext.Email) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return nil
+	}
+	return invite
+}
+
+func ViewPartyInvite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.HTML(http.StatusUnauthorized, "signin.html", gin.H{
+				"context":  appContext,
+				"redirect": c.Request.URL,
+			})
+			return
+		}
+
+		invite := loadUsableInvite(c, db)
+		if invite == nil {
+			return
+		}
+		appContext.Year = int(invite.Year)
+
+		c.Header("Cache-Control", "no-cache")
+		c.HTML(http.StatusOK, "invite.html", gin.H{
+			"context": appContext,
+			"invite":  invite,
+		})
+	}
+}
+
+func AcceptPartyInvite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		invite := loadUsableInvite(c, db)
+		if invite == nil {
+			return
+		}
+
+		log.Printf("%v is joining party %v", appContext.Email, invite.PartyId)
+		if err := postgres.AcceptPartyInvite(db, invite, appContext.Email); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, fmt.Sprintf("/party/%d", invite.PartyId))
+	}
+}
+
+func DeclinePartyInvite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		invite := loadUsableInvite(c, db)
+		if invite == nil {
+			return
+		}
+
+		// Join links are shared, so one person saying no shouldn't break it
+		// for everyone else.
+		if !invite.IsLink() {
+			if err := postgres.DeletePartyInvite(db, invite.Token); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+		c.Redirect(http.StatusSeeOther, "/user")
+	}
+}
+
+// Only the owner can kick people out. Owners leave instead of removing
+// themselves.
+func RemovePartyMember(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+		if !party.IsOwner(appContext.Email) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		email := c.PostForm("email")
+		if email == appContext.Email || !party.HasMember(email) {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		if err := postgres.RemovePartyMember(db, party.Id, email); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, partyUrl(party))
+	}
+}
+
+func LeaveParty(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+
+		if err := postgres.LeaveParty(db, party, appContext.Email); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/user")
+	}
+}
+
+func DeleteParty(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		party := loadMemberParty(c, db)
+		if party == nil {
+			return
+		}
+		if !party.IsOwner(appContext.Email) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		log.Printf("%v is deleting party %v", appContext.Email, party.Id)
+		if err := postgres.DeleteParty(db, party.Id); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/user")
+	}
+}
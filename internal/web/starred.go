@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/schedule"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -132,9 +133,47 @@ func StarredPage(db *sql.DB) func(c *gin.Context) {
 			return
 		}
 
+		starredInfo, err := postgres.LoadStarredInfo(db, appContext.Email, appContext.Year)
+		if err != nil {
+			log.Printf("Error loading starred info")
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		// Without the venue conflicts fall back to guessing walking times
+		venue, err := postgres.LoadVenue(db)
+		if err != nil {
+			log.Printf("Error loading the venue: %v", err)
+		}
+		conflicts := schedule.NewVenueAnalyzer(venue).FindConflicts(postgres.StarredEntries(starredEvents, starredInfo))
+
+		// Look up the last visit before recording this one, otherwise nothing
+		// would ever be new.
+		visitPage := "starred:" + strconv.Itoa(appContext.Year)
+		lastVisit, err := postgres.LastVisit(db, appContext.Email, visitPage)
+		if err != nil {
+			log.Printf("Error loading last visit: %v", err)
+		}
+		changes, changedTitles, err := postgres.LoadStarredChanges(db, appContext.Email, appContext.Year, lastVisit)
+		if err != nil {
+			log.Printf("Error loading starred changes: %v", err)
+		}
+		if err = postgres.RecordVisit(db, appContext.Email, visitPage); err != nil {
+			log.Printf("Error recording visit: %v", err)
+		}
+
 		startDate := GenconStartDate(appContext.Year)
 		endDate := GenconEndDate(appContext.Year)
 
+		calendarToken, err := postgres.LoadOrCreateCalendarToken(db, appContext.Email)
+		if err != nil {
+			// Not worth failing the page over, just hide the subscribe link
+			log.Printf("Error loading calendar token: %v", err)
+		}
+		calendarUrl := ""
+		if len(calendarToken) > 0 {
+			calendarUrl = calendarFeedUrl(c, calendarToken, appContext.Year)
+		}
+
 		c.Header("Cache-Control", "no-cache")
 		c.HTML(http.StatusOK, "starred.html", gin.H{
 			"context":          appContext,
@@ -144,6 +183,11 @@ func StarredPage(db *sql.DB) func(c *gin.Context) {
 			"calendarGroups":   groupedEvents,
 			"startDate":        startDate,
 			"endDate":          endDate,
+			"calendarUrl":      calendarUrl,
+			"conflicts":        conflicts,
+			"changes":          events.CollapseChanges(changes),
+			"changedTitles":    changedTitles,
+			"lastVisit":        lastVisit,
 		})
 	}
 }
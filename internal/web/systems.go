@@ -0,0 +1,99 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// A game system that isn't on BGG, with names from BGG it might be
+type UnmappedSystem struct {
+	GameSystem  string
+	NumEvents   int
+	Suggestions []string
+}
+
+// Enough to work through in one sitting, suggestions aren't cheap
+const maxUnmappedSystems = 100
+
+func renderGameSystems(c *gin.Context, db *sql.DB, message string) {
+	appContext := c.MustGet("context").(*Context)
+	appContext.Year = time.Now().Year()
+	if year, err := strconv.Atoi(c.Query("year")); err == nil {
+		appContext.Year = year
+	}
+
+	aliases, err := postgres.LoadGameSystemAliases(db)
+	if err != nil {
+		log.Printf("Error loading game system aliases: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	counts, err := postgres.LoadGameSystemCounts(db, appContext.Year)
+	if err != nil {
+		log.Printf("Error loading game systems: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	unmapped := make([]*UnmappedSystem, 0)
+	for _, count := range counts {
+		if len(unmapped) == maxUnmappedSystems {
+			break
+		}
+		if appContext.BggCache.FindGame(count.GameSystem) != nil {
+			continue
+		}
+		unmapped = append(unmapped, &UnmappedSystem{
+			GameSystem:  count.GameSystem,
+			NumEvents:   count.NumEvents,
+			Suggestions: appContext.BggCache.SuggestGames(count.GameSystem, 3),
+		})
+	}
+
+	c.HTML(http.StatusOK, "systems.html", gin.H{
+		"context":  appContext,
+		"aliases":  aliases,
+		"unmapped": unmapped,
+		"message":  message,
+	})
+}
+
+func ViewGameSystems(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		renderGameSystems(c, db, "")
+	}
+}
+
+func SaveGameSystemAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		gameSystem := c.PostForm("game_system")
+		changed, err := postgres.SaveGameSystemAlias(db, alias, gameSystem)
+		if err != nil {
+			log.Printf("Error saving game system alias %q: %v", alias, err)
+			renderGameSystems(c, db, fmt.Sprintf("Couldn't save %q: %v", alias, err))
+			return
+		}
+		renderGameSystems(c, db, fmt.Sprintf("%q is now %q, renamed %d events", alias, gameSystem, changed))
+	}
+}
+
+func DeleteGameSystemAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		changed, err := postgres.DeleteGameSystemAlias(db, alias)
+		if err != nil {
+			log.Printf("Error deleting game system alias %q: %v", alias, err)
+			renderGameSystems(c, db, fmt.Sprintf("Couldn't delete %q: %v", alias, err))
+			return
+		}
+		renderGameSystems(c, db, fmt.Sprintf("Removed %q, renamed %d events back", alias, changed))
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Encinarus/genconplanner/internal/background"
+	"github.com/Encinarus/genconplanner/internal/ical"
 )
 
 var textStrippingRegex, _ = regexp.Compile("[^a-zA-Z0-9]+")
@@ -25,9 +27,19 @@ func GetTemplateFunctions(cache *background.GameCache) template.FuncMap {
 		"bggRating":     func(gameName string) string { return bggRating(gameName, cache) },
 		"bggNumRatings": func(gameName string) string { return bggNumRatings(gameName, cache) },
 		"bggYear":       func(gameName string) string { return bggYear(gameName, cache) },
+		"minutes":       minutes,
+		"eventLocation": ical.FormatLocation,
 	}
 }
 
+// Whole minutes in the duration, ignoring the sign
+func minutes(d time.Duration) int {
+	if d < 0 {
+		d = -d
+	}
+	return int(d / time.Minute)
+}
+
 func dict(v ...interface{}) map[string]interface{} {
 	dict := map[string]interface{}{}
 	lenv := len(v)
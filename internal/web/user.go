@@ -2,11 +2,14 @@ package web
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/Encinarus/genconplanner/internal/notify"
 	"github.com/Encinarus/genconplanner/internal/postgres"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,14 +37,53 @@ func User(db *sql.DB) gin.HandlerFunc {
 			log.Printf("Num parties: %v", len(parties))
 		}
 
+		invites, err := postgres.LoadPendingInvites(db, appContext.Email)
+		if err != nil {
+			log.Printf("Unable to load party invites: %v", err)
+		}
+
+		notificationPrefs, err := postgres.LoadNotificationPreferences(db, appContext.Email)
+		if err != nil {
+			log.Printf("Unable to load notification preferences: %v", err)
+		}
+
 		c.HTML(http.StatusOK, "user.html", gin.H{
-			"context": appContext,
-			"user":    appContext.User,
-			"parties": parties,
+			"context":       appContext,
+			"user":          appContext.User,
+			"parties":       parties,
+			"invites":       invites,
+			"notifications": notificationPrefs,
 		})
 	}
 }
 
+// Unchecked boxes aren't sent, so every field of the form is saved at once.
+func SaveNotificationPreferences(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if appContext.Email == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		prefs := notify.DefaultPreferences(appContext.Email)
+		_, prefs.SendEmail = c.GetPostForm("sendEmail")
+		_, prefs.Digest = c.GetPostForm("digest")
+		_, prefs.Tickets = c.GetPostForm("tickets")
+		prefs.WebhookUrl = strings.TrimSpace(c.PostForm("webhookUrl"))
+		if prefs.WebhookUrl != "" && !strings.HasPrefix(prefs.WebhookUrl, "https://") {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("webhooks must use https"))
+			return
+		}
+
+		if err := postgres.SaveNotificationPreferences(db, prefs); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/user")
+	}
+}
+
 func UserNameChange(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		year, err := strconv.Atoi(c.Param("year"))
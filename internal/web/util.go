@@ -1,13 +1,12 @@
 package web
 
 import (
-	"bytes"
 	"cmp"
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"slices"
 	"sort"
@@ -19,6 +18,7 @@ import (
 	"github.com/Encinarus/genconplanner/internal/background"
 	"github.com/Encinarus/genconplanner/internal/events"
 	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/Encinarus/genconplanner/internal/search"
 	"github.com/gin-gonic/gin"
 )
 
@@ -83,48 +83,10 @@ func parseQuery(params QueryParams) *postgres.ParsedQuery {
 
 	log.Printf("Search query: %v", query)
 
-	// Preprocess, removing symbols which are used in tsquery
-	params.Query = strings.Replace(params.Query, "!", "", -1)
-	params.Query = strings.Replace(params.Query, "&", "", -1)
-	params.Query = strings.Replace(params.Query, "(", "", -1)
-	params.Query = strings.Replace(params.Query, ")", "", -1)
-	params.Query = strings.Replace(params.Query, "|", "", -1)
-
-	queryReader := csv.NewReader(bytes.NewBufferString(params.Query))
-	queryReader.Comma = ' '
-
-	splitQuery, _ := queryReader.Read()
-
-	for _, term := range splitQuery {
-		invertTerm := false
-		if strings.HasPrefix(term, "-") {
-			term = strings.TrimLeft(term, "-")
-			invertTerm = true
-		}
-		if strings.ContainsAny(term, ":<>=-~") {
-			// TODO(alek) Handle key:value searches
-			// : and = work as equals
-			// < > compare for dates or num tickets
-			// ~ is for checking if the string is in a field
-			continue
-		}
-
-		// Now remove remaining symbols we want to allow in field-specific
-		// searches, but not in the general text search
-		term = strings.Replace(term, "<", "", -1)
-		term = strings.Replace(term, ">", "", -1)
-		term = strings.Replace(term, "=", "", -1)
-		term = strings.Replace(term, "-", "", -1)
-		term = strings.Replace(term, "~", "", -1)
-		term = strings.TrimSpace(term)
-		if len(term) == 0 {
-			continue
-		}
-		if invertTerm {
-			term = "!" + term
-		}
-		query.TextQueries = append(query.TextQueries, term)
-	}
+	parsed := search.Parse(params.Query)
+	query.TextQueries = parsed.Text
+	query.Filters = parsed.Filters
+	query.Errors = parsed.Errors
 	return &query
 }
 
@@ -272,6 +234,35 @@ func BootstrapContext(app *firebase.App, db *sql.DB, bggCache *background.GameCa
 	}
 }
 
+// Emails allowed into the admin pages, from the comma separated ADMIN_EMAILS
+func adminEmails() map[string]bool {
+	admins := make(map[string]bool)
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); len(email) > 0 {
+			admins[email] = true
+		}
+	}
+	return admins
+}
+
+// Guards the admin pages, only users signed in with an email from
+// ADMIN_EMAILS get through. Nobody does when it isn't set. Runs after
+// BootstrapContext, which checks the firebase login.
+func RequireAdmin() gin.HandlerFunc {
+	admins := adminEmails()
+	if len(admins) == 0 {
+		log.Printf("No ADMIN_EMAILS set, the admin pages are closed")
+	}
+	return func(c *gin.Context) {
+		appContext := c.MustGet("context").(*Context)
+		if !admins[strings.ToLower(appContext.Email)] {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
 func PartitionGroups(
 	groups []*postgres.EventGroup,
 	context *Context,
@@ -0,0 +1,209 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Encinarus/genconplanner/internal/events"
+	"github.com/Encinarus/genconplanner/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// One cell of the walking time matrix, Minutes is empty when it's unknown.
+// Each pair shows up twice, only the copy above the diagonal is a field.
+type WalkCell struct {
+	Field   string
+	Minutes string
+	Same    bool
+}
+
+type WalkRow struct {
+	Building *events.Building
+	Cells    []*WalkCell
+}
+
+func walkField(from, to string) string {
+	return "walk_" + from + "_" + to
+}
+
+func walkMatrix(tables *postgres.VenueTables) []*WalkRow {
+	venue := events.NewVenue(tables.Buildings, nil, nil, tables.Walks)
+	matrix := make([]*WalkRow, 0, len(tables.Buildings))
+	for i, from := range tables.Buildings {
+		row := &WalkRow{Building: from}
+		for j, to := range tables.Buildings {
+			cell := &WalkCell{Same: i == j}
+			if j > i {
+				cell.Field = walkField(from.Code, to.Code)
+			}
+			if walk, known := venue.Walk(from.Code, to.Code); known && !cell.Same {
+				cell.Minutes = strconv.Itoa(int(walk / time.Minute))
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		matrix = append(matrix, row)
+	}
+	return matrix
+}
+
+func renderVenue(c *gin.Context, db *sql.DB, message string) {
+	appContext := c.MustGet("context").(*Context)
+	appContext.Year = time.Now().Year()
+	if year, err := strconv.Atoi(c.Query("year")); err == nil {
+		appContext.Year = year
+	}
+
+	tables, err := postgres.LoadVenueTables(db, appContext.Year)
+	if err != nil {
+		log.Printf("Error loading the venue: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "venue.html", gin.H{
+		"context": appContext,
+		"tables":  tables,
+		"matrix":  walkMatrix(tables),
+		"message": message,
+	})
+}
+
+func ViewVenue(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		renderVenue(c, db, "")
+	}
+}
+
+func SaveBuilding(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.PostForm("code")
+		insideMinutes, err := strconv.Atoi(c.DefaultPostForm("inside_minutes", "0"))
+		if err != nil {
+			renderVenue(c, db, fmt.Sprintf("Couldn't save %q: minutes inside need to be a whole number", code))
+			return
+		}
+		moved, err := postgres.SaveBuilding(db, code, c.PostForm("name"), insideMinutes)
+		if err != nil {
+			log.Printf("Error saving building %q: %v", code, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't save %q: %v", code, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Saved %q, moved %d events", code, moved))
+	}
+}
+
+func DeleteBuilding(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.PostForm("code")
+		moved, err := postgres.DeleteBuilding(db, code)
+		if err != nil {
+			log.Printf("Error deleting building %q: %v", code, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't delete %q: %v", code, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Removed %q, %d events aren't in a building now", code, moved))
+	}
+}
+
+func SaveBuildingAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		code := c.PostForm("code")
+		moved, err := postgres.SaveBuildingAlias(db, alias, code)
+		if err != nil {
+			log.Printf("Error saving building alias %q: %v", alias, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't save %q: %v", alias, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("%q is now %v, moved %d events", alias, code, moved))
+	}
+}
+
+func DeleteBuildingAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		moved, err := postgres.DeleteBuildingAlias(db, alias)
+		if err != nil {
+			log.Printf("Error deleting building alias %q: %v", alias, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't delete %q: %v", alias, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Removed %q, moved %d events", alias, moved))
+	}
+}
+
+func SaveRoomAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		moved, err := postgres.SaveRoomAlias(db, alias, c.PostForm("building"), c.PostForm("room"))
+		if err != nil {
+			log.Printf("Error saving room alias %q: %v", alias, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't save %q: %v", alias, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Saved %q, moved %d events", alias, moved))
+	}
+}
+
+func DeleteRoomAlias(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		moved, err := postgres.DeleteRoomAlias(db, alias)
+		if err != nil {
+			log.Printf("Error deleting room alias %q: %v", alias, err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't delete %q: %v", alias, err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Removed %q, moved %d events", alias, moved))
+	}
+}
+
+// Saves the whole walking time matrix at once. Blank cells clear the time.
+func SaveWalks(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tables, err := postgres.LoadVenueTables(db, time.Now().Year())
+		if err != nil {
+			log.Printf("Error loading the venue: %v", err)
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		venue := events.NewVenue(tables.Buildings, nil, nil, tables.Walks)
+
+		walks := make([]*events.Walk, 0)
+		for i, from := range tables.Buildings {
+			for _, to := range tables.Buildings[i+1:] {
+				value, found := c.GetPostForm(walkField(from.Code, to.Code))
+				if !found {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				walk := &events.Walk{From: from.Code, To: to.Code, Time: -1}
+				if value != "" {
+					minutes, err := strconv.Atoi(value)
+					if err != nil || minutes < 0 {
+						renderVenue(c, db, fmt.Sprintf("Couldn't save walks: %q from %v to %v isn't a number of minutes", value, from.Name, to.Name))
+						return
+					}
+					walk.Time = time.Duration(minutes) * time.Minute
+				}
+				current, known := venue.Walk(from.Code, to.Code)
+				if (known && current == walk.Time) || (!known && walk.Time < 0) {
+					continue
+				}
+				walks = append(walks, walk)
+			}
+		}
+
+		if err = postgres.SaveWalks(db, walks); err != nil {
+			log.Printf("Error saving walks: %v", err)
+			renderVenue(c, db, fmt.Sprintf("Couldn't save walks: %v", err))
+			return
+		}
+		renderVenue(c, db, fmt.Sprintf("Saved %d walking times", len(walks)))
+	}
+}